@@ -0,0 +1,307 @@
+// Package aix implements the hashing algorithms of the AIX Loadable Password Algorithms (LPA)
+// found in /etc/security/passwd: {smd5}, {ssha1}, {ssha256} and {ssha512}.
+package aix
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"hash"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/hashutil"
+	"github.com/sergeymakinen/go-crypt/md5/md5crypt"
+)
+
+const (
+	PrefixSMD5    = "{smd5}"
+	PrefixSSHA1   = "{ssha1}"
+	PrefixSSHA256 = "{ssha256}"
+	PrefixSSHA512 = "{ssha512}"
+)
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+const (
+	MinSaltLength     = 8
+	MaxSaltLength     = 24
+	DefaultSaltLength = 16
+	SMD5SaltLength    = 8
+)
+
+// InvalidSaltLengthError values describe errors resulting from an invalid length of a salt.
+type InvalidSaltLengthError int
+
+func (e InvalidSaltLengthError) Error() string {
+	return "invalid salt length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidSaltError values describe errors resulting from an invalid character in a hash string.
+type InvalidSaltError byte
+
+func (e InvalidSaltError) Error() string {
+	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in salt"
+}
+
+const (
+	MinCost     = 4
+	MaxCost     = 31
+	DefaultCost = 10
+)
+
+// InvalidCostError values describe errors resulting from an invalid cost.
+type InvalidCostError uint8
+
+func (e InvalidCostError) Error() string {
+	return "invalid cost " + strconv.FormatUint(uint64(e), 10)
+}
+
+// CompatibilityOptions are the key derivation parameters required to produce keys from old/non-standard hashes.
+type CompatibilityOptions struct {
+	Prefix string
+}
+
+func prefixHash(prefix string) func() hash.Hash {
+	switch prefix {
+	case PrefixSSHA1:
+		return sha1.New
+	case PrefixSSHA256:
+		return sha256.New
+	case PrefixSSHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// Key returns an AIX key derived from the password, salt, cost and compatibility options.
+// The cost is the base-2 logarithm of the PBKDF2 iteration count and is ignored for {smd5}.
+//
+// The opts parameter is optional. If nil, default options are used.
+// Returns crypt.ErrNotApproved for {smd5} in FIPS 140 mode.
+func Key(password, salt []byte, cost uint8, opts *CompatibilityOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &CompatibilityOptions{Prefix: PrefixSSHA512}
+	}
+	if opts.Prefix == PrefixSMD5 {
+		if cryptoutil.FIPS140Enabled() {
+			return nil, crypt.ErrNotApproved
+		}
+		if n := len(salt); n > SMD5SaltLength {
+			return nil, InvalidSaltLengthError(n)
+		}
+		if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
+			return nil, InvalidSaltError(salt[i])
+		}
+		// {smd5} is MD5-crypt without the magic string
+		return md5crypt.Encrypt(password, salt, nil), nil
+	}
+	h := prefixHash(opts.Prefix)
+	if h == nil {
+		return nil, UnsupportedPrefixError(opts.Prefix)
+	}
+	if n := len(salt); n < MinSaltLength || n > MaxSaltLength {
+		return nil, InvalidSaltLengthError(n)
+	}
+	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
+		return nil, InvalidSaltError(salt[i])
+	}
+	if cost < MinCost || cost > MaxCost {
+		return nil, InvalidCostError(cost)
+	}
+	return pbkdf2.Key(h, string(password), salt, 1<<cost, h().Size())
+}
+
+// encode encodes the key with the crypt(3) alphabet of hash.BigEndianEncoding,
+// emitting the 6-bit groups of every 3 bytes from the least significant one.
+func encode(key []byte) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i += 3 {
+		var group [3]byte
+		n := copy(group[:], key[i:])
+		var enc [4]byte
+		crypthash.BigEndianEncoding.Encode(enc[:], group[:])
+		for j := 3; j >= 3-n; j-- {
+			b.WriteByte(enc[j])
+		}
+	}
+	return b.String()
+}
+
+func encodedLen(n int) int {
+	return (n*8 + 5) / 6
+}
+
+type scheme struct {
+	Prefix string
+	Cost   uint8
+	Salt   []byte
+	Sum    string
+}
+
+func (s *scheme) String() string {
+	if s.Prefix == PrefixSMD5 {
+		return s.Prefix + string(s.Salt) + "$" + s.Sum
+	}
+	cost := strconv.FormatUint(uint64(s.Cost), 10)
+	if s.Cost < 10 {
+		cost = "0" + cost
+	}
+	return s.Prefix + cost + "$" + string(s.Salt) + "$" + s.Sum
+}
+
+func parseHash(hash string) (*scheme, error) {
+	i := strings.IndexByte(hash, '}')
+	if !strings.HasPrefix(hash, "{") || i < 0 {
+		return nil, &parse.SyntaxError{Offset: 0, Msg: "prefix not found"}
+	}
+	s := &scheme{Prefix: hash[:i+1]}
+	offset := len(s.Prefix)
+	fields := strings.Split(hash[offset:], "$")
+	keyLen := 16
+	switch s.Prefix {
+	case PrefixSMD5:
+		if len(fields) != 2 {
+			return nil, &parse.SyntaxError{Offset: len(hash), Msg: "expected 2 fields"}
+		}
+		fields = append([]string{""}, fields...)
+	case PrefixSSHA1, PrefixSSHA256, PrefixSSHA512:
+		if len(fields) != 3 {
+			return nil, &parse.SyntaxError{Offset: len(hash), Msg: "expected 3 fields"}
+		}
+		if len(fields[0]) != 2 {
+			return nil, &parse.SyntaxError{Offset: offset, Msg: "invalid cost"}
+		}
+		cost, err := strconv.ParseUint(fields[0], 10, 8)
+		if err != nil {
+			return nil, &parse.SyntaxError{Offset: offset, Msg: "invalid cost"}
+		}
+		s.Cost = uint8(cost)
+		offset += len(fields[0]) + 1
+		keyLen = prefixHash(s.Prefix)().Size()
+	default:
+		return nil, UnsupportedPrefixError(s.Prefix)
+	}
+	s.Salt = []byte(fields[1])
+	offset += len(fields[1]) + 1
+	if len(fields[2]) != encodedLen(keyLen) {
+		return nil, &parse.SyntaxError{Offset: offset, Msg: "invalid hash length"}
+	}
+	if i := hashutil.HashEncoding.IndexAnyInvalid([]byte(fields[2])); i >= 0 {
+		return nil, &parse.SyntaxError{Offset: offset + i, Msg: "invalid character " + strconv.QuoteRuneToASCII(rune(fields[2][i]))}
+	}
+	s.Sum = fields[2]
+	return s, nil
+}
+
+func (s *scheme) sum(key []byte) string {
+	if s.Prefix == PrefixSMD5 {
+		return crypthash.LittleEndianEncoding.EncodeToString(key)
+	}
+	return encode(key)
+}
+
+// NewHash returns the AIX hash of the password with the given prefix and cost.
+// The cost is ignored for {smd5}, and {smd5} returns crypt.ErrNotApproved in FIPS 140 mode.
+func NewHash(prefix, password string, cost uint8) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(prefix, password, cost)
+	})
+}
+
+func newHash(prefix, password string, cost uint8) (string, error) {
+	s := scheme{
+		Prefix: prefix,
+		Cost:   cost,
+		Salt:   hashutil.HashEncoding.Rand(DefaultSaltLength),
+	}
+	if prefix == PrefixSMD5 {
+		s.Cost = 0
+		s.Salt = hashutil.HashEncoding.Rand(SMD5SaltLength)
+	}
+	key, err := Key([]byte(password), s.Salt, s.Cost, &CompatibilityOptions{Prefix: prefix})
+	if err != nil {
+		return "", err
+	}
+	s.Sum = s.sum(key)
+	return s.String(), nil
+}
+
+// Params returns the hashing salt, cost and compatibility options used to create
+// the given AIX hash.
+func Params(hash string) (salt []byte, cost uint8, opts *CompatibilityOptions, err error) {
+	s, err := parseHash(hash)
+	if err != nil {
+		return
+	}
+	return s.Salt, s.Cost, &CompatibilityOptions{Prefix: s.Prefix}, nil
+}
+
+var (
+	hashAlgorithm = &crypt.Hash{
+		Algorithm: "aix",
+		Check:     check,
+		Params:    hookParams,
+		Approved:  true,
+	}
+
+	// {smd5} uses MD5, which is not approved by FIPS 140
+	smd5HashAlgorithm = &crypt.Hash{
+		Algorithm: "aix",
+		Check:     check,
+		Params:    hookParams,
+	}
+)
+
+// hookParams returns the prefix and cost of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, cost, opts, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String("prefix", opts.Prefix),
+		slog.Uint64("cost", uint64(cost)),
+	}
+}
+
+// Check compares the given AIX hash with a new hash derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
+	s, err := parseHash(hash)
+	if err != nil {
+		return err
+	}
+	key, err := Key([]byte(password), s.Salt, s.Cost, &CompatibilityOptions{Prefix: s.Prefix})
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(s.sum(key)), []byte(s.Sum)) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+func init() {
+	crypt.Register(PrefixSMD5, smd5HashAlgorithm)
+	crypt.Register(PrefixSSHA1, hashAlgorithm)
+	crypt.Register(PrefixSSHA256, hashAlgorithm)
+	crypt.Register(PrefixSSHA512, hashAlgorithm)
+}
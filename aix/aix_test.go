@@ -0,0 +1,150 @@
+package aix
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestParse(t *testing.T) {
+	// Hashcat
+	tests := []struct {
+		hash string
+		salt []byte
+		cost uint8
+		opts *CompatibilityOptions
+	}{
+		{
+			hash: "{smd5}a5/yTL/u$VfvgyHx1xUlXZYBocQpQY0",
+			salt: []byte("a5/yTL/u"),
+			opts: &CompatibilityOptions{Prefix: PrefixSMD5},
+		},
+		{
+			hash: "{ssha1}06$bJbkFGJAB30L2e23$dCESGOsP7jaIIAJ1QAcmaGeG.kr",
+			salt: []byte("bJbkFGJAB30L2e23"),
+			cost: 6,
+			opts: &CompatibilityOptions{Prefix: PrefixSSHA1},
+		},
+		{
+			hash: "{ssha256}06$aJckFGJAB30LTe10$ohUsB7LBPlgclE3hJg9x042DLJvQyxVCX.nZZLEz.g2",
+			salt: []byte("aJckFGJAB30LTe10"),
+			cost: 6,
+			opts: &CompatibilityOptions{Prefix: PrefixSSHA256},
+		},
+		{
+			hash: "{ssha512}06$bJbkFGJAB30L2e23$bXiXjyH5YGIyoWWmEVwq67nCU5t7GLy9HkCzrodRCQCx3r9VvG98o7O3V0r9cVrX3LPPGuHqT5LLn0oGCuI1..",
+			salt: []byte("bJbkFGJAB30L2e23"),
+			cost: 6,
+			opts: &CompatibilityOptions{Prefix: PrefixSSHA512},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			salt, cost, opts, err := Params(test.hash)
+			if err != nil {
+				t.Fatalf("Params() = _, _, _, %v; want nil", err)
+			}
+			if !bytes.Equal(salt, test.salt) || cost != test.cost || *opts != *test.opts {
+				t.Errorf("Params() = %q, %d, %+v, _; want %q, %d, %+v", salt, cost, *opts, test.salt, test.cost, *test.opts)
+			}
+			if err := Check(test.hash, "hashcat"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := crypt.Check(test.hash, "password"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("crypt.Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestParseShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "ssha1}06$bJbkFGJAB30L2e23$dCESGOsP7jaIIAJ1QAcmaGeG.kr",
+			err:  &parse.SyntaxError{Offset: 0, Msg: "prefix not found"},
+		},
+		{
+			hash: "{ssha384}06$bJbkFGJAB30L2e23$dCESGOsP7jaIIAJ1QAcmaGeG.kr",
+			err:  UnsupportedPrefixError("{ssha384}"),
+		},
+		{
+			hash: "{ssha1}06$bJbkFGJAB30L2e23",
+			err:  &parse.SyntaxError{Offset: 26, Msg: "expected 3 fields"},
+		},
+		{
+			hash: "{ssha1}6$bJbkFGJAB30L2e23$dCESGOsP7jaIIAJ1QAcmaGeG.kr",
+			err:  &parse.SyntaxError{Offset: 7, Msg: "invalid cost"},
+		},
+		{
+			hash: "{ssha1}06$bJbkFGJAB30L2e23$dCESGOsP7jaIIAJ1QAcmaGeG.k",
+			err:  &parse.SyntaxError{Offset: 27, Msg: "invalid hash length"},
+		},
+		{
+			hash: "{ssha1}06$bJbkFGJAB30L2e23$dCESGOsP7jaIIAJ1QAcmaGeG@kr",
+			err:  &parse.SyntaxError{Offset: 51, Msg: "invalid character '@'"},
+		},
+		{
+			hash: "{ssha1}03$bJbkFGJAB30L2e23$dCESGOsP7jaIIAJ1QAcmaGeG.kr",
+			err:  InvalidCostError(3),
+		},
+		{
+			hash: "{ssha1}06$bJbkFGJ$dCESGOsP7jaIIAJ1QAcmaGeG.kr",
+			err:  InvalidSaltLengthError(7),
+		},
+		{
+			hash: "{smd5}a5/yTL/u@$VfvgyHx1xUlXZYBocQpQY0",
+			err:  InvalidSaltLengthError(9),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "hashcat"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	for _, prefix := range []string{PrefixSMD5, PrefixSSHA1, PrefixSSHA256, PrefixSSHA512} {
+		t.Run(prefix, func(t *testing.T) {
+			hash, err := NewHash(prefix, "password", MinCost)
+			if err != nil {
+				t.Fatalf("NewHash() = _, %v; want nil", err)
+			}
+			if err := crypt.Check(hash, "password"); err != nil {
+				t.Errorf("crypt.Check() = %v; want nil", err)
+			}
+		})
+	}
+	if _, err := NewHash(PrefixSSHA512, "password", MaxCost+1); err != InvalidCostError(MaxCost+1) {
+		t.Errorf("NewHash() = _, %v; want %v", err, InvalidCostError(MaxCost+1))
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	if err := Check("{smd5}a5/yTL/u$VfvgyHx1xUlXZYBocQpQY0", "hashcat"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := NewHash(PrefixSMD5, "password", 0); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+	hash, err := NewHash(PrefixSSHA256, "password", 6)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err = Check(hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+}
@@ -0,0 +1,17 @@
+package aix_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt"
+	_ "github.com/sergeymakinen/go-crypt/aix"
+)
+
+func Example() {
+	hash := "{ssha256}06$aJckFGJAB30LTe10$ohUsB7LBPlgclE3hJg9x042DLJvQyxVCX.nZZLEz.g2"
+	fmt.Println(crypt.Check(hash, "hashcat"))
+	fmt.Println(crypt.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
@@ -4,6 +4,7 @@ package argon2
 import (
 	"crypto/subtle"
 	"encoding/base64"
+	"log/slog"
 	"strconv"
 
 	"github.com/sergeymakinen/go-crypt"
@@ -101,11 +102,33 @@ type CompatibilityOptions struct {
 
 const keyLen = 32
 
+func validateParams(salt []byte, memory, time uint32, threads uint8) error {
+	if n := len(salt); n < MinSaltLength {
+		return InvalidSaltLengthError(n)
+	}
+	if i := hashutil.Base64Encoding.IndexAnyInvalid(salt); i >= 0 {
+		return InvalidSaltError(salt[i])
+	}
+	if memory < MinMemory {
+		return InvalidMemoryError(memory)
+	}
+	if time < MinTime {
+		return InvalidTimeError(time)
+	}
+	if threads < MinThreads {
+		return InvalidThreadsError(threads)
+	}
+	return nil
+}
+
 // Key returns an Argon2 key derived from the password, salt, memory and time costs,
 // threads and compatibility options.
 //
 // The opts parameter is optional. If nil, default options are used.
 func Key(password, salt []byte, memory, time uint32, threads uint8, opts *CompatibilityOptions) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
 	if opts == nil {
 		opts = &CompatibilityOptions{
 			Prefix:  Prefix2id,
@@ -132,23 +155,11 @@ func Key(password, salt []byte, memory, time uint32, threads uint8, opts *Compat
 	default:
 		return nil, UnsupportedVersionError(opts.Version)
 	}
-	if n := len(salt); n < MinSaltLength {
-		return nil, InvalidSaltLengthError(n)
-	}
-	if i := hashutil.Base64Encoding.IndexAnyInvalid(salt); i >= 0 {
-		return nil, InvalidSaltError(salt[i])
+	if err := validateParams(salt, memory, time, threads); err != nil {
+		return nil, err
 	}
 	decSalt := make([]byte, base64.RawStdEncoding.DecodedLen(len(salt)))
 	base64.RawStdEncoding.Decode(decSalt, salt)
-	if memory < MinMemory {
-		return nil, InvalidMemoryError(memory)
-	}
-	if time < MinTime {
-		return nil, InvalidTimeError(time)
-	}
-	if threads < MinThreads {
-		return nil, InvalidThreadsError(threads)
-	}
 	return argon2crypto.Key(mode, version, password, decSalt, time, memory, threads, keyLen), nil
 }
 
@@ -174,8 +185,43 @@ type scheme struct {
 	Sum        []byte `hash:"enc:base64"`
 }
 
+// setting is a hash string without the sum.
+type setting struct {
+	HashPrefix hashPrefix
+	Version    uint8  `hash:"param:v,omitempty"`
+	Memory     uint32 `hash:"param:m,group"`
+	Time       uint32 `hash:"param:t,group"`
+	Threads    uint8  `hash:"param:p,group"`
+	Salt       []byte `hash:"enc:base64"`
+}
+
+func parseSetting(s string) (*setting, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &setting{
+			HashPrefix: scheme.HashPrefix,
+			Version:    scheme.Version,
+			Memory:     scheme.Memory,
+			Time:       scheme.Time,
+			Threads:    scheme.Threads,
+			Salt:       scheme.Salt,
+		}, nil
+	}
+	var setting setting
+	if err := crypthash.Unmarshal(s, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
 // NewHash returns the crypt(3) Argon2 hash of the password, memory and time costs.
 func NewHash(password string, memory, time uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, memory, time)
+	})
+}
+
+func newHash(password string, memory, time uint32) (string, error) {
 	scheme := scheme{
 		HashPrefix: Prefix2id,
 		Version:    Version13,
@@ -213,9 +259,34 @@ func Params(hash string) (salt []byte, memory, time uint32, threads uint8, opts
 	}, nil
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "argon2",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the prefix, version, memory and time costs and threads of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, memory, time, threads, opts, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String("prefix", opts.Prefix),
+		slog.Int("version", opts.Version),
+		slog.Uint64("memory", uint64(memory)),
+		slog.Uint64("time", uint64(time)),
+		slog.Uint64("threads", uint64(threads)),
+	}
+}
+
 // Check compares the given crypt(3) Argon2 hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -238,8 +309,75 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return "", err
+	}
+	version := int(setting.Version)
+	if version == 0 {
+		version = Version10
+	}
+	key, err := Key([]byte(password), setting.Salt, setting.Memory, setting.Time, setting.Threads, &CompatibilityOptions{
+		Prefix:  string(setting.HashPrefix),
+		Version: version,
+	})
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{
+		HashPrefix: setting.HashPrefix,
+		Version:    setting.Version,
+		Memory:     setting.Memory,
+		Time:       setting.Time,
+		Threads:    setting.Threads,
+		Salt:       setting.Salt,
+		Sum:        make([]byte, base64.RawStdEncoding.EncodedLen(len(key))),
+	}
+	base64.RawStdEncoding.Encode(scheme.Sum, key)
+	return crypthash.Marshal(scheme)
+}
+
+// genSalt returns a function returning a setting with the prefix,
+// DefaultMemory and the count time cost, or DefaultTime if 0.
+func genSalt(prefix string) func(count uint64, rbytes []byte) (string, error) {
+	return func(count uint64, rbytes []byte) (string, error) {
+		setting := setting{
+			HashPrefix: hashPrefix(prefix),
+			Version:    Version13,
+			Memory:     DefaultMemory,
+			Time:       uint32(min(count, 1<<32-1)),
+			Threads:    DefaultThreads,
+			Salt:       make([]byte, DefaultSaltLength),
+		}
+		if count == 0 {
+			setting.Time = DefaultTime
+		}
+		base64.RawStdEncoding.Encode(setting.Salt, rbytes[:base64.RawStdEncoding.DecodedLen(DefaultSaltLength)])
+		return crypthash.Marshal(setting)
+	}
+}
+
+func checkSetting(s string) error {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return err
+	}
+	switch setting.Version {
+	case 0, Version10, Version13:
+	default:
+		return UnsupportedVersionError(setting.Version)
+	}
+	return validateParams(setting.Salt, setting.Memory, setting.Time, setting.Threads)
+}
+
 func init() {
-	crypt.RegisterHash(Prefix2d, Check)
-	crypt.RegisterHash(Prefix2i, Check)
-	crypt.RegisterHash(Prefix2id, Check)
+	for _, prefix := range []string{Prefix2d, Prefix2i, Prefix2id} {
+		crypt.Register(prefix, hashAlgorithm)
+		crypt.RegisterMethod(prefix, &crypt.Method{
+			Crypt:        cryptSetting,
+			GenSalt:      genSalt(prefix),
+			CheckSetting: checkSetting,
+		})
+	}
 }
@@ -8,7 +8,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"github.com/sergeymakinen/go-crypt/internal/testutil"
 )
 
@@ -595,3 +597,76 @@ func TestNewHash(t *testing.T) {
 		})
 	}
 }
+
+func TestCrypt(t *testing.T) {
+	tests := []struct {
+		setting string
+		hash    string
+	}{
+		{
+			setting: "$argon2id$v=19$m=512,t=3,p=1$qXMlAYBABLl",
+			hash:    "$argon2id$v=19$m=512,t=3,p=1$qXMlAYBABLl$/OuG+qcZ1ntdTRfhUGFVp2YMcTPJ7aH3e4j7KIEnRho",
+		},
+		{
+			setting: "$argon2id$v=19$m=512,t=3,p=1$qXMlAYBABLl$/OuG+qcZ1ntdTRfhUGFVp2YMcTPJ7aH3e4j7KIEnRho",
+			hash:    "$argon2id$v=19$m=512,t=3,p=1$qXMlAYBABLl$/OuG+qcZ1ntdTRfhUGFVp2YMcTPJ7aH3e4j7KIEnRho",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			hash, err := crypt.Crypt("password", test.setting)
+			if err != nil {
+				t.Fatalf("Crypt() = _, %v; want nil", err)
+			}
+			if hash != test.hash {
+				t.Errorf("Crypt() = %q, _; want %q", hash, test.hash)
+			}
+		})
+	}
+}
+
+func TestGenSalt(t *testing.T) {
+	tests := []struct {
+		count   uint64
+		setting string
+	}{
+		{
+			count:   0,
+			setting: "$argon2id$v=19$m=4096,t=3,p=1$MDEyMzQ1Njc",
+		},
+		{
+			count:   1,
+			setting: "$argon2id$v=19$m=4096,t=1,p=1$MDEyMzQ1Njc",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			setting, err := crypt.GenSalt(Prefix2id, test.count, []byte("0123456789abcdef"))
+			if err != nil {
+				t.Fatalf("GenSalt() = _, %v; want nil", err)
+			}
+			if setting != test.setting {
+				t.Errorf("GenSalt() = %q, _; want %q", setting, test.setting)
+			}
+			if status := crypt.CheckSalt(setting); status != crypt.SaltOK {
+				t.Errorf("CheckSalt() = %v; want %v", status, crypt.SaltOK)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	if err := Check("$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$wWKIMhR9lyDFvRz9YTZweHKfbftvj+qf+YFY4NeBbtA", "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := crypt.Crypt("password", "$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$wWKIMhR9lyDFvRz9YTZweHKfbftvj+qf+YFY4NeBbtA"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Crypt() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := NewHash("password", DefaultMemory, DefaultTime); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
@@ -0,0 +1,314 @@
+// Package aspnet implements the ASP.NET Identity password hashing algorithm (PasswordHasher V2 and V3).
+//
+// The hashes are base64-encoded binary values without a distinctive prefix,
+// so the package registers a hash matcher for use by crypt.Check.
+package aspnet
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"hash"
+	"log/slog"
+	"strconv"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+const (
+	MinSaltLength     = 16
+	DefaultSaltLength = MinSaltLength
+)
+
+// InvalidSaltLengthError values describe errors resulting from an invalid length of a salt.
+type InvalidSaltLengthError int
+
+func (e InvalidSaltLengthError) Error() string {
+	return "invalid salt length " + strconv.FormatInt(int64(e), 10)
+}
+
+const (
+	MinIterations     = 1
+	V2Iterations      = 1000
+	DefaultIterations = 100000
+)
+
+// InvalidIterationsError values describe errors resulting from an invalid iteration count.
+type InvalidIterationsError uint32
+
+func (e InvalidIterationsError) Error() string {
+	return "invalid iteration count " + strconv.FormatUint(uint64(e), 10)
+}
+
+const (
+	Version2 = 0x00 // PBKDF2 with HMAC-SHA1, 128-bit salt, 256-bit subkey, 1000 iterations
+	Version3 = 0x01 // PBKDF2 with HMAC-SHA1/SHA256/SHA512, salt length and iterations stored in the hash
+)
+
+// UnsupportedVersionError values describe errors resulting from an unsupported format marker.
+type UnsupportedVersionError byte
+
+func (e UnsupportedVersionError) Error() string {
+	return "unsupported version 0x" + strconv.FormatUint(uint64(e), 16)
+}
+
+// PRF is the pseudo-random function of a V3 hash.
+type PRF uint32
+
+const (
+	HMACSHA1   PRF = 0
+	HMACSHA256 PRF = 1
+	HMACSHA512 PRF = 2
+)
+
+// UnsupportedPRFError values describe errors resulting from an unsupported pseudo-random function.
+type UnsupportedPRFError PRF
+
+func (e UnsupportedPRFError) Error() string {
+	return "unsupported PRF " + strconv.FormatUint(uint64(e), 10)
+}
+
+// CompatibilityOptions are the key derivation parameters required to produce keys from old/non-standard hashes.
+type CompatibilityOptions struct {
+	Version byte
+	PRF     PRF // ignored for V2 hashes
+}
+
+const (
+	subkeyLength    = 32
+	minSubkeyLength = 16
+	v3HeaderLength  = 13
+)
+
+// Key returns an ASP.NET Identity subkey derived from the password, raw salt, iterations
+// and compatibility options.
+//
+// The opts parameter is optional. If nil, default options are used.
+func Key(password, salt []byte, iterations uint32, opts *CompatibilityOptions) ([]byte, error) {
+	return key(password, salt, iterations, opts, subkeyLength)
+}
+
+func key(password, salt []byte, iterations uint32, opts *CompatibilityOptions, keyLen int) ([]byte, error) {
+	if opts == nil {
+		opts = &CompatibilityOptions{
+			Version: Version3,
+			PRF:     HMACSHA512,
+		}
+	}
+	var h func() hash.Hash
+	switch opts.Version {
+	case Version2:
+		h = sha1.New
+	case Version3:
+		switch opts.PRF {
+		case HMACSHA1:
+			h = sha1.New
+		case HMACSHA256:
+			h = sha256.New
+		case HMACSHA512:
+			h = sha512.New
+		default:
+			return nil, UnsupportedPRFError(opts.PRF)
+		}
+	default:
+		return nil, UnsupportedVersionError(opts.Version)
+	}
+	if n := len(salt); n < MinSaltLength {
+		return nil, InvalidSaltLengthError(n)
+	}
+	if iterations < MinIterations {
+		return nil, InvalidIterationsError(iterations)
+	}
+	return pbkdf2.Key(h, string(password), salt, int(iterations), keyLen)
+}
+
+type scheme struct {
+	Version    byte
+	PRF        PRF
+	Iterations uint32
+	Salt       []byte
+	Sum        []byte
+}
+
+func (s *scheme) String() string {
+	var b []byte
+	if s.Version == Version2 {
+		b = append(b, Version2)
+	} else {
+		b = append(b, Version3)
+		b = binary.BigEndian.AppendUint32(b, uint32(s.PRF))
+		b = binary.BigEndian.AppendUint32(b, s.Iterations)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(s.Salt)))
+	}
+	b = append(b, s.Salt...)
+	b = append(b, s.Sum...)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func parseHash(hash string) (*scheme, error) {
+	b, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil {
+		return nil, &parse.SyntaxError{Offset: int(err.(base64.CorruptInputError)), Msg: "invalid base64 data"}
+	}
+	if len(b) == 0 {
+		return nil, &parse.SyntaxError{Offset: 0, Msg: "missing version"}
+	}
+	switch b[0] {
+	case Version2:
+		if len(b) != 1+MinSaltLength+subkeyLength {
+			return nil, &parse.SyntaxError{Offset: len(hash), Msg: "length mismatch"}
+		}
+		return &scheme{
+			Version:    Version2,
+			Iterations: V2Iterations,
+			Salt:       b[1 : 1+MinSaltLength],
+			Sum:        b[1+MinSaltLength:],
+		}, nil
+	case Version3:
+		if len(b) < v3HeaderLength+MinSaltLength+minSubkeyLength {
+			return nil, &parse.SyntaxError{Offset: len(hash), Msg: "length mismatch"}
+		}
+		saltLen := int(binary.BigEndian.Uint32(b[9:]))
+		if saltLen < MinSaltLength || saltLen > len(b)-v3HeaderLength-minSubkeyLength {
+			return nil, InvalidSaltLengthError(saltLen)
+		}
+		return &scheme{
+			Version:    Version3,
+			PRF:        PRF(binary.BigEndian.Uint32(b[1:])),
+			Iterations: binary.BigEndian.Uint32(b[5:]),
+			Salt:       b[v3HeaderLength : v3HeaderLength+saltLen],
+			Sum:        b[v3HeaderLength+saltLen:],
+		}, nil
+	default:
+		return nil, UnsupportedVersionError(b[0])
+	}
+}
+
+// NewHash returns the ASP.NET Identity V3 hash of the password with the given iterations.
+// The hash uses PBKDF2 with HMAC-SHA512, the default of ASP.NET Core Identity 7 and later.
+func NewHash(password string, iterations uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, iterations)
+	})
+}
+
+func newHash(password string, iterations uint32) (string, error) {
+	scheme := scheme{
+		Version:    Version3,
+		PRF:        HMACSHA512,
+		Iterations: iterations,
+		Salt:       cryptoutil.Rand(DefaultSaltLength),
+	}
+	key, err := Key([]byte(password), scheme.Salt, scheme.Iterations, &CompatibilityOptions{
+		Version: scheme.Version,
+		PRF:     scheme.PRF,
+	})
+	if err != nil {
+		return "", err
+	}
+	scheme.Sum = key
+	return scheme.String(), nil
+}
+
+// NewV2Hash returns the ASP.NET Identity V2 hash of the password.
+func NewV2Hash(password string) (string, error) {
+	scheme := scheme{
+		Version:    Version2,
+		Iterations: V2Iterations,
+		Salt:       cryptoutil.Rand(MinSaltLength),
+	}
+	key, err := Key([]byte(password), scheme.Salt, scheme.Iterations, &CompatibilityOptions{Version: scheme.Version})
+	if err != nil {
+		return "", err
+	}
+	scheme.Sum = key
+	return scheme.String(), nil
+}
+
+// Params returns the hashing salt, iterations and compatibility options used to create
+// the given ASP.NET Identity hash.
+func Params(hash string) (salt []byte, iterations uint32, opts *CompatibilityOptions, err error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return
+	}
+	return scheme.Salt, scheme.Iterations, &CompatibilityOptions{
+		Version: scheme.Version,
+		PRF:     scheme.PRF,
+	}, nil
+}
+
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: MatcherName,
+	Check:     check,
+	Params:    hookParams,
+	Approved:  true,
+}
+
+// hookParams returns the version, PRF and iterations of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, iterations, opts, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{
+		slog.Uint64("version", uint64(opts.Version)),
+		slog.Uint64("prf", uint64(opts.PRF)),
+		slog.Uint64("iterations", uint64(iterations)),
+	}
+}
+
+// Check compares the given ASP.NET Identity hash with a new hash derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return err
+	}
+	// V3 hashes may have subkeys of any length of at least 128 bits
+	h, err := key([]byte(password), scheme.Salt, scheme.Iterations, &CompatibilityOptions{
+		Version: scheme.Version,
+		PRF:     scheme.PRF,
+	}, len(scheme.Sum))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(h, scheme.Sum) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+// MatcherName is the name of the ASP.NET Identity hash matcher registered for use by crypt.Check,
+// as used in the allow and deny lists of crypt registries.
+const MatcherName = "aspnet"
+
+// IsHash reports whether the given string looks like an ASP.NET Identity hash.
+func IsHash(hash string) bool {
+	b, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil || len(b) == 0 {
+		return false
+	}
+	switch b[0] {
+	case Version2:
+		return len(b) == 1+MinSaltLength+subkeyLength
+	case Version3:
+		return len(b) >= v3HeaderLength+MinSaltLength+minSubkeyLength && PRF(binary.BigEndian.Uint32(b[1:])) <= HMACSHA512
+	default:
+		return false
+	}
+}
+
+func init() {
+	crypt.RegisterMatcher(IsHash, hashAlgorithm)
+}
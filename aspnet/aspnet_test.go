@@ -0,0 +1,173 @@
+package aspnet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+var testSalt = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		hash       string
+		iterations uint32
+		opts       CompatibilityOptions
+	}{
+		{
+			hash:       "AAABAgMEBQYHCAkKCwwNDg8DCeL+Tgvf59D+SCjUHCNEFuLZv7Yc3Y9kOhHPv9/BGQ==",
+			iterations: V2Iterations,
+			opts:       CompatibilityOptions{Version: Version2},
+		},
+		{
+			hash:       "AQAAAAEAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q==",
+			iterations: 10000,
+			opts:       CompatibilityOptions{Version: Version3, PRF: HMACSHA256},
+		},
+		{
+			hash:       "AQAAAAIAAYagAAAAEAABAgMEBQYHCAkKCwwNDg/73hTTOMxvghBX8/SnisILxwGxHjepOzeQw1EOAZRz8w==",
+			iterations: 100000,
+			opts:       CompatibilityOptions{Version: Version3, PRF: HMACSHA512},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			salt, iterations, opts, err := Params(test.hash)
+			if err != nil {
+				t.Fatalf("Params() = _, _, _, %v; want nil", err)
+			}
+			if !bytes.Equal(salt, testSalt) || iterations != test.iterations || *opts != test.opts {
+				t.Errorf("Params() = %v, %d, %+v, _; want %v, %d, %+v", salt, iterations, *opts, testSalt, test.iterations, test.opts)
+			}
+			if !IsHash(test.hash) {
+				t.Errorf("IsHash() = false; want true")
+			}
+			if err := Check(test.hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := crypt.Check(test.hash, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("crypt.Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestParseShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  &parse.SyntaxError{Offset: 0, Msg: "missing version"},
+		},
+		{
+			hash: "AAABAgMEBQYHCAkKCwwNDg8DCeL+Tgvf59D+SCjUHCNEFuLZv7Yc3Y9kOhHPv9/BGQ=",
+			err:  &parse.SyntaxError{Offset: 67, Msg: "invalid base64 data"},
+		},
+		{
+			hash: "AAABAgMEBQYHCAkKCwwNDg8DCeL+Tgvf59D+SCjUHCNEFuLZv7Yc3Y9kOhHPv9/B",
+			err:  &parse.SyntaxError{Offset: 64, Msg: "length mismatch"},
+		},
+		{
+			hash: "AgAAAAEAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q==",
+			err:  UnsupportedVersionError(2),
+		},
+		{
+			hash: "AQAAAAEAACcQAAAADwABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q==",
+			err:  InvalidSaltLengthError(15),
+		},
+		{
+			hash: "AQAAAAMAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q==",
+			err:  UnsupportedPRFError(3),
+		},
+		{
+			hash: "AQAAAAEAAAAKAAAAEA==",
+			err:  &parse.SyntaxError{Offset: 20, Msg: "length mismatch"},
+		},
+		{
+			hash: "AQAAAAEAACcQAAAAIQABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q==",
+			err:  InvalidSaltLengthError(33),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParamsShortV3Hash(t *testing.T) {
+	expected := &parse.SyntaxError{Offset: 20, Msg: "length mismatch"}
+	if _, _, _, err := Params("AQAAAAEAAAAKAAAAEA=="); !testutil.IsEqualError(err, expected) {
+		t.Errorf("Params() = _, _, _, %v; want %v", err, expected)
+	}
+}
+
+func TestKey(t *testing.T) {
+	key, err := Key([]byte("password"), testSalt, V2Iterations, &CompatibilityOptions{Version: Version2})
+	if err != nil {
+		t.Fatalf("Key() = _, %v; want nil", err)
+	}
+	b, _ := base64.StdEncoding.DecodeString("AAABAgMEBQYHCAkKCwwNDg8DCeL+Tgvf59D+SCjUHCNEFuLZv7Yc3Y9kOhHPv9/BGQ==")
+	if expected := b[1+len(testSalt):]; !bytes.Equal(key, expected) {
+		t.Errorf("Key() = %v, _; want %v", key, expected)
+	}
+}
+
+func TestKeyShouldFail(t *testing.T) {
+	tests := []struct {
+		salt       []byte
+		iterations uint32
+		err        error
+	}{
+		{
+			salt:       testSalt[1:],
+			iterations: DefaultIterations,
+			err:        InvalidSaltLengthError(15),
+		},
+		{
+			salt:       testSalt,
+			iterations: 0,
+			err:        InvalidIterationsError(0),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			if _, err := Key([]byte("password"), test.salt, test.iterations, nil); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Key() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	hash, err := NewHash("password", DefaultIterations)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err := crypt.Check(hash, "password"); err != nil {
+		t.Errorf("crypt.Check() = %v; want nil", err)
+	}
+	_, iterations, opts, err := Params(hash)
+	if err != nil {
+		t.Fatalf("Params() = _, _, _, %v; want nil", err)
+	}
+	if expected := (CompatibilityOptions{Version: Version3, PRF: HMACSHA512}); iterations != DefaultIterations || *opts != expected {
+		t.Errorf("Params() = _, %d, %+v, _; want _, %d, %+v", iterations, *opts, DefaultIterations, expected)
+	}
+
+	hash, err = NewV2Hash("password")
+	if err != nil {
+		t.Fatalf("NewV2Hash() = _, %v; want nil", err)
+	}
+	if err := crypt.Check(hash, "password"); err != nil {
+		t.Errorf("crypt.Check() = %v; want nil", err)
+	}
+}
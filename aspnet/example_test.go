@@ -0,0 +1,26 @@
+package aspnet_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/aspnet"
+)
+
+func Example() {
+	hash := "AQAAAAEAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q=="
+	fmt.Println(crypt.Check(hash, "password"))
+	fmt.Println(crypt.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
+
+func ExampleParams() {
+	_, iterations, opts, _ := aspnet.Params("AQAAAAEAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q==")
+	fmt.Println(iterations)
+	fmt.Println(opts.PRF == aspnet.HMACSHA256)
+	// Output:
+	// 10000
+	// true
+}
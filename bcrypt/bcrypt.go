@@ -1,4 +1,5 @@
-// Package bcrypt implements the bcrypt hashing algorithm for crypt(3).
+// Package bcrypt implements the bcrypt hashing algorithm for crypt(3)
+// and the bcrypt_pbkdf key derivation function of OpenBSD.
 package bcrypt
 
 import (
@@ -6,6 +7,7 @@ import (
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
+	"log/slog"
 	"strconv"
 
 	"github.com/sergeymakinen/go-crypt"
@@ -53,6 +55,7 @@ const (
 	Prefix2  = "$2$"  // the original bcrypt specification
 	Prefix2a = "$2a$" // requires the string must be UTF-8 encoded and the null terminator must be included
 	Prefix2b = "$2b$" // fixing bug with storing the string length in an unsigned char
+	Prefix2y = "$2y$" // the crypt_blowfish equivalent of 2b
 )
 
 // UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
@@ -67,21 +70,37 @@ type CompatibilityOptions struct {
 	Prefix string
 }
 
+func validateParams(salt []byte, cost uint8) error {
+	if n := len(salt); n != SaltLength {
+		return InvalidSaltLengthError(n)
+	}
+	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
+		return InvalidSaltError(salt[i])
+	}
+	if cost < MinCost || cost > MaxCost {
+		return InvalidCostError(cost)
+	}
+	return nil
+}
+
 // Key returns a bcrypt key derived from the password, salt, cost and compatibility options.
 //
 // The opts parameter is optional. If nil, default options are used.
 func Key(password, salt []byte, cost uint8, opts *CompatibilityOptions) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
 	if opts == nil {
 		opts = &CompatibilityOptions{Prefix: Prefix2b}
 	}
 	switch opts.Prefix {
-	case Prefix2, Prefix2a, Prefix2b:
+	case Prefix2, Prefix2a, Prefix2b, Prefix2y:
 	default:
 		return nil, UnsupportedPrefixError(opts.Prefix)
 	}
 	n := len(password)
-	if opts.Prefix == Prefix2b && n > 72 {
-		// BUG: if the version is 2b and the string length is greater than 72,
+	if (opts.Prefix == Prefix2b || opts.Prefix == Prefix2y) && n > 72 {
+		// BUG: if the version is 2b or 2y and the string length is greater than 72,
 		// only first 72 characters will be used.
 		// It's intentional to emulate the old behavior.
 		password = password[:72]
@@ -91,17 +110,11 @@ func Key(password, salt []byte, cost uint8, opts *CompatibilityOptions) ([]byte,
 		// It's intentional to emulate the old behavior.
 		password = bytes.Repeat([]byte{'0'}, 72)
 	}
-	if n := len(salt); n != SaltLength {
-		return nil, InvalidSaltLengthError(n)
-	}
-	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
-		return nil, InvalidSaltError(salt[i])
+	if err := validateParams(salt, cost); err != nil {
+		return nil, err
 	}
 	decSalt := make([]byte, Encoding.DecodedLen(len(salt)))
 	Encoding.Decode(decSalt, salt)
-	if cost < MinCost || cost > MaxCost {
-		return nil, InvalidCostError(cost)
-	}
 	return encode(password, decSalt, cost, opts.Prefix)
 }
 
@@ -125,13 +138,19 @@ func setup(key, salt []byte, cost uint8, prefix string) (*blowfish.Cipher, error
 		// It's intentional to emulate the old behavior.
 		key = append(key, 0)
 	}
+	return expandState(key, salt, 1<<cost, key, salt)
+}
+
+// expandState returns a blowfish cipher salted with the key and salt
+// and then expanded n times with the first and the second keys in turn.
+func expandState(key, salt []byte, n int, first, second []byte) (*blowfish.Cipher, error) {
 	c, err := blowfish.NewSaltedCipher(key, salt)
 	if err != nil {
 		return nil, errors.New("failed to create blowfish cipher: " + err.Error())
 	}
-	for i, n := 0, 1<<cost; i < n; i++ {
-		blowfish.ExpandKey(key, c)
-		blowfish.ExpandKey(salt, c)
+	for i := 0; i < n; i++ {
+		blowfish.ExpandKey(first, c)
+		blowfish.ExpandKey(second, c)
 	}
 	return c, nil
 }
@@ -140,7 +159,7 @@ type hashPrefix string
 
 func (h *hashPrefix) UnmarshalText(text []byte) error {
 	switch s := hashPrefix(text); s {
-	case Prefix2, Prefix2a, Prefix2b:
+	case Prefix2, Prefix2a, Prefix2b, Prefix2y:
 		*h = s
 		return nil
 	default:
@@ -169,8 +188,37 @@ type scheme struct {
 	Sum        [sumLength]byte
 }
 
+// setting is a hash string without the sum.
+type setting struct {
+	HashPrefix hashPrefix
+	Cost       hashCost `hash:"length:2"`
+	Salt       []byte   `hash:"length:22"`
+}
+
+func parseSetting(s string) (*setting, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &setting{
+			HashPrefix: scheme.HashPrefix,
+			Cost:       scheme.Cost,
+			Salt:       scheme.Salt,
+		}, nil
+	}
+	var setting setting
+	if err := crypthash.Unmarshal(s, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
 // NewHash returns the crypt(3) bcrypt hash of the password at the given cost.
 func NewHash(password string, cost uint8) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, cost)
+	})
+}
+
+func newHash(password string, cost uint8) (string, error) {
 	scheme := scheme{
 		HashPrefix: Prefix2b,
 		Cost:       hashCost(cost),
@@ -195,9 +243,31 @@ func Params(hash string) (salt []byte, cost uint8, opts *CompatibilityOptions, e
 	return scheme.Salt, uint8(scheme.Cost), &CompatibilityOptions{Prefix: string(scheme.HashPrefix)}, nil
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "bcrypt",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the prefix and cost of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, cost, opts, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String("prefix", opts.Prefix),
+		slog.Uint64("cost", uint64(cost)),
+	}
+}
+
 // Check compares the given crypt(3) bcrypt hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -214,8 +284,57 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return "", err
+	}
+	key, err := Key([]byte(password), setting.Salt, uint8(setting.Cost), &CompatibilityOptions{Prefix: string(setting.HashPrefix)})
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{
+		HashPrefix: setting.HashPrefix,
+		Cost:       setting.Cost,
+		Salt:       setting.Salt,
+	}
+	Encoding.Encode(scheme.Sum[:], key)
+	return crypthash.Marshal(scheme)
+}
+
+// genSalt returns a function returning a setting with the prefix
+// and the count cost, or DefaultCost if 0.
+func genSalt(prefix string) func(count uint64, rbytes []byte) (string, error) {
+	return func(count uint64, rbytes []byte) (string, error) {
+		setting := setting{
+			HashPrefix: hashPrefix(prefix),
+			Cost:       hashCost(min(max(count, MinCost), MaxCost)),
+			Salt:       make([]byte, SaltLength),
+		}
+		if count == 0 {
+			setting.Cost = DefaultCost
+		}
+		Encoding.Encode(setting.Salt, rbytes[:Encoding.DecodedLen(SaltLength)])
+		return crypthash.Marshal(setting)
+	}
+}
+
+func checkSetting(s string) error {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return err
+	}
+	return validateParams(setting.Salt, uint8(setting.Cost))
+}
+
 func init() {
-	crypt.RegisterHash(Prefix2, Check)
-	crypt.RegisterHash(Prefix2a, Check)
-	crypt.RegisterHash(Prefix2b, Check)
+	for _, prefix := range []string{Prefix2, Prefix2a, Prefix2b, Prefix2y} {
+		crypt.Register(prefix, hashAlgorithm)
+		crypt.RegisterMethod(prefix, &crypt.Method{
+			Crypt:        cryptSetting,
+			GenSalt:      genSalt(prefix),
+			CheckSetting: checkSetting,
+			Legacy:       prefix == Prefix2,
+		})
+	}
 }
@@ -9,7 +9,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"github.com/sergeymakinen/go-crypt/internal/testutil"
 )
 
@@ -43,6 +45,13 @@ func TestParse(t *testing.T) {
 			cost:     5,
 			opts:     &CompatibilityOptions{Prefix: Prefix2b},
 		},
+		{
+			hash:     "$2y$05$6bNw2HLQYeqHYyBfLMsv/OUcZd0LKP39b87nBw3.S2tVZSqiQX6eu",
+			password: "\xD1\x91",
+			salt:     []byte("6bNw2HLQYeqHYyBfLMsv/O"),
+			cost:     5,
+			opts:     &CompatibilityOptions{Prefix: Prefix2y},
+		},
 		{
 			hash:     "$2a$04$R1lJ2gkNaoPGdafE.H.16.nVyh2niHsGJhayOHLMiXlI45o8/DU.6",
 			password: strings.Repeat("0123456789", 26)[:254],
@@ -408,3 +417,88 @@ func TestNewHash(t *testing.T) {
 		})
 	}
 }
+
+func TestCrypt(t *testing.T) {
+	tests := []struct {
+		setting string
+		hash    string
+	}{
+		{
+			setting: "$2b$05$abcdefghijklmnopqrstuu",
+			hash:    "$2b$05$abcdefghijklmnopqrstuuWG29KuyeAicPCJODk1zjyGvyQUU2awu",
+		},
+		{
+			setting: "$2b$05$abcdefghijklmnopqrstuuWG29KuyeAicPCJODk1zjyGvyQUU2awu",
+			hash:    "$2b$05$abcdefghijklmnopqrstuuWG29KuyeAicPCJODk1zjyGvyQUU2awu",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			hash, err := crypt.Crypt("password", test.setting)
+			if err != nil {
+				t.Fatalf("Crypt() = _, %v; want nil", err)
+			}
+			if hash != test.hash {
+				t.Errorf("Crypt() = %q, _; want %q", hash, test.hash)
+			}
+		})
+	}
+}
+
+func TestGenSalt(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		count   uint64
+		setting string
+		status  crypt.SaltStatus
+	}{
+		{
+			prefix:  Prefix2b,
+			count:   0,
+			setting: "$2b$12$KBCwKxOzLha2MUDgW0PjXe",
+			status:  crypt.SaltOK,
+		},
+		{
+			prefix:  Prefix2b,
+			count:   10,
+			setting: "$2b$10$KBCwKxOzLha2MUDgW0PjXe",
+			status:  crypt.SaltOK,
+		},
+		{
+			prefix:  Prefix2,
+			count:   10,
+			setting: "$2$10$KBCwKxOzLha2MUDgW0PjXe",
+			status:  crypt.SaltMethodLegacy,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			setting, err := crypt.GenSalt(test.prefix, test.count, []byte("0123456789abcdef"))
+			if err != nil {
+				t.Fatalf("GenSalt() = _, %v; want nil", err)
+			}
+			if setting != test.setting {
+				t.Errorf("GenSalt() = %q, _; want %q", setting, test.setting)
+			}
+			if status := crypt.CheckSalt(setting); status != test.status {
+				t.Errorf("CheckSalt() = %v; want %v", status, test.status)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	if err := Check("$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := crypt.Crypt("password", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Crypt() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := NewHash("password", DefaultCost); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
@@ -1,6 +1,7 @@
 package bcrypt_test
 
 import (
+	"encoding/hex"
 	"fmt"
 
 	"github.com/sergeymakinen/go-crypt/bcrypt"
@@ -36,3 +37,10 @@ func ExampleCheck() {
 	// <nil>
 	// hash and password mismatch
 }
+
+func ExamplePBKDF() {
+	key, _ := bcrypt.PBKDF([]byte("password"), []byte("salt"), 12, 32)
+	fmt.Println(hex.EncodeToString(key))
+	// Output:
+	// 1ae42c05d487bc02f64921a4ebe4ea93bcacfe135fda99974c06b7b01fae149a
+}
@@ -0,0 +1,104 @@
+package bcrypt
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"strconv"
+)
+
+// InvalidPasswordLengthError values describe errors resulting from an invalid length of a password.
+type InvalidPasswordLengthError int
+
+func (e InvalidPasswordLengthError) Error() string {
+	return "invalid password length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidRoundsError values describe errors resulting from an invalid round count.
+type InvalidRoundsError uint32
+
+func (e InvalidRoundsError) Error() string {
+	return "invalid round count " + strconv.FormatUint(uint64(e), 10)
+}
+
+// InvalidKeyLengthError values describe errors resulting from an invalid length of a key.
+type InvalidKeyLengthError int
+
+func (e InvalidKeyLengthError) Error() string {
+	return "invalid key length " + strconv.FormatInt(int64(e), 10)
+}
+
+const (
+	pbkdfHashLength    = 32
+	MaxPBKDFKeyLength  = pbkdfHashLength * pbkdfHashLength
+	MaxPBKDFSaltLength = 1 << 20
+)
+
+// PBKDF returns a key derived from the password, salt and rounds
+// with the bcrypt_pbkdf function of OpenBSD, as used by OpenSSH to encrypt private keys.
+func PBKDF(password, salt []byte, rounds uint32, keyLen int) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, InvalidPasswordLengthError(0)
+	}
+	if n := len(salt); n == 0 || n > MaxPBKDFSaltLength {
+		return nil, InvalidSaltLengthError(n)
+	}
+	if rounds < 1 {
+		return nil, InvalidRoundsError(rounds)
+	}
+	if keyLen < 1 || keyLen > MaxPBKDFKeyLength {
+		return nil, InvalidKeyLengthError(keyLen)
+	}
+	// The output bytes are spread across the key so that
+	// all of them depend on the last block
+	stride := (keyLen + pbkdfHashLength - 1) / pbkdfHashLength
+	amt := (keyLen + stride - 1) / stride
+	sha2Pass := sha512.Sum512(password)
+	countSalt := make([]byte, len(salt)+4)
+	copy(countSalt, salt)
+	key := make([]byte, keyLen)
+	var out, tmp [pbkdfHashLength]byte
+	for count := 1; count <= stride; count++ {
+		binary.BigEndian.PutUint32(countSalt[len(salt):], uint32(count))
+		sha2Salt := sha512.Sum512(countSalt)
+		if err := pbkdfHash(tmp[:], sha2Pass[:], sha2Salt[:]); err != nil {
+			return nil, err
+		}
+		out = tmp
+		for i := uint32(1); i < rounds; i++ {
+			sha2Salt = sha512.Sum512(tmp[:])
+			if err := pbkdfHash(tmp[:], sha2Pass[:], sha2Salt[:]); err != nil {
+				return nil, err
+			}
+			for j := range out {
+				out[j] ^= tmp[j]
+			}
+		}
+		for i := 0; i < amt; i++ {
+			dst := i*stride + count - 1
+			if dst >= keyLen {
+				break
+			}
+			key[dst] = out[i]
+		}
+	}
+	return key, nil
+}
+
+// pbkdfHash implements the bcrypt_hash function of bcrypt_pbkdf.
+func pbkdfHash(dst, sha2Pass, sha2Salt []byte) error {
+	c, err := expandState(sha2Pass, sha2Salt, 64, sha2Salt, sha2Pass)
+	if err != nil {
+		return err
+	}
+	copy(dst, "OxychromaticBlowfishSwatDynamite")
+	for i := 0; i < 64; i++ {
+		for j := 0; j < len(dst); j += 8 {
+			c.Encrypt(dst[j:j+8], dst[j:j+8])
+		}
+	}
+	// The words are stored in little-endian byte order
+	for i := 0; i < len(dst); i += 4 {
+		binary.LittleEndian.PutUint32(dst[i:], binary.BigEndian.Uint32(dst[i:]))
+	}
+	return nil
+}
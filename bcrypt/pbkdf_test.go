@@ -0,0 +1,123 @@
+package bcrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestPBKDF(t *testing.T) {
+	tests := []struct {
+		password, salt []byte
+		rounds         uint32
+		key            string
+	}{
+		{
+			password: []byte("password"),
+			salt:     []byte("salt"),
+			rounds:   12,
+			key:      "1ae42c05d487bc02f64921a4ebe4ea93bcacfe135fda99974c06b7b01fae149a",
+		},
+		{
+			password: []byte("passwordy\x00PASSWORD\x00"),
+			salt:     []byte("salty\x00SALT\x00"),
+			rounds:   3,
+			key:      "7f310bd3e78c3280c59ce4595211a2928e8d4ec744c1ed2efc9f764e3388e0ad",
+		},
+		{
+			password: []byte("секретное слово"),
+			salt:     []byte("посолить немножко"),
+			rounds:   8,
+			key: "8df43fc6fe131fc47f0c9e39224bd94c70b6fcc8ee8135faddf61156e6cb2733ea765f315a3e1e4afc35bf8687d189254c1e05a6fe80c0617f9183d67260d6a1" +
+				"15c6c94e3603e2303fbb43a76a64523ffda686b1d4518543",
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("password=%s;salt=%s;rounds=%d", test.password, test.salt, test.rounds), func(t *testing.T) {
+			key, err := PBKDF(test.password, test.salt, test.rounds, len(test.key)/2)
+			if err != nil {
+				t.Fatalf("PBKDF() = _, %v; want nil", err)
+			}
+			if encKey := hex.EncodeToString(key); encKey != test.key {
+				t.Errorf("PBKDF() = %q, _; want %q", encKey, test.key)
+			}
+		})
+	}
+}
+
+func TestPBKDFHash(t *testing.T) {
+	var pass, salt [64]byte
+	for i := range pass {
+		pass[i] = byte(i)
+		salt[i] = byte(i + 64)
+	}
+	var b [pbkdfHashLength]byte
+	if err := pbkdfHash(b[:], pass[:], salt[:]); err != nil {
+		t.Fatalf("pbkdfHash() = %v; want nil", err)
+	}
+	if encKey, expected := hex.EncodeToString(b[:]), "87904870eef9deddf8e7611a140106e6aaf1a363d9a2c504db356443721eb555"; encKey != expected {
+		t.Errorf("pbkdfHash() = %q; want %q", encKey, expected)
+	}
+}
+
+func TestPBKDFShouldFail(t *testing.T) {
+	tests := []struct {
+		password, salt []byte
+		rounds         uint32
+		keyLen         int
+		err            error
+	}{
+		{
+			password: nil,
+			salt:     []byte("salt"),
+			rounds:   16,
+			keyLen:   32,
+			err:      InvalidPasswordLengthError(0),
+		},
+		{
+			password: []byte("password"),
+			salt:     nil,
+			rounds:   16,
+			keyLen:   32,
+			err:      InvalidSaltLengthError(0),
+		},
+		{
+			password: []byte("password"),
+			salt:     bytes.Repeat([]byte{'a'}, MaxPBKDFSaltLength+1),
+			rounds:   16,
+			keyLen:   32,
+			err:      InvalidSaltLengthError(MaxPBKDFSaltLength + 1),
+		},
+		{
+			password: []byte("password"),
+			salt:     []byte("salt"),
+			rounds:   0,
+			keyLen:   32,
+			err:      InvalidRoundsError(0),
+		},
+		{
+			password: []byte("password"),
+			salt:     []byte("salt"),
+			rounds:   16,
+			keyLen:   0,
+			err:      InvalidKeyLengthError(0),
+		},
+		{
+			password: []byte("password"),
+			salt:     []byte("salt"),
+			rounds:   16,
+			keyLen:   MaxPBKDFKeyLength + 1,
+			err:      InvalidKeyLengthError(MaxPBKDFKeyLength + 1),
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("rounds=%d;keyLen=%d;err=%v", test.rounds, test.keyLen, test.err), func(t *testing.T) {
+			if _, err := PBKDF(test.password, test.salt, test.rounds, test.keyLen); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("PBKDF() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+// Package cisco implements the Cisco IOS type 5, 8 and 9 secrets
+// and the parsing of secrets from configuration lines.
+//
+// Type 5 secrets are crypt(3) MD5 hashes and are handled by the md5 package.
+package cisco
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/sergeymakinen/go-crypt"
+	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/hashutil"
+	"github.com/sergeymakinen/go-crypt/md5"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Type is the type of a secret in a configuration.
+type Type int
+
+const (
+	Type0 Type = 0 // plaintext
+	Type5 Type = 5 // crypt(3) MD5
+	Type7 Type = 7 // reversible Vigenère cipher
+	Type8 Type = 8 // PBKDF2-SHA256
+	Type9 Type = 9 // scrypt
+)
+
+// Weak reports whether the secret type is plaintext, reversible or a fast hash.
+func (t Type) Weak() bool {
+	return t != Type8 && t != Type9
+}
+
+// UnsupportedTypeError values describe errors resulting from an unsupported secret type.
+type UnsupportedTypeError Type
+
+func (e UnsupportedTypeError) Error() string {
+	return "unsupported type " + strconv.FormatInt(int64(e), 10)
+}
+
+const (
+	Prefix5 = md5.Prefix
+	Prefix8 = "$8$"
+	Prefix9 = "$9$"
+)
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+const (
+	Type5SaltLength = 4
+	SaltLength      = 14 // of type 8 and 9 secrets
+)
+
+// InvalidSaltLengthError values describe errors resulting from an invalid length of a salt.
+type InvalidSaltLengthError int
+
+func (e InvalidSaltLengthError) Error() string {
+	return "invalid salt length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidSaltError values describe errors resulting from an invalid character in a hash string.
+type InvalidSaltError byte
+
+func (e InvalidSaltError) Error() string {
+	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in salt"
+}
+
+const (
+	Type8Iterations = 20000
+
+	Type9N = 1 << 14
+	Type9R = 1
+	Type9P = 1
+)
+
+const (
+	keyLength = 32
+	sumLength = 43
+)
+
+func validateSalt(salt []byte) error {
+	if n := len(salt); n != SaltLength {
+		return InvalidSaltLengthError(n)
+	}
+	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
+		return InvalidSaltError(salt[i])
+	}
+	return nil
+}
+
+// Key returns a type 8 or 9 key derived from the password and salt.
+// Returns crypt.ErrNotApproved for type 9 in FIPS 140 mode.
+func Key(password, salt []byte, t Type) ([]byte, error) {
+	if t != Type8 && t != Type9 {
+		return nil, UnsupportedTypeError(t)
+	}
+	if t == Type9 && cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	if err := validateSalt(salt); err != nil {
+		return nil, err
+	}
+	if t == Type8 {
+		return pbkdf2.Key(sha256.New, string(password), salt, Type8Iterations, keyLength)
+	}
+	return scrypt.Key(password, salt, Type9N, Type9R, Type9P, keyLength)
+}
+
+type hashPrefix string
+
+func (h *hashPrefix) UnmarshalText(text []byte) error {
+	switch s := hashPrefix(text); s {
+	case Prefix8, Prefix9:
+		*h = s
+		return nil
+	default:
+		return UnsupportedPrefixError(s)
+	}
+}
+
+type scheme struct {
+	HashPrefix hashPrefix
+	Salt       []byte
+	Sum        [sumLength]byte
+}
+
+func prefixType(prefix hashPrefix) Type {
+	if prefix == Prefix8 {
+		return Type8
+	}
+	return Type9
+}
+
+// NewHash returns the type 5, 8 or 9 secret of the password.
+func NewHash(password string, t Type) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, t)
+	})
+}
+
+func newHash(password string, t Type) (string, error) {
+	switch t {
+	case Type5:
+		salt := hashutil.HashEncoding.Rand(Type5SaltLength)
+		key, err := md5.Key([]byte(password), salt)
+		if err != nil {
+			return "", err
+		}
+		return Prefix5 + string(salt) + "$" + crypthash.LittleEndianEncoding.EncodeToString(key), nil
+	case Type8, Type9:
+		scheme := scheme{
+			HashPrefix: Prefix9,
+			Salt:       hashutil.HashEncoding.Rand(SaltLength),
+		}
+		if t == Type8 {
+			scheme.HashPrefix = Prefix8
+		}
+		key, err := Key([]byte(password), scheme.Salt, t)
+		if err != nil {
+			return "", err
+		}
+		crypthash.BigEndianEncoding.Encode(scheme.Sum[:], key)
+		return crypthash.Marshal(scheme)
+	default:
+		return "", UnsupportedTypeError(t)
+	}
+}
+
+var (
+	hashAlgorithm = &crypt.Hash{
+		Algorithm: "cisco",
+		Check:     check,
+		Params:    hookParams,
+		Approved:  true,
+	}
+
+	// Type 9 secrets use scrypt, which is not approved by FIPS 140
+	scryptHashAlgorithm = &crypt.Hash{
+		Algorithm: "cisco",
+		Check:     check,
+		Params:    hookParams,
+	}
+)
+
+// hookParams returns the type of the secret for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	t := Type5
+	if !strings.HasPrefix(hash, Prefix5) {
+		var scheme scheme
+		if err := crypthash.Unmarshal(hash, &scheme); err != nil {
+			return nil
+		}
+		t = prefixType(scheme.HashPrefix)
+	}
+	return []slog.Attr{slog.Int("type", int(t))}
+}
+
+// Check compares the given type 5, 8 or 9 secret with a new secret derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
+	if strings.HasPrefix(hash, Prefix5) {
+		return md5.Check(hash, password)
+	}
+	var scheme scheme
+	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
+		return err
+	}
+	key, err := Key([]byte(password), scheme.Salt, prefixType(scheme.HashPrefix))
+	if err != nil {
+		return err
+	}
+	var b [sumLength]byte
+	crypthash.BigEndianEncoding.Encode(b[:], key)
+	if subtle.ConstantTimeCompare(b[:], scheme.Sum[:]) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+// Secret is a secret found in a configuration line.
+type Secret struct {
+	Keyword string // "secret" or "password"
+	Type    Type
+	Value   string
+}
+
+// Check compares the secret with the password.
+// Type 0 secrets are compared as is.
+// Returns nil on success, or an error on failure.
+func (s *Secret) Check(password string) error {
+	switch s.Type {
+	case Type0:
+		if subtle.ConstantTimeCompare([]byte(s.Value), []byte(password)) == 0 {
+			return crypt.ErrPasswordMismatch
+		}
+		return nil
+	case Type5, Type8, Type9:
+		return Check(s.Value, password)
+	default:
+		return UnsupportedTypeError(s.Type)
+	}
+}
+
+// ErrNoSecret is returned by ParseLine when the line has no secret.
+var ErrNoSecret = errors.New("no secret")
+
+// ParseLine parses the secret of a configuration line, like
+// "enable secret 9 $9$…" or "username admin privilege 15 password 7 0822455D0A16".
+// A secret without a type is a type 0 secret.
+func ParseLine(line string) (*Secret, error) {
+	var (
+		s        *Secret
+		hasType  bool
+		i, start int
+	)
+	for {
+		for i < len(line) && unicode.IsSpace(rune(line[i])) {
+			i++
+		}
+		if i == len(line) {
+			break
+		}
+		start = i
+		for i < len(line) && !unicode.IsSpace(rune(line[i])) {
+			i++
+		}
+		field := line[start:i]
+		switch {
+		case s == nil:
+			if field == "secret" || field == "password" {
+				s = &Secret{Keyword: field}
+			}
+			continue
+		case !hasType:
+			hasType = true
+			if n, err := strconv.Atoi(field); err == nil && len(field) == 1 {
+				s.Type = Type(n)
+				continue
+			}
+		}
+		// The value is the rest of the line
+		s.Value = strings.TrimRightFunc(line[start:], unicode.IsSpace)
+		return s, nil
+	}
+	if s == nil {
+		return nil, ErrNoSecret
+	}
+	return nil, &parse.SyntaxError{Offset: len(line), Msg: "missing secret value"}
+}
+
+func init() {
+	crypt.Register(Prefix8, hashAlgorithm)
+	crypt.Register(Prefix9, scryptHashAlgorithm)
+}
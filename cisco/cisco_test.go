@@ -0,0 +1,257 @@
+package cisco
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		hash, password string
+	}{
+		{
+			hash:     "$1$mERr$hx5rVt7rPNoS4wqbXKX7m0",
+			password: "cisco",
+		},
+		// Hashcat
+		{
+			hash:     "$8$TnGX/fE4KGHOVU$pEhnEvxrvaynpi8j4f.EMHr6M.FzU8xnZnBr/tJdFWk",
+			password: "hashcat",
+		},
+		{
+			hash:     "$9$2MJBozw/9R3UsU$2lFhcKvpghcyw8deP25GOfyZaagyUOGBymkryvOdfo6",
+			password: "hashcat",
+		},
+		{
+			hash:     "$8$dsYGNam3K1SIJO$DZ56Yv3lYgsn3RkNlyIDkTK6vREySohdZjD2fyOucUU",
+			password: "password",
+		},
+		{
+			hash:     "$9$cvWdfQlRRDKq/U$NxG4BeiEh9H5v1adnq3.zYy/VsQoEyetJ8gEenmnukE",
+			password: "password",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, test.password); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := crypt.Check(test.hash, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("crypt.Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "$7$TnGX/fE4KGHOVU$pEhnEvxrvaynpi8j4f.EMHr6M.FzU8xnZnBr/tJdFWk",
+			err: &crypthash.UnmarshalTypeError{
+				Value:  "prefix",
+				Type:   testutil.FieldType(scheme{}, "HashPrefix"),
+				Offset: 3,
+				Struct: "*cisco.scheme",
+				Field:  "HashPrefix",
+				Msg:    `unsupported prefix "$7$"`,
+			},
+		},
+		{
+			hash: "$8$TnGX/fE4KGHOV$pEhnEvxrvaynpi8j4f.EMHr6M.FzU8xnZnBr/tJdFWk",
+			err:  InvalidSaltLengthError(13),
+		},
+		{
+			hash: "$8$TnGX/fE4KGHOV@$pEhnEvxrvaynpi8j4f.EMHr6M.FzU8xnZnBr/tJdFWk",
+			err: &crypthash.UnmarshalTypeError{
+				Value:  "value",
+				Type:   testutil.FieldType(scheme{}, "Salt"),
+				Offset: 17,
+				Struct: "*cisco.scheme",
+				Field:  "Salt",
+				Msg:    "invalid character '@'",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "hashcat"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	key, err := Key([]byte("hashcat"), []byte("TnGX/fE4KGHOVU"), Type8)
+	if err != nil {
+		t.Fatalf("Key() = _, %v; want nil", err)
+	}
+	const expected = "pEhnEvxrvaynpi8j4f.EMHr6M.FzU8xnZnBr/tJdFWk"
+	if s := crypthash.BigEndianEncoding.EncodeToString(key); s != expected {
+		t.Errorf("Key() = %q, _; want %q", s, expected)
+	}
+	if _, err := Key([]byte("hashcat"), []byte("TnGX/fE4KGHOVU"), Type5); err != UnsupportedTypeError(Type5) {
+		t.Errorf("Key() = _, %v; want %v", err, UnsupportedTypeError(Type5))
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	tests := []struct {
+		typ    Type
+		prefix string
+		length int
+	}{
+		{Type5, Prefix5, 30},
+		{Type8, Prefix8, 61},
+		{Type9, Prefix9, 61},
+	}
+	for _, test := range tests {
+		t.Run(strings.Trim(test.prefix, "$"), func(t *testing.T) {
+			hash, err := NewHash("password", test.typ)
+			if err != nil {
+				t.Fatalf("NewHash() = _, %v; want nil", err)
+			}
+			if !strings.HasPrefix(hash, test.prefix) || len(hash) != test.length {
+				t.Errorf("NewHash() = %q, _; want %d characters starting with %q", hash, test.length, test.prefix)
+			}
+			if err := Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+		})
+	}
+	if _, err := NewHash("password", Type7); err != UnsupportedTypeError(Type7) {
+		t.Errorf("NewHash() = _, %v; want %v", err, UnsupportedTypeError(Type7))
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		secret Secret
+	}{
+		{
+			line:   "enable secret 5 $1$mERr$hx5rVt7rPNoS4wqbXKX7m0",
+			secret: Secret{Keyword: "secret", Type: Type5, Value: "$1$mERr$hx5rVt7rPNoS4wqbXKX7m0"},
+		},
+		{
+			line:   " username admin privilege 15 secret 9 $9$2MJBozw/9R3UsU$2lFhcKvpghcyw8deP25GOfyZaagyUOGBymkryvOdfo6\r",
+			secret: Secret{Keyword: "secret", Type: Type9, Value: "$9$2MJBozw/9R3UsU$2lFhcKvpghcyw8deP25GOfyZaagyUOGBymkryvOdfo6"},
+		},
+		{
+			line:   "username admin password 7 0822455D0A16",
+			secret: Secret{Keyword: "password", Type: Type7, Value: "0822455D0A16"},
+		},
+		{
+			line:   "enable password cisco",
+			secret: Secret{Keyword: "password", Type: Type0, Value: "cisco"},
+		},
+		{
+			line:   "enable algorithm-type scrypt secret 12345",
+			secret: Secret{Keyword: "secret", Type: Type0, Value: "12345"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			secret, err := ParseLine(test.line)
+			if err != nil {
+				t.Fatalf("ParseLine() = _, %v; want nil", err)
+			}
+			if *secret != test.secret {
+				t.Errorf("ParseLine() = %+v, _; want %+v", *secret, test.secret)
+			}
+		})
+	}
+}
+
+func TestParseLineShouldFail(t *testing.T) {
+	tests := []struct {
+		line string
+		err  error
+	}{
+		{
+			line: "service password-encryption",
+			err:  ErrNoSecret,
+		},
+		{
+			line: "enable secret 9 ",
+			err:  &parse.SyntaxError{Offset: 16, Msg: "missing secret value"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			if _, err := ParseLine(test.line); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("ParseLine() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestSecretCheck(t *testing.T) {
+	tests := []struct {
+		secret Secret
+		weak   bool
+		err    error
+	}{
+		{
+			secret: Secret{Type: Type0, Value: "cisco"},
+			weak:   true,
+		},
+		{
+			secret: Secret{Type: Type5, Value: "$1$mERr$hx5rVt7rPNoS4wqbXKX7m0"},
+			weak:   true,
+		},
+		{
+			secret: Secret{Type: Type7, Value: "0822455D0A16"},
+			weak:   true,
+			err:    UnsupportedTypeError(Type7),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.secret.Value, func(t *testing.T) {
+			if weak := test.secret.Type.Weak(); weak != test.weak {
+				t.Errorf("Weak() = %v; want %v", weak, test.weak)
+			}
+			if err := test.secret.Check("cisco"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	tests := []struct {
+		hash     string
+		expected error
+	}{
+		{
+			hash: "$8$dsYGNam3K1SIJO$DZ56Yv3lYgsn3RkNlyIDkTK6vREySohdZjD2fyOucUU",
+		},
+		{
+			hash:     "$9$cvWdfQlRRDKq/U$NxG4BeiEh9H5v1adnq3.zYy/VsQoEyetJ8gEenmnukE",
+			expected: crypt.ErrNotApproved,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.expected) {
+				t.Errorf("Check() = %v; want %v", err, test.expected)
+			}
+		})
+	}
+	if _, err := NewHash("password", Type9); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
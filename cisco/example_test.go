@@ -0,0 +1,18 @@
+package cisco_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/cisco"
+)
+
+func ExampleParseLine() {
+	secret, _ := cisco.ParseLine("enable secret 8 $8$TnGX/fE4KGHOVU$pEhnEvxrvaynpi8j4f.EMHr6M.FzU8xnZnBr/tJdFWk")
+	fmt.Println(secret.Type, secret.Type.Weak())
+	fmt.Println(secret.Check("hashcat"))
+	fmt.Println(secret.Check("test"))
+	// Output:
+	// 8 false
+	// <nil>
+	// hash and password mismatch
+}
@@ -6,12 +6,14 @@
 // 	import _ "github.com/sergeymakinen/go-crypt/argon2"
 // in a program's main package. The _ means to import a package purely for its
 // initialization side effects.
+//
+// As any imported hash package enables its hash, programs that must accept
+// only specific hashes should check them with a Registry restricted with an allow list.
 package crypt
 
 import (
 	"errors"
-	"strings"
-	"sync"
+	"log/slog"
 )
 
 var (
@@ -19,35 +21,105 @@ var (
 	ErrPasswordMismatch = errors.New("hash and password mismatch")
 )
 
-var hashCache sync.Map // map[string]func(hash, password string) error
+// ErrNotApproved is returned in FIPS 140 mode, when crypto/fips140.Enabled reports true,
+// for the hashing algorithms not approved by FIPS 140, like DES, MD5, bcrypt and Argon2.
+// Check refuses all hashes not registered as Hash.Approved.
+var ErrNotApproved = errors.New("hashing algorithm not approved in FIPS 140 mode")
+
+// Hash is a hashing algorithm registered with Register or RegisterMatcher for use by Check.
+type Hash struct {
+	// Algorithm is the name of the algorithm reported to hooks, like "sha512".
+	// It identifies the hashes registered with RegisterMatcher
+	// in the allow and deny lists of registries.
+	Algorithm string
+
+	// Check compares the given hash with a new hash derived from the password.
+	// It must not report the operation to the hook, as Check does it.
+	Check func(hash, password string) error
+
+	// Params returns the cost parameters of the hash reported to hooks.
+	// It's optional.
+	Params func(hash string) []slog.Attr
 
-// RegisterHash registers a hash for use by Check.
+	// Approved reports whether the algorithm is approved by FIPS 140, like PBKDF2 and SHA-crypt.
+	// Check refuses the hashes not approved with ErrNotApproved in FIPS 140 mode.
+	Approved bool
+}
+
+// ObserveCheck calls h.Check and reports the operation to the hook, if set,
+// like the package-level ObserveCheck. Hash packages use it to implement their Check functions.
+func (h *Hash) ObserveCheck(hash, password string) error {
+	return ObserveCheck(h.Algorithm, hash, password, h.Check, h.Params)
+}
+
+// ObserveNewHash calls newHash and reports the operation to the hook, if set,
+// like the package-level ObserveNewHash. Hash packages use it to implement their NewHash functions.
+func (h *Hash) ObserveNewHash(newHash func() (string, error)) (string, error) {
+	return ObserveNewHash(h.Algorithm, newHash, h.Params)
+}
+
+// RegisterHash registers a hash in DefaultRegistry for use by Check.
 // Prefix is a prefix that identifies the hash.
 // Check is the function that compares the given hash
 // with a new hash derived from the password.
+//
+// The hash is reported to hooks as the "crypt" algorithm
+// and refused by Check with ErrNotApproved in FIPS 140 mode.
+// Use Register to set the algorithm, its parameters and approval.
 func RegisterHash(prefix string, check func(hash, password string) error) {
-	hashCache.Store(prefix, check)
+	DefaultRegistry.RegisterHash(prefix, check)
 }
 
-// Check compares the given crypt(3) hash with a new hash derived from the password.
+// Register registers the hash h in DefaultRegistry for use by Check.
+// Prefix is a prefix that identifies the hash.
+func Register(prefix string, h *Hash) {
+	DefaultRegistry.Register(prefix, h)
+}
+
+// RegisterMatcher registers the hash h without a distinctive prefix in DefaultRegistry for use by Check.
+// Match is the function that reports whether the hash is in the format of h.
+// The hash is identified by h.Algorithm in the allow and deny lists of registries.
+//
+// Matchers are consulted in the registration order for hashes
+// that don't start with a "$", "_", "*" or registered "{id}" prefix, before the DES hash is assumed.
+// Several matchers may share an algorithm, for example, to register
+// only some hashes of the algorithm as approved.
+func RegisterMatcher(match func(hash string) bool, h *Hash) {
+	DefaultRegistry.RegisterMatcher(match, h)
+}
+
+// Check compares the given crypt(3) hash with a new hash derived from the password
+// using the hashes registered in DefaultRegistry.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
-	var prefix string
-	if strings.HasPrefix(hash, "$") {
-		if i := strings.IndexAny(hash[1:], "$,"); i >= 0 {
-			if i == 0 {
-				return ErrHash
-			}
-			prefix = hash[:i+2]
-		} else {
-			return ErrHash
-		}
-	}
-	if strings.HasPrefix(hash, "_") {
-		prefix = "_"
-	}
-	if check, ok := hashCache.Load(prefix); ok {
-		return check.(func(hash, password string) error)(hash, password)
-	}
-	return ErrHash
+	return DefaultRegistry.Check(hash, password)
+}
+
+// Policy is the hashing method of the dummy verifications by CheckOrDummy,
+// typically the one of the new hashes.
+type Policy struct {
+	Prefix string // the prefix of the method, as passed to GenSalt
+	Count  uint64 // the method-specific cost, as passed to GenSalt, or 0 to use the method default
 }
+
+// CheckOrDummy is like Check but, if the hash is missing (empty) or can't be checked,
+// for example, if it's malformed or unknown, it compares a dummy hash produced with the policy
+// with the password before returning the error, so failures take about as long
+// as the checks of the hashes produced with the policy. That hides, for example,
+// whether a user exists, if the hashes of unknown users are passed as empty.
+//
+// The dummy hash is produced once per policy with the methods registered in DefaultRegistry,
+// so the first failure with a policy also takes the time of GenSalt and Crypt,
+// unless the dummy hash is produced in advance with PrepareDummy.
+// If it can't be produced, the error of Check is returned without the dummy comparison.
+func CheckOrDummy(hash, password string, policy Policy) error {
+	return DefaultRegistry.CheckOrDummy(hash, password, policy)
+}
+
+// PrepareDummy produces the dummy hash of CheckOrDummy for the policy in advance,
+// for example, when a server starts. Returns the error of GenSalt or Crypt
+// if it can't be produced with the methods registered in DefaultRegistry.
+func PrepareDummy(policy Policy) error {
+	return DefaultRegistry.PrepareDummy(policy)
+}
+
@@ -1,8 +1,12 @@
 package crypt
 
 import (
+	"fmt"
+	"log/slog"
+	"strconv"
 	"testing"
 
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"github.com/sergeymakinen/go-crypt/internal/testutil"
 )
 
@@ -35,3 +39,533 @@ func TestCheckOverride(t *testing.T) {
 		t.Errorf("Check() = _, %v; want nil", err)
 	}
 }
+
+func TestCheckStarPrefix(t *testing.T) {
+	r := NewRegistry(nil)
+	r.RegisterHash("*", func(hash, password string) error {
+		return nil
+	})
+	err := r.Check("*foo", "bar")
+	if err != nil {
+		t.Errorf("Check() = _, %v; want nil", err)
+	}
+}
+
+func TestCheckBracePrefix(t *testing.T) {
+	RegisterHash("{foo}", func(hash, password string) error {
+		return nil
+	})
+	if err := Check("{foo}bar", "bar"); err != nil {
+		t.Errorf("Check() = _, %v; want nil", err)
+	}
+	if err := Check("{bar}$foo$", "bar"); !testutil.IsEqualError(err, ErrHash) {
+		t.Errorf("Check() = _, %v; want %v", err, ErrHash)
+	}
+}
+
+func TestCheckMatcher(t *testing.T) {
+	RegisterMatcher(func(hash string) bool {
+		return hash == "matched"
+	}, &Hash{
+		Algorithm: "matched",
+		Check: func(hash, password string) error {
+			return nil
+		},
+	})
+	if err := Check("matched", "bar"); err != nil {
+		t.Errorf("Check() = _, %v; want nil", err)
+	}
+	if err := Check("$matched", "bar"); !testutil.IsEqualError(err, ErrHash) {
+		t.Errorf("Check() = _, %v; want %v", err, ErrHash)
+	}
+}
+
+func registerTestMethod() {
+	RegisterHash("$bar$", func(hash, password string) error {
+		return nil
+	})
+	RegisterMethod("$bar$", &Method{
+		Crypt: func(password, setting string) (string, error) {
+			return setting + "$" + password, nil
+		},
+		GenSalt: func(count uint64, rbytes []byte) (string, error) {
+			return "$bar$" + strconv.FormatUint(count, 10) + "$" + string(rbytes[:4]), nil
+		},
+		CheckSetting: func(setting string) error {
+			if setting == "$bar$invalid" {
+				return ErrHash
+			}
+			return nil
+		},
+	})
+	RegisterHash("$baz$", func(hash, password string) error {
+		return nil
+	})
+}
+
+func TestCrypt(t *testing.T) {
+	registerTestMethod()
+	hash, err := Crypt("password", "$bar$salt")
+	if err != nil {
+		t.Fatalf("Crypt() = _, %v; want nil", err)
+	}
+	if expected := "$bar$salt$password"; hash != expected {
+		t.Errorf("Crypt() = %q, _; want %q", hash, expected)
+	}
+	for _, setting := range []string{"$baz$salt", "$$salt", "$unknown$salt"} {
+		if _, err := Crypt("password", setting); !testutil.IsEqualError(err, ErrHash) {
+			t.Errorf("Crypt(%q) = _, %v; want %v", setting, err, ErrHash)
+		}
+	}
+}
+
+func TestGenSalt(t *testing.T) {
+	registerTestMethod()
+	setting, err := GenSalt("$bar$", 10, []byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("GenSalt() = _, %v; want nil", err)
+	}
+	if expected := "$bar$10$0123"; setting != expected {
+		t.Errorf("GenSalt() = %q, _; want %q", setting, expected)
+	}
+	if _, err = GenSalt("$bar$", 0, nil); err != nil {
+		t.Errorf("GenSalt() = _, %v; want nil", err)
+	}
+	if _, err = GenSalt("$baz$", 0, nil); !testutil.IsEqualError(err, ErrHash) {
+		t.Errorf("GenSalt() = _, %v; want %v", err, ErrHash)
+	}
+	expected := InvalidRandomBytesLengthError(4)
+	if _, err = GenSalt("$bar$", 0, []byte("0123")); !testutil.IsEqualError(err, expected) {
+		t.Errorf("GenSalt() = _, %v; want %v", err, expected)
+	}
+}
+
+func TestCheckSalt(t *testing.T) {
+	registerTestMethod()
+	tests := []struct {
+		setting string
+		status  SaltStatus
+	}{
+		{"$bar$salt", SaltOK},
+		{"$bar$invalid", SaltInvalid},
+		{"$baz$salt", SaltMethodDisabled},
+		{"$unknown$salt", SaltInvalid},
+		{"*", SaltInvalid},
+		{"", SaltInvalid},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			if status := CheckSalt(test.setting); status != test.status {
+				t.Errorf("CheckSalt() = %v; want %v", status, test.status)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registerTestMethod()
+	r := NewRegistry(nil)
+	if err := r.Check("$bar$salt$password", "password"); !testutil.IsEqualError(err, ErrHash) {
+		t.Errorf("Check() = %v; want %v", err, ErrHash)
+	}
+	r.RegisterHash("$qux$", func(hash, password string) error {
+		return nil
+	})
+	r.RegisterMatcher(func(hash string) bool {
+		return hash == "matched"
+	}, &Hash{
+		Algorithm: "matched",
+		Check: func(hash, password string) error {
+			return nil
+		},
+	})
+	for _, hash := range []string{"$qux$salt", "matched"} {
+		if err := r.Check(hash, "password"); err != nil {
+			t.Errorf("Check(%q) = %v; want nil", hash, err)
+		}
+	}
+	if err := Check("$qux$salt", "password"); !testutil.IsEqualError(err, ErrHash) {
+		t.Errorf("Check() = %v; want %v", err, ErrHash)
+	}
+}
+
+func TestRegistryPolicy(t *testing.T) {
+	registerTestMethod()
+	RegisterHash("$foo$", func(hash, password string) error {
+		return nil
+	})
+	RegisterMatcher(func(hash string) bool {
+		return hash == "matched"
+	}, &Hash{
+		Algorithm: "matched",
+		Check: func(hash, password string) error {
+			return nil
+		},
+	})
+	parent := NewRegistry(&RegistryOptions{Parent: DefaultRegistry})
+	parent.RegisterHash("", func(hash, password string) error {
+		return nil
+	})
+	tests := []struct {
+		name    string
+		opts    *RegistryOptions
+		allowed []string
+		denied  []string
+	}{
+		{
+			name:    "parent",
+			opts:    &RegistryOptions{Parent: parent},
+			allowed: []string{"$foo$", "$bar$", "matched"},
+		},
+		{
+			name:    "allow",
+			opts:    &RegistryOptions{Parent: parent, Allow: []string{"$bar$"}},
+			allowed: []string{"$bar$"},
+			denied:  []string{"$foo$", "matched"},
+		},
+		{
+			name:    "deny",
+			opts:    &RegistryOptions{Parent: parent, Deny: []string{"$bar$", "matched"}},
+			allowed: []string{"$foo$", "des"},
+			denied:  []string{"$bar$", "matched"},
+		},
+		{
+			name:    "deny empty prefix",
+			opts:    &RegistryOptions{Parent: parent, Deny: []string{""}},
+			allowed: []string{"$foo$", "matched"},
+			denied:  []string{"des"},
+		},
+		{
+			name:    "allow matcher",
+			opts:    &RegistryOptions{Parent: parent, Allow: []string{"matched"}},
+			allowed: []string{"matched"},
+			denied:  []string{"$foo$", "des"},
+		},
+		{
+			name:    "allow and deny",
+			opts:    &RegistryOptions{Parent: parent, Allow: []string{"$foo$", "$bar$"}, Deny: []string{"$bar$"}},
+			allowed: []string{"$foo$"},
+			denied:  []string{"$bar$"},
+		},
+		{
+			name: "parent policy",
+			opts: &RegistryOptions{
+				Parent: NewRegistry(&RegistryOptions{Parent: parent, Deny: []string{"$foo$"}}),
+				Allow:  []string{"$foo$", "$bar$"},
+			},
+			allowed: []string{"$bar$"},
+			denied:  []string{"$foo$"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewRegistry(test.opts)
+			for _, hash := range test.allowed {
+				if err := r.Check(hash, "password"); err != nil {
+					t.Errorf("Check(%q) = %v; want nil", hash, err)
+				}
+			}
+			for _, hash := range test.denied {
+				if err := r.Check(hash, "password"); !testutil.IsEqualError(err, ErrHashNotAllowed) {
+					t.Errorf("Check(%q) = %v; want %v", hash, err, ErrHashNotAllowed)
+				}
+			}
+			if err := r.Check("$unknown$", "password"); !testutil.IsEqualError(err, ErrHash) {
+				t.Errorf("Check() = %v; want %v", err, ErrHash)
+			}
+		})
+	}
+}
+
+func TestRegistryMethodPolicy(t *testing.T) {
+	registerTestMethod()
+	r := NewRegistry(&RegistryOptions{Parent: DefaultRegistry, Deny: []string{"$bar$"}})
+	if _, err := r.Crypt("password", "$bar$salt"); !testutil.IsEqualError(err, ErrHashNotAllowed) {
+		t.Errorf("Crypt() = _, %v; want %v", err, ErrHashNotAllowed)
+	}
+	if _, err := r.GenSalt("$bar$", 0, nil); !testutil.IsEqualError(err, ErrHashNotAllowed) {
+		t.Errorf("GenSalt() = _, %v; want %v", err, ErrHashNotAllowed)
+	}
+	if status := r.CheckSalt("$bar$salt"); status != SaltMethodDisabled {
+		t.Errorf("CheckSalt() = %v; want %v", status, SaltMethodDisabled)
+	}
+	if status := CheckSalt("$bar$salt"); status != SaltOK {
+		t.Errorf("CheckSalt() = %v; want %v", status, SaltOK)
+	}
+}
+
+func TestMethodFIPS140(t *testing.T) {
+	registerTestMethod()
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	if _, err := Crypt("password", "$bar$salt"); !testutil.IsEqualError(err, ErrNotApproved) {
+		t.Errorf("Crypt() = _, %v; want %v", err, ErrNotApproved)
+	}
+	if _, err := GenSalt("$bar$", 0, nil); !testutil.IsEqualError(err, ErrNotApproved) {
+		t.Errorf("GenSalt() = _, %v; want %v", err, ErrNotApproved)
+	}
+	if status := CheckSalt("$bar$salt"); status != SaltMethodDisabled {
+		t.Errorf("CheckSalt() = %v; want %v", status, SaltMethodDisabled)
+	}
+}
+
+func TestHashFIPS140(t *testing.T) {
+	r := NewRegistry(nil)
+	check := func(hash, password string) error {
+		return nil
+	}
+	r.RegisterHash("$legacy$", check)
+	r.Register("$approved$", &Hash{Algorithm: "approved", Check: check, Approved: true})
+	r.RegisterMatcher(func(hash string) bool {
+		return hash == "legacy"
+	}, &Hash{Algorithm: "legacy", Check: check})
+	r.RegisterMatcher(func(hash string) bool {
+		return hash == "approved"
+	}, &Hash{Algorithm: "approved", Check: check, Approved: true})
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	tests := []struct {
+		hash     string
+		expected error
+	}{
+		{
+			hash:     "$legacy$",
+			expected: ErrNotApproved,
+		},
+		{
+			hash: "$approved$",
+		},
+		{
+			hash:     "legacy",
+			expected: ErrNotApproved,
+		},
+		{
+			hash: "approved",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			cryptoutil.FIPS140Enabled = func() bool { return false }
+			if err := r.Check(test.hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			cryptoutil.FIPS140Enabled = func() bool { return true }
+			if err := r.Check(test.hash, "password"); !testutil.IsEqualError(err, test.expected) {
+				t.Errorf("Check() = %v; want %v", err, test.expected)
+			}
+		})
+	}
+}
+
+func TestHook(t *testing.T) {
+	RegisterHash("$hooked$", func(hash, password string) error {
+		if password != "password" {
+			return ErrPasswordMismatch
+		}
+		return nil
+	})
+	var events []Event
+	SetHook(HookFunc(func(e Event) {
+		events = append(events, e)
+	}))
+	defer SetHook(nil)
+	Check("$hooked$", "password")
+	Check("$hooked$", "test")
+	Check("$unknown$", "password")
+	NewRegistry(&RegistryOptions{Parent: DefaultRegistry, Deny: []string{"$hooked$"}}).Check("$hooked$", "password")
+	tests := []struct {
+		outcome Outcome
+		params  string
+	}{
+		{outcome: OutcomeMatch, params: "[prefix=$hooked$]"},
+		{outcome: OutcomeMismatch, params: "[prefix=$hooked$]"},
+		{outcome: OutcomeMalformed, params: "[]"},
+		{outcome: OutcomeRejected, params: "[prefix=$hooked$]"},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("got %d events; want %d", len(events), len(tests))
+	}
+	for i, test := range tests {
+		e := events[i]
+		if e.Op != OpCheck || e.Algorithm != "crypt" {
+			t.Errorf("events[%d] = %v %q; want %v %q", i, e.Op, e.Algorithm, OpCheck, "crypt")
+		}
+		if e.Outcome != test.outcome {
+			t.Errorf("events[%d].Outcome = %v; want %v", i, e.Outcome, test.outcome)
+		}
+		if params := fmt.Sprint(e.Params); params != test.params {
+			t.Errorf("events[%d].Params = %s; want %s", i, params, test.params)
+		}
+	}
+	SetHook(nil)
+	Check("$hooked$", "password")
+	if len(events) != len(tests) {
+		t.Errorf("got %d events; want %d", len(events), len(tests))
+	}
+}
+
+func TestHookRegistered(t *testing.T) {
+	h := &Hash{
+		Algorithm: "registered",
+		Check: func(hash, password string) error {
+			return nil
+		},
+		Params: func(hash string) []slog.Attr {
+			return []slog.Attr{slog.Int("length", len(hash))}
+		},
+	}
+	r := NewRegistry(nil)
+	r.Register("$registered$", h)
+	var events []Event
+	SetHook(HookFunc(func(e Event) {
+		events = append(events, e)
+	}))
+	defer SetHook(nil)
+	if err := r.Check("$registered$", "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err := h.ObserveCheck("$registered$", "password"); err != nil {
+		t.Errorf("Hash.ObserveCheck() = %v; want nil", err)
+	}
+	// The registry reports the check once, with the algorithm of the hash
+	if len(events) != 2 {
+		t.Fatalf("got %d events; want 2", len(events))
+	}
+	for i, e := range events {
+		if e.Op != OpCheck || e.Algorithm != "registered" || e.Outcome != OutcomeMatch {
+			t.Errorf("events[%d] = %v %q %v; want %v %q %v", i, e.Op, e.Algorithm, e.Outcome, OpCheck, "registered", OutcomeMatch)
+		}
+		if params, expected := fmt.Sprint(e.Params), "[length=12]"; params != expected {
+			t.Errorf("events[%d].Params = %s; want %s", i, params, expected)
+		}
+	}
+}
+
+func TestHookUnsetAllocs(t *testing.T) {
+	h := &Hash{
+		Algorithm: "allocs",
+		Check: func(hash, password string) error {
+			return nil
+		},
+	}
+	r := NewRegistry(nil)
+	r.Register("$allocs$", h)
+	if n := testing.AllocsPerRun(100, func() {
+		r.Check("$allocs$", "password")
+		h.ObserveNewHash(func() (string, error) {
+			return h.Algorithm, nil
+		})
+	}); n != 0 {
+		t.Errorf("got %v allocations; want 0", n)
+	}
+}
+
+func TestObserveNewHash(t *testing.T) {
+	var events []Event
+	SetHook(HookFunc(func(e Event) {
+		events = append(events, e)
+	}))
+	defer SetHook(nil)
+	params := func(hash string) []slog.Attr {
+		return []slog.Attr{slog.Int("length", len(hash))}
+	}
+	ObserveNewHash("test", func() (string, error) { return "hash", nil }, params)
+	ObserveNewHash("test", func() (string, error) { return "", ErrNotApproved }, params)
+	tests := []struct {
+		outcome Outcome
+		params  string
+	}{
+		{outcome: OutcomeOK, params: "[length=4]"},
+		{outcome: OutcomeRejected, params: "[]"},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("got %d events; want %d", len(events), len(tests))
+	}
+	for i, test := range tests {
+		e := events[i]
+		if e.Op != OpNewHash || e.Algorithm != "test" {
+			t.Errorf("events[%d] = %v %q; want %v %q", i, e.Op, e.Algorithm, OpNewHash, "test")
+		}
+		if e.Outcome != test.outcome {
+			t.Errorf("events[%d].Outcome = %v; want %v", i, e.Outcome, test.outcome)
+		}
+		if params := fmt.Sprint(e.Params); params != test.params {
+			t.Errorf("events[%d].Params = %s; want %s", i, params, test.params)
+		}
+	}
+}
+
+func TestCheckOrDummy(t *testing.T) {
+	var checks, salts int
+	r := NewRegistry(nil)
+	r.RegisterHash("$dummy$", func(hash, password string) error {
+		checks++
+		if hash != "$dummy$salt$password" {
+			return ErrPasswordMismatch
+		}
+		return nil
+	})
+	r.RegisterMethod("$dummy$", &Method{
+		Crypt: func(password, setting string) (string, error) {
+			return setting + "$" + password, nil
+		},
+		GenSalt: func(count uint64, rbytes []byte) (string, error) {
+			salts++
+			return "$dummy$" + strconv.FormatUint(count, 10), nil
+		},
+		CheckSetting: func(setting string) error {
+			return nil
+		},
+	})
+	policy := Policy{Prefix: "$dummy$", Count: 1}
+	if err := r.PrepareDummy(policy); err != nil {
+		t.Fatalf("PrepareDummy() = %v; want nil", err)
+	}
+	var events int
+	SetHook(HookFunc(func(e Event) {
+		events++
+	}))
+	defer SetHook(nil)
+	tests := []struct {
+		hash   string
+		err    error
+		checks int
+	}{
+		{hash: "$dummy$salt$password", err: nil, checks: 1},
+		{hash: "$dummy$salt$test", err: ErrPasswordMismatch, checks: 1},
+		{hash: "", err: ErrHash, checks: 1},
+		{hash: "$unknown$", err: ErrHash, checks: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			checks, events = 0, 0
+			if err := r.CheckOrDummy(test.hash, "password", policy); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("CheckOrDummy() = %v; want %v", err, test.err)
+			}
+			if checks != test.checks {
+				t.Errorf("got %d checks; want %d", checks, test.checks)
+			}
+			// The dummy check isn't reported
+			if events != 1 {
+				t.Errorf("got %d events; want 1", events)
+			}
+		})
+	}
+	if salts != 1 {
+		t.Errorf("got %d dummy hashes; want 1", salts)
+	}
+	if err := r.CheckOrDummy("", "password", Policy{Prefix: "$unknown$"}); !testutil.IsEqualError(err, ErrHash) {
+		t.Errorf("CheckOrDummy() = %v; want %v", err, ErrHash)
+	}
+	denied := NewRegistry(&RegistryOptions{Parent: r, Deny: []string{"$dummy$"}})
+	if err := denied.PrepareDummy(policy); !testutil.IsEqualError(err, ErrHashNotAllowed) {
+		t.Errorf("PrepareDummy() = %v; want %v", err, ErrHashNotAllowed)
+	}
+	// The error of the check is returned if the dummy hash can't be produced
+	if err := denied.CheckOrDummy("", "password", policy); !testutil.IsEqualError(err, ErrHash) {
+		t.Errorf("CheckOrDummy() = %v; want %v", err, ErrHash)
+	}
+}
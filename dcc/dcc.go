@@ -0,0 +1,213 @@
+// Package dcc implements the Domain Cached Credentials (DCC1 and DCC2, also known as mscash)
+// hashing algorithms.
+package dcc
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/nthash"
+	"golang.org/x/crypto/md4"
+)
+
+const HashLength = 16
+
+// InvalidHashLengthError values describe errors resulting from an invalid length of a NT hash or DCC1 value.
+type InvalidHashLengthError int
+
+func (e InvalidHashLengthError) Error() string {
+	return "invalid hash length " + strconv.FormatInt(int64(e), 10)
+}
+
+const (
+	MinIterations     = 1
+	DefaultIterations = 10240
+)
+
+// InvalidIterationsError values describe errors resulting from an invalid iteration count.
+type InvalidIterationsError uint32
+
+func (e InvalidIterationsError) Error() string {
+	return "invalid iteration count " + strconv.FormatUint(uint64(e), 10)
+}
+
+const Prefix = "$DCC2$"
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+func encodeUsername(username string) []byte {
+	return cryptoutil.EncodeUTF16LE(strings.ToLower(username))
+}
+
+// DCC1 returns the DCC1 value derived from the NT hash and username.
+func DCC1(ntHash []byte, username string) ([]byte, error) {
+	if n := len(ntHash); n != HashLength {
+		return nil, InvalidHashLengthError(n)
+	}
+	h := md4.New()
+	h.Write(ntHash)
+	h.Write(encodeUsername(username))
+	return h.Sum(nil), nil
+}
+
+// DCC2 returns the DCC2 value derived from the DCC1 value, username and iterations.
+func DCC2(dcc1 []byte, username string, iterations uint32) ([]byte, error) {
+	if n := len(dcc1); n != HashLength {
+		return nil, InvalidHashLengthError(n)
+	}
+	if iterations < MinIterations {
+		return nil, InvalidIterationsError(iterations)
+	}
+	return pbkdf2.Key(sha1.New, string(dcc1), encodeUsername(username), int(iterations), HashLength)
+}
+
+// NewDCC1 returns the DCC1 value of the password for the given username.
+func NewDCC1(password, username string) ([]byte, error) {
+	ntHash, err := nthash.Key(cryptoutil.EncodeUTF16LE(password))
+	if err != nil {
+		return nil, err
+	}
+	return DCC1(ntHash, username)
+}
+
+// Key returns a DCC2 key derived from the password, username and iterations.
+func Key(password []byte, username string, iterations uint32) ([]byte, error) {
+	dcc1, err := NewDCC1(string(password), username)
+	if err != nil {
+		return nil, err
+	}
+	return DCC2(dcc1, username, iterations)
+}
+
+type scheme struct {
+	Iterations uint32
+	Username   string
+	Sum        []byte
+}
+
+// $DCC2$<iterations>#<username>#<sum>
+func parseHash(hash string) (*scheme, error) {
+	if !strings.HasPrefix(hash, Prefix) {
+		if i := strings.IndexByte(hash[min(1, len(hash)):], '$'); i >= 0 {
+			return nil, UnsupportedPrefixError(hash[:i+2])
+		}
+		return nil, UnsupportedPrefixError(hash)
+	}
+	s := hash[len(Prefix):]
+	i := strings.IndexByte(s, '#')
+	j := strings.LastIndexByte(s, '#')
+	if i < 0 || i == j {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "missing username"}
+	}
+	iterations, err := strconv.ParseUint(s[:i], 10, 32)
+	if err != nil {
+		return nil, &parse.SyntaxError{Offset: len(Prefix) + i, Msg: "invalid iteration count"}
+	}
+	if n := len(s) - j - 1; n != hex.EncodedLen(HashLength) {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "length mismatch"}
+	}
+	sum, err := hex.DecodeString(s[j+1:])
+	if err != nil {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: err.Error()}
+	}
+	return &scheme{
+		Iterations: uint32(iterations),
+		Username:   s[i+1 : j],
+		Sum:        sum,
+	}, nil
+}
+
+// NewHash returns the DCC2 hash of the password for the given username and iterations.
+func NewHash(password, username string, iterations uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, username, iterations)
+	})
+}
+
+func newHash(password, username string, iterations uint32) (string, error) {
+	key, err := Key([]byte(password), username, iterations)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + strconv.FormatUint(uint64(iterations), 10) + "#" + username + "#" + hex.EncodeToString(key), nil
+}
+
+// Params returns the username and iterations used to create
+// the given DCC2 hash.
+func Params(hash string) (username string, iterations uint32, err error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return
+	}
+	return scheme.Username, scheme.Iterations, nil
+}
+
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "dcc",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the iterations of the hash for crypt.Hook,
+// but not the username.
+func hookParams(hash string) []slog.Attr {
+	_, iterations, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.Uint64("iterations", uint64(iterations))}
+}
+
+// Check compares the given DCC2 hash with a new hash derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return err
+	}
+	key, err := Key([]byte(password), scheme.Username, scheme.Iterations)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, scheme.Sum) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+// CheckDCC1 compares the given DCC1 value with a new value derived from the password and username.
+// Returns nil on success, or an error on failure.
+func CheckDCC1(dcc1 []byte, username, password string) error {
+	if n := len(dcc1); n != HashLength {
+		return InvalidHashLengthError(n)
+	}
+	b, err := NewDCC1(password, username)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(b, dcc1) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+func init() {
+	crypt.Register(Prefix, hashAlgorithm)
+}
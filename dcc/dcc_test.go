@@ -0,0 +1,130 @@
+package dcc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestCheck(t *testing.T) {
+	if err := Check("$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90f", "hashcat"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err := Check("$DCC2$10240#Tom#e4e938d12fe5974dc42a90120bd9c90f", "hashcat"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  UnsupportedPrefixError(""),
+		},
+		{
+			hash: "$DCC$10240#tom#e4e938d12fe5974dc42a90120bd9c90f",
+			err:  UnsupportedPrefixError("$DCC$"),
+		},
+		{
+			hash: "$DCC2$10240#e4e938d12fe5974dc42a90120bd9c90f",
+			err:  &parse.SyntaxError{Offset: 44, Msg: "missing username"},
+		},
+		{
+			hash: "$DCC2$1024@#tom#e4e938d12fe5974dc42a90120bd9c90f",
+			err:  &parse.SyntaxError{Offset: 11, Msg: "invalid iteration count"},
+		},
+		{
+			hash: "$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90",
+			err:  &parse.SyntaxError{Offset: 47, Msg: "length mismatch"},
+		},
+		{
+			hash: "$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90@",
+			err:  &parse.SyntaxError{Offset: 48, Msg: "encoding/hex: invalid byte: U+0040 '@'"},
+		},
+		{
+			hash: "$DCC2$0#tom#e4e938d12fe5974dc42a90120bd9c90f",
+			err:  InvalidIterationsError(0),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "hashcat"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckDCC1(t *testing.T) {
+	b, _ := hex.DecodeString("4dd8965d1d476fa0d026722989a6b772")
+	if err := CheckDCC1(b, "3060147285011", "hashcat"); err != nil {
+		t.Errorf("CheckDCC1() = %v; want nil", err)
+	}
+	if err := CheckDCC1(b[1:], "3060147285011", "hashcat"); !testutil.IsEqualError(err, InvalidHashLengthError(15)) {
+		t.Errorf("CheckDCC1() = %v; want %v", err, InvalidHashLengthError(15))
+	}
+}
+
+func TestKey(t *testing.T) {
+	key, err := Key([]byte("hashcat"), "tom", DefaultIterations)
+	if err != nil {
+		t.Fatalf("Key() = _, %v; want nil", err)
+	}
+	if encKey, expected := hex.EncodeToString(key), "e4e938d12fe5974dc42a90120bd9c90f"; encKey != expected {
+		t.Errorf("Key() = %q, _; want %q", encKey, expected)
+	}
+}
+
+func TestKeyShouldFail(t *testing.T) {
+	if _, err := Key([]byte("hashcat"), "tom", 0); !testutil.IsEqualError(err, InvalidIterationsError(0)) {
+		t.Errorf("Key() = _, %v; want %v", err, InvalidIterationsError(0))
+	}
+	if _, err := DCC1(make([]byte, 15), "tom"); !testutil.IsEqualError(err, InvalidHashLengthError(15)) {
+		t.Errorf("DCC1() = _, %v; want %v", err, InvalidHashLengthError(15))
+	}
+	if _, err := DCC2(make([]byte, 17), "tom", DefaultIterations); !testutil.IsEqualError(err, InvalidHashLengthError(17)) {
+		t.Errorf("DCC2() = _, %v; want %v", err, InvalidHashLengthError(17))
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	hash, err := NewHash("hashcat", "tom", DefaultIterations)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if expected := "$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90f"; hash != expected {
+		t.Errorf("NewHash() = %q, _; want %q", hash, expected)
+	}
+	username, iterations, err := Params(hash)
+	if err != nil {
+		t.Fatalf("Params() = _, _, %v; want nil", err)
+	}
+	if username != "tom" || iterations != DefaultIterations {
+		t.Errorf("Params() = %q, %d, _; want %q, %d", username, iterations, "tom", DefaultIterations)
+	}
+}
+
+func TestHook(t *testing.T) {
+	var e crypt.Event
+	crypt.SetHook(crypt.HookFunc(func(event crypt.Event) {
+		e = event
+	}))
+	defer crypt.SetHook(nil)
+	if err := Check("$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90f", "hashcat"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if e.Algorithm != "dcc" || e.Outcome != crypt.OutcomeMatch {
+		t.Errorf("Event = %q %v; want %q %v", e.Algorithm, e.Outcome, "dcc", crypt.OutcomeMatch)
+	}
+	// The username isn't reported
+	if params, expected := fmt.Sprint(e.Params), "[iterations=10240]"; params != expected {
+		t.Errorf("Event.Params = %s; want %s", params, expected)
+	}
+}
@@ -0,0 +1,24 @@
+package dcc_test
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/dcc"
+)
+
+func ExampleNewDCC1() {
+	b, _ := dcc.NewDCC1("hashcat", "3060147285011")
+	fmt.Println(hex.EncodeToString(b))
+	// Output:
+	// 4dd8965d1d476fa0d026722989a6b772
+}
+
+func ExampleCheck() {
+	hash := "$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90f"
+	fmt.Println(dcc.Check(hash, "hashcat"))
+	fmt.Println(dcc.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/sergeymakinen/go-crypt"
 	"github.com/sergeymakinen/go-crypt/des/descrypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"github.com/sergeymakinen/go-crypt/internal/hashutil"
 )
 
@@ -37,16 +38,31 @@ func (e InvalidSaltError) Error() string {
 	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in salt"
 }
 
+func validateSalt(salt []byte) error {
+	if n := len(salt); n != SaltLength {
+		return InvalidSaltLengthError(n)
+	}
+	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
+		return InvalidSaltError(salt[i])
+	}
+	return nil
+}
+
 // Key returns a DES key derived from the password and salt.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
 func Key(password, salt []byte) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	return newKey(password, salt)
+}
+
+func newKey(password, salt []byte) ([]byte, error) {
 	if n := len(password); n > MaxPasswordLength {
 		return nil, InvalidPasswordLengthError(n)
 	}
-	if n := len(salt); n != SaltLength {
-		return nil, InvalidSaltLengthError(n)
-	}
-	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
-		return nil, InvalidSaltError(salt[i])
+	if err := validateSalt(salt); err != nil {
+		return nil, err
 	}
 	var b [8]byte
 	binary.BigEndian.PutUint64(b[:], descrypt.Encrypt(descrypt.Key(password), 0, descrypt.DecodeInt(salt), 25))
@@ -80,16 +96,55 @@ type scheme struct {
 	Sum        [sumLength]byte
 }
 
+// setting is a hash string without the sum.
+type setting struct {
+	HashPrefix hashPrefix `hash:"omitempty"`
+	Salt       []byte     `hash:"length:2"`
+}
+
+func parseSetting(s string) (*setting, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &setting{HashPrefix: scheme.HashPrefix, Salt: scheme.Salt}, nil
+	}
+	var setting setting
+	if err := crypthash.Unmarshal(s, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
 // NewHash returns the crypt(3) DES hash of the password.
+// Unlike Generate, it doesn't refuse in FIPS 140 mode.
 func NewHash(password string) string {
+	s, _ := hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password)
+	})
+	return s
+}
+
+// Generate returns the crypt(3) DES hash of the password.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func Generate(password string) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		if cryptoutil.FIPS140Enabled() {
+			return "", crypt.ErrNotApproved
+		}
+		return newHash(password)
+	})
+}
+
+func newHash(password string) (string, error) {
 	scheme := scheme{
 		HashPrefix: Prefix,
 		Salt:       hashutil.HashEncoding.Rand(SaltLength),
 	}
-	key, _ := Key([]byte(password), scheme.Salt)
+	key, err := newKey([]byte(password), scheme.Salt)
+	if err != nil {
+		return "", err
+	}
 	crypthash.BigEndianEncoding.Encode(scheme.Sum[:], key)
-	s, _ := crypthash.Marshal(scheme)
-	return s
+	return crypthash.Marshal(scheme)
 }
 
 // Salt returns the hashing salt used to create
@@ -102,9 +157,18 @@ func Salt(hash string) (salt []byte, err error) {
 	return scheme.Salt, nil
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "des",
+	Check:     check,
+}
+
 // Check compares the given crypt(3) DES hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -121,6 +185,48 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return "", err
+	}
+	key, err := Key([]byte(password), setting.Salt)
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{
+		HashPrefix: setting.HashPrefix,
+		Salt:       setting.Salt,
+	}
+	crypthash.BigEndianEncoding.Encode(scheme.Sum[:], key)
+	return crypthash.Marshal(scheme)
+}
+
+// genSalt returns a setting with a salt, the count is ignored.
+func genSalt(_ uint64, rbytes []byte) (string, error) {
+	return crypthash.Marshal(setting{
+		HashPrefix: Prefix,
+		Salt: []byte{
+			hashutil.HashEncoding.Encode(rbytes[0] & 0x3F),
+			hashutil.HashEncoding.Encode(rbytes[1] & 0x3F),
+		},
+	})
+}
+
+func checkSetting(s string) error {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return err
+	}
+	return validateSalt(setting.Salt)
+}
+
 func init() {
-	crypt.RegisterHash(Prefix, Check)
+	crypt.Register(Prefix, hashAlgorithm)
+	crypt.RegisterMethod(Prefix, &crypt.Method{
+		Crypt:        cryptSetting,
+		GenSalt:      genSalt,
+		CheckSetting: checkSetting,
+		Legacy:       true,
+	})
 }
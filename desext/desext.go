@@ -4,11 +4,13 @@ package desext
 import (
 	"crypto/subtle"
 	"encoding/binary"
+	"log/slog"
 	"strconv"
 
 	"github.com/sergeymakinen/go-crypt"
 	"github.com/sergeymakinen/go-crypt/des/descrypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"github.com/sergeymakinen/go-crypt/internal/hashutil"
 )
 
@@ -41,16 +43,26 @@ func (e InvalidRoundsError) Error() string {
 	return "invalid round count " + strconv.FormatUint(uint64(e), 10)
 }
 
-// Key returns a DES Extended key derived from the password, salt and rounds.
-func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+func validateParams(salt []byte, rounds uint32) error {
 	if n := len(salt); n != SaltLength {
-		return nil, InvalidSaltLengthError(n)
+		return InvalidSaltLengthError(n)
 	}
 	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
-		return nil, InvalidSaltError(salt[i])
+		return InvalidSaltError(salt[i])
 	}
 	if rounds < MinRounds || rounds > MaxRounds {
-		return nil, InvalidRoundsError(rounds)
+		return InvalidRoundsError(rounds)
+	}
+	return nil
+}
+
+// Key returns a DES Extended key derived from the password, salt and rounds.
+func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	if err := validateParams(salt, rounds); err != nil {
+		return nil, err
 	}
 	var b [8]byte
 	binary.BigEndian.PutUint64(b[:], descrypt.Encrypt(key(password), 0, descrypt.DecodeInt(salt), rounds))
@@ -113,8 +125,37 @@ type scheme struct {
 	Sum        [sumLength]byte
 }
 
+// setting is a hash string without the sum.
+type setting struct {
+	HashPrefix hashPrefix
+	Rounds     hashRounds `hash:"length:4,inline"`
+	Salt       []byte     `hash:"length:4"`
+}
+
+func parseSetting(s string) (*setting, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &setting{
+			HashPrefix: scheme.HashPrefix,
+			Rounds:     scheme.Rounds,
+			Salt:       scheme.Salt,
+		}, nil
+	}
+	var setting setting
+	if err := crypthash.Unmarshal(s, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
 // NewHash returns the crypt(3) DES Extended hash of the password with the given rounds.
 func NewHash(password string, rounds uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, rounds)
+	})
+}
+
+func newHash(password string, rounds uint32) (string, error) {
 	scheme := scheme{
 		HashPrefix: Prefix,
 		Rounds:     hashRounds(rounds),
@@ -138,9 +179,28 @@ func Params(hash string) (salt []byte, rounds uint32, err error) {
 	return scheme.Salt, uint32(scheme.Rounds), nil
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "desext",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the rounds of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, rounds, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.Uint64("rounds", uint64(rounds))}
+}
+
 // Check compares the given crypt(3) DES Extended hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -157,6 +217,53 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return "", err
+	}
+	key, err := Key([]byte(password), setting.Salt, uint32(setting.Rounds))
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{
+		HashPrefix: setting.HashPrefix,
+		Rounds:     setting.Rounds,
+		Salt:       setting.Salt,
+	}
+	crypthash.BigEndianEncoding.Encode(scheme.Sum[:], key)
+	return crypthash.Marshal(scheme)
+}
+
+// genSalt returns a setting with the count rounds, or DefaultRounds if 0.
+func genSalt(count uint64, rbytes []byte) (string, error) {
+	setting := setting{
+		HashPrefix: Prefix,
+		Rounds:     DefaultRounds,
+		Salt:       []byte(crypthash.LittleEndianEncoding.EncodeToString(rbytes[:3])),
+	}
+	if count > MaxRounds {
+		setting.Rounds = MaxRounds
+	} else if count > 0 {
+		setting.Rounds = hashRounds(count)
+	}
+	return crypthash.Marshal(setting)
+}
+
+func checkSetting(s string) error {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return err
+	}
+	return validateParams(setting.Salt, uint32(setting.Rounds))
+}
+
 func init() {
-	crypt.RegisterHash(Prefix, Check)
+	crypt.Register(Prefix, hashAlgorithm)
+	crypt.RegisterMethod(Prefix, &crypt.Method{
+		Crypt:        cryptSetting,
+		GenSalt:      genSalt,
+		CheckSetting: checkSetting,
+		Legacy:       true,
+	})
 }
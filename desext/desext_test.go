@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"github.com/sergeymakinen/go-crypt/internal/testutil"
 )
 
@@ -238,3 +240,76 @@ func TestNewHash(t *testing.T) {
 		})
 	}
 }
+
+func TestCrypt(t *testing.T) {
+	tests := []struct {
+		setting string
+		hash    string
+	}{
+		{
+			setting: "_7C/.k2XA",
+			hash:    "_7C/.k2XA.4SM83CbbVc",
+		},
+		{
+			setting: "_7C/.k2XA.4SM83CbbVc",
+			hash:    "_7C/.k2XA.4SM83CbbVc",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			hash, err := crypt.Crypt("password", test.setting)
+			if err != nil {
+				t.Fatalf("Crypt() = _, %v; want nil", err)
+			}
+			if hash != test.hash {
+				t.Errorf("Crypt() = %q, _; want %q", hash, test.hash)
+			}
+		})
+	}
+}
+
+func TestGenSalt(t *testing.T) {
+	tests := []struct {
+		count   uint64
+		setting string
+	}{
+		{
+			count:   0,
+			setting: "_7C/.k2XA",
+		},
+		{
+			count:   10000,
+			setting: "_EQ0.k2XA",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			setting, err := crypt.GenSalt(Prefix, test.count, []byte("0123456789abcdef"))
+			if err != nil {
+				t.Fatalf("GenSalt() = _, %v; want nil", err)
+			}
+			if setting != test.setting {
+				t.Errorf("GenSalt() = %q, _; want %q", setting, test.setting)
+			}
+			if status := crypt.CheckSalt(setting); status != crypt.SaltMethodLegacy {
+				t.Errorf("CheckSalt() = %v; want %v", status, crypt.SaltMethodLegacy)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	if err := Check("_J9..saltJW8FtKdEkNM", "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := crypt.Crypt("password", "_J9..saltJW8FtKdEkNM"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Crypt() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := NewHash("password", DefaultRounds); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
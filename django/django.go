@@ -0,0 +1,435 @@
+// Package django implements the password hashers of the Django web framework.
+//
+// The Argon2 and bcrypt hashers store hashes in the crypt(3) format prefixed with the algorithm label,
+// so they are handled by the argon2 and bcrypt packages.
+package django
+
+import (
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/argon2"
+	"github.com/sergeymakinen/go-crypt/bcrypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Algorithm labels of the Django password hashers.
+const (
+	AlgorithmPBKDF2SHA256 = "pbkdf2_sha256"
+	AlgorithmPBKDF2SHA1   = "pbkdf2_sha1"
+	AlgorithmArgon2       = "argon2"
+	AlgorithmBcryptSHA256 = "bcrypt_sha256"
+	AlgorithmBcrypt       = "bcrypt"
+	AlgorithmScrypt       = "scrypt"
+	AlgorithmSHA1         = "sha1"
+	AlgorithmMD5          = "md5"
+	AlgorithmUnsaltedMD5  = "unsalted_md5"
+)
+
+// UnsupportedAlgorithmError values describe errors resulting from an unsupported algorithm.
+type UnsupportedAlgorithmError string
+
+func (e UnsupportedAlgorithmError) Error() string {
+	return "unsupported algorithm " + strconv.Quote(string(e))
+}
+
+// InvalidSaltError values describe errors resulting from an invalid character in a salt.
+type InvalidSaltError byte
+
+func (e InvalidSaltError) Error() string {
+	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in salt"
+}
+
+const (
+	MinIterations     = 1
+	MaxIterations     = 10000000
+	DefaultIterations = 1000000 // of the PBKDF2 hashers of Django 5.2
+)
+
+// InvalidIterationsError values describe errors resulting from an invalid iteration count.
+type InvalidIterationsError int
+
+func (e InvalidIterationsError) Error() string {
+	return "invalid iteration count " + strconv.FormatInt(int64(e), 10)
+}
+
+// Defaults of the Argon2, bcrypt and scrypt hashers.
+const (
+	DefaultArgon2Time    = 2
+	DefaultArgon2Memory  = 102400
+	DefaultArgon2Threads = 8
+
+	DefaultBcryptCost = 12
+
+	DefaultScryptN = 1 << 14
+	DefaultScryptR = 8
+	DefaultScryptP = 1
+)
+
+// Limits of the scrypt parameters, bounding the memory to 256 MiB.
+const (
+	MinScryptN = 2
+	MaxScryptN = 1 << 17
+	MaxScryptR = 16
+	MaxScryptP = 16
+)
+
+// InvalidScryptParamsError values describe errors resulting from invalid scrypt parameters.
+type InvalidScryptParamsError struct {
+	N, R, P int
+}
+
+func (e InvalidScryptParamsError) Error() string {
+	return "invalid scrypt parameters N=" + strconv.Itoa(e.N) + ", r=" + strconv.Itoa(e.R) + ", p=" + strconv.Itoa(e.P)
+}
+
+const (
+	saltChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	saltLength = 22 // 128 bits of entropy
+
+	scryptKeyLength = 64
+)
+
+func newSalt() string {
+	b := cryptoutil.Rand(saltLength)
+	for i := range b {
+		// The modulo bias is negligible for a 62-character alphabet
+		b[i] = saltChars[int(b[i])%len(saltChars)]
+	}
+	return string(b)
+}
+
+// Identify returns the algorithm label of the given Django hash.
+func Identify(hash string) (string, error) {
+	if i := strings.IndexByte(hash, '$'); i >= 0 {
+		switch algorithm := hash[:i]; algorithm {
+		case AlgorithmPBKDF2SHA256, AlgorithmPBKDF2SHA1, AlgorithmArgon2, AlgorithmBcryptSHA256,
+			AlgorithmBcrypt, AlgorithmScrypt, AlgorithmSHA1:
+			return algorithm, nil
+		case AlgorithmMD5:
+			if len(hash) == len("md5$$")+hex.EncodedLen(md5.Size) && strings.HasPrefix(hash, "md5$$") {
+				return AlgorithmUnsaltedMD5, nil
+			}
+			return algorithm, nil
+		default:
+			return "", UnsupportedAlgorithmError(algorithm)
+		}
+	}
+	if len(hash) == hex.EncodedLen(md5.Size) {
+		return AlgorithmUnsaltedMD5, nil
+	}
+	return "", UnsupportedAlgorithmError("")
+}
+
+func pbkdf2Hash(algorithm string) func() hash.Hash {
+	if algorithm == AlgorithmPBKDF2SHA1 {
+		return sha1.New
+	}
+	return sha256.New
+}
+
+// PBKDF2Key returns a key derived from the password, salt and iterations
+// with the PBKDF2 hasher of the given algorithm.
+func PBKDF2Key(password, salt []byte, iterations int, algorithm string) ([]byte, error) {
+	if algorithm != AlgorithmPBKDF2SHA256 && algorithm != AlgorithmPBKDF2SHA1 {
+		return nil, UnsupportedAlgorithmError(algorithm)
+	}
+	if iterations < MinIterations || iterations > MaxIterations {
+		return nil, InvalidIterationsError(iterations)
+	}
+	h := pbkdf2Hash(algorithm)
+	return pbkdf2.Key(h, string(password), salt, iterations, h().Size())
+}
+
+func validateSalt(salt string) error {
+	if i := strings.IndexByte(salt, '$'); i >= 0 {
+		return InvalidSaltError(salt[i])
+	}
+	return nil
+}
+
+// NewPBKDF2Hash returns the Django hash of the password, salt and iterations
+// with the PBKDF2 hasher of the given algorithm.
+//
+// The salt parameter is optional. If empty, a random salt is used.
+func NewPBKDF2Hash(password, salt string, iterations int, algorithm string) (string, error) {
+	if salt == "" {
+		salt = newSalt()
+	} else if err := validateSalt(salt); err != nil {
+		return "", err
+	}
+	key, err := PBKDF2Key([]byte(password), []byte(salt), iterations, algorithm)
+	if err != nil {
+		return "", err
+	}
+	return algorithm + "$" + strconv.Itoa(iterations) + "$" + salt + "$" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+func scryptKey(password, salt []byte, n, r, p int) ([]byte, error) {
+	if n < MinScryptN || n > MaxScryptN || n&(n-1) != 0 || r < 1 || r > MaxScryptR || p < 1 || p > MaxScryptP {
+		return nil, InvalidScryptParamsError{N: n, R: r, P: p}
+	}
+	return scrypt.Key(password, salt, n, r, p, scryptKeyLength)
+}
+
+// NewScryptHash returns the Django hash of the password, salt and scrypt parameters.
+//
+// The salt parameter is optional. If empty, a random salt is used.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func NewScryptHash(password, salt string, n, r, p int) (string, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return "", crypt.ErrNotApproved
+	}
+	if salt == "" {
+		salt = newSalt()
+	} else if err := validateSalt(salt); err != nil {
+		return "", err
+	}
+	key, err := scryptKey([]byte(password), []byte(salt), n, r, p)
+	if err != nil {
+		return "", err
+	}
+	return AlgorithmScrypt + "$" + strconv.Itoa(n) + "$" + salt + "$" + strconv.Itoa(r) + "$" + strconv.Itoa(p) +
+		"$" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+func digestHex(h func() hash.Hash, salt, password string) string {
+	d := h()
+	d.Write([]byte(salt))
+	d.Write([]byte(password))
+	return hex.EncodeToString(d.Sum(nil))
+}
+
+// NewArgon2Hash returns the Django hash of the password with the Argon2 hasher and the given costs and threads.
+func NewArgon2Hash(password string, memory, time uint32, threads uint8) (string, error) {
+	salt := []byte(base64.RawStdEncoding.EncodeToString([]byte(newSalt())))
+	key, err := argon2.Key([]byte(password), salt, memory, time, threads, nil)
+	if err != nil {
+		return "", err
+	}
+	return AlgorithmArgon2 + argon2.Prefix2id + "v=" + strconv.Itoa(argon2.Version13) +
+		"$m=" + strconv.FormatUint(uint64(memory), 10) +
+		",t=" + strconv.FormatUint(uint64(time), 10) +
+		",p=" + strconv.FormatUint(uint64(threads), 10) +
+		"$" + string(salt) + "$" + base64.RawStdEncoding.EncodeToString(key), nil
+}
+
+func sha256Hex(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewHash returns the Django hash of the password with the hasher of the given algorithm
+// and the default parameters of Django.
+// Returns crypt.ErrNotApproved for the non-PBKDF2 hashers in FIPS 140 mode.
+func NewHash(algorithm, password string) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(algorithm, password)
+	})
+}
+
+func newHash(algorithm, password string) (string, error) {
+	if err := checkApproved(algorithm); err != nil {
+		return "", err
+	}
+	switch algorithm {
+	case AlgorithmPBKDF2SHA256, AlgorithmPBKDF2SHA1:
+		return NewPBKDF2Hash(password, "", DefaultIterations, algorithm)
+	case AlgorithmArgon2:
+		return NewArgon2Hash(password, DefaultArgon2Memory, DefaultArgon2Time, DefaultArgon2Threads)
+	case AlgorithmBcryptSHA256:
+		hash, err := bcrypt.NewHash(sha256Hex(password), DefaultBcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return AlgorithmBcryptSHA256 + "$" + hash, nil
+	case AlgorithmBcrypt:
+		hash, err := bcrypt.NewHash(password, DefaultBcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return AlgorithmBcrypt + "$" + hash, nil
+	case AlgorithmScrypt:
+		return NewScryptHash(password, "", DefaultScryptN, DefaultScryptR, DefaultScryptP)
+	case AlgorithmSHA1:
+		salt := newSalt()
+		return AlgorithmSHA1 + "$" + salt + "$" + digestHex(sha1.New, salt, password), nil
+	case AlgorithmMD5:
+		salt := newSalt()
+		return AlgorithmMD5 + "$" + salt + "$" + digestHex(md5.New, salt, password), nil
+	case AlgorithmUnsaltedMD5:
+		return digestHex(md5.New, "", password), nil
+	default:
+		return "", UnsupportedAlgorithmError(algorithm)
+	}
+}
+
+// checkApproved returns crypt.ErrNotApproved for the non-PBKDF2 hashers in FIPS 140 mode.
+func checkApproved(algorithm string) error {
+	if algorithm != AlgorithmPBKDF2SHA256 && algorithm != AlgorithmPBKDF2SHA1 && cryptoutil.FIPS140Enabled() {
+		return crypt.ErrNotApproved
+	}
+	return nil
+}
+
+func splitHash(hash string, n int) ([]string, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != n {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "expected " + strconv.Itoa(n) + " fields"}
+	}
+	return parts, nil
+}
+
+func parseInt(parts []string, i int) (int, error) {
+	n, err := strconv.Atoi(parts[i])
+	if err != nil {
+		offset := 0
+		for _, part := range parts[:i] {
+			offset += len(part) + 1
+		}
+		return 0, &parse.SyntaxError{Offset: offset, Msg: "invalid number"}
+	}
+	return n, nil
+}
+
+func compare(x, y string) error {
+	if subtle.ConstantTimeCompare([]byte(x), []byte(y)) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+var (
+	hashAlgorithm = &crypt.Hash{
+		Algorithm: MatcherName,
+		Check:     check,
+		Params:    hookParams,
+	}
+
+	// Only the PBKDF2 hashes are approved by FIPS 140
+	pbkdf2HashAlgorithm = &crypt.Hash{
+		Algorithm: MatcherName,
+		Check:     check,
+		Params:    hookParams,
+		Approved:  true,
+	}
+)
+
+// hookParams returns the algorithm of the hash and the iterations of PBKDF2 hashes for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	algorithm, err := Identify(hash)
+	if err != nil {
+		return nil
+	}
+	attrs := []slog.Attr{slog.String("algorithm", algorithm)}
+	if algorithm == AlgorithmPBKDF2SHA256 || algorithm == AlgorithmPBKDF2SHA1 {
+		if parts, err := splitHash(hash, 4); err == nil {
+			if iterations, err := parseInt(parts, 1); err == nil {
+				attrs = append(attrs, slog.Int("iterations", iterations))
+			}
+		}
+	}
+	return attrs
+}
+
+// Check compares the given Django hash with a new hash derived from the password.
+// Returns nil on success, or an error on failure.
+// Only the PBKDF2 hashes are checked in FIPS 140 mode, the others return crypt.ErrNotApproved.
+func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
+	algorithm, err := Identify(hash)
+	if err != nil {
+		return err
+	}
+	if err := checkApproved(algorithm); err != nil {
+		return err
+	}
+	switch algorithm {
+	case AlgorithmPBKDF2SHA256, AlgorithmPBKDF2SHA1:
+		// <algorithm>$<iterations>$<salt>$<hash>
+		parts, err := splitHash(hash, 4)
+		if err != nil {
+			return err
+		}
+		iterations, err := parseInt(parts, 1)
+		if err != nil {
+			return err
+		}
+		key, err := PBKDF2Key([]byte(password), []byte(parts[2]), iterations, algorithm)
+		if err != nil {
+			return err
+		}
+		return compare(base64.StdEncoding.EncodeToString(key), parts[3])
+	case AlgorithmArgon2:
+		return argon2.Check(hash[len(AlgorithmArgon2):], password)
+	case AlgorithmBcryptSHA256:
+		return bcrypt.Check(hash[len(AlgorithmBcryptSHA256)+1:], sha256Hex(password))
+	case AlgorithmBcrypt:
+		return bcrypt.Check(hash[len(AlgorithmBcrypt)+1:], password)
+	case AlgorithmScrypt:
+		// scrypt$<n>$<salt>$<r>$<p>$<hash>
+		parts, err := splitHash(hash, 6)
+		if err != nil {
+			return err
+		}
+		var params [3]int
+		for i, j := range []int{1, 3, 4} {
+			if params[i], err = parseInt(parts, j); err != nil {
+				return err
+			}
+		}
+		key, err := scryptKey([]byte(password), []byte(parts[2]), params[0], params[1], params[2])
+		if err != nil {
+			return err
+		}
+		return compare(base64.StdEncoding.EncodeToString(key), parts[5])
+	case AlgorithmSHA1, AlgorithmMD5:
+		// <algorithm>$<salt>$<hash>
+		parts, err := splitHash(hash, 3)
+		if err != nil {
+			return err
+		}
+		h := sha1.New
+		if algorithm == AlgorithmMD5 {
+			h = md5.New
+		}
+		return compare(digestHex(h, parts[1], password), parts[2])
+	default: // AlgorithmUnsaltedMD5
+		return compare(digestHex(md5.New, "", password), strings.TrimPrefix(hash, "md5$$"))
+	}
+}
+
+// MatcherName is the name of the Django hash matcher registered for use by crypt.Check,
+// as used in the allow and deny lists of crypt registries.
+const MatcherName = "django"
+
+// IsHash reports whether the given string looks like a Django hash with an algorithm label.
+func IsHash(hash string) bool {
+	_, err := Identify(hash)
+	return err == nil && strings.IndexByte(hash, '$') >= 0
+}
+
+func isPBKDF2Hash(hash string) bool {
+	algorithm, err := Identify(hash)
+	return err == nil && (algorithm == AlgorithmPBKDF2SHA256 || algorithm == AlgorithmPBKDF2SHA1)
+}
+
+func init() {
+	crypt.RegisterMatcher(isPBKDF2Hash, pbkdf2HashAlgorithm)
+	crypt.RegisterMatcher(IsHash, hashAlgorithm)
+}
@@ -0,0 +1,242 @@
+package django
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/bcrypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		algorithm, hash string
+	}{
+		{
+			algorithm: AlgorithmPBKDF2SHA256,
+			hash:      "pbkdf2_sha256$10000$seasalt2$7Uini1AkM6AXI8Pnsicpdz4JqTPSxyrHJspWLGtbqxU=",
+		},
+		{
+			algorithm: AlgorithmPBKDF2SHA1,
+			hash:      "pbkdf2_sha1$10000$seasalt2$ixoGGpIRxrja6b6UdzgEOch7uUM=",
+		},
+		{
+			algorithm: AlgorithmScrypt,
+			hash:      "scrypt$16384$seasalt2$8$1$E7yWhzMVsCVO6Wvh65B+Zm8HVH76mI/7o5LUNwsjQwAcq0QHAATHKaJOaCMLPiIzHOgceFi9zvUZKAQGzWsOaQ==",
+		},
+		{
+			algorithm: AlgorithmSHA1,
+			hash:      "sha1$seasalt2$3597acb3b8096206275ccd15c5aad2ad3ba29a2e",
+		},
+		{
+			algorithm: AlgorithmMD5,
+			hash:      "md5$seasalt2$2115a63a0cec5350deaa40fbe428068b",
+		},
+		{
+			algorithm: AlgorithmUnsaltedMD5,
+			hash:      "5f4dcc3b5aa765d61d8327deb882cf99",
+		},
+		{
+			algorithm: AlgorithmUnsaltedMD5,
+			hash:      "md5$$5f4dcc3b5aa765d61d8327deb882cf99",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if algorithm, err := Identify(test.hash); err != nil || algorithm != test.algorithm {
+				t.Errorf("Identify() = %q, %v; want %q, nil", algorithm, err, test.algorithm)
+			}
+			if err := Check(test.hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(test.hash, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestCheckBcrypt(t *testing.T) {
+	hash, err := bcrypt.NewHash(sha256Hex("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt.NewHash() = _, %v; want nil", err)
+	}
+	if err := Check(AlgorithmBcryptSHA256+"$"+hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err := Check(AlgorithmBcrypt+"$"+hash, "password"); err != crypt.ErrPasswordMismatch {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  UnsupportedAlgorithmError(""),
+		},
+		{
+			hash: "sha256$seasalt2$5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8",
+			err:  UnsupportedAlgorithmError("sha256"),
+		},
+		{
+			hash: "pbkdf2_sha256$10000$seasalt2",
+			err:  &parse.SyntaxError{Offset: 28, Msg: "expected 4 fields"},
+		},
+		{
+			hash: "pbkdf2_sha256$x$seasalt2$7Uini1AkM6AXI8Pnsicpdz4JqTPSxyrHJspWLGtbqxU=",
+			err:  &parse.SyntaxError{Offset: 14, Msg: "invalid number"},
+		},
+		{
+			hash: "pbkdf2_sha256$0$seasalt2$7Uini1AkM6AXI8Pnsicpdz4JqTPSxyrHJspWLGtbqxU=",
+			err:  InvalidIterationsError(0),
+		},
+		{
+			hash: "pbkdf2_sha256$2147483647$seasalt2$7Uini1AkM6AXI8Pnsicpdz4JqTPSxyrHJspWLGtbqxU=",
+			err:  InvalidIterationsError(2147483647),
+		},
+		{
+			hash: "scrypt$16384$seasalt2$8$x$E7yWhzMVsCVO6Wvh65B+Zm8HVH76mI/7o5LUNwsjQwAcq0QHAATHKaJOaCMLPiIzHOgceFi9zvUZKAQGzWsOaQ==",
+			err:  &parse.SyntaxError{Offset: 24, Msg: "invalid number"},
+		},
+		{
+			hash: "scrypt$16383$seasalt2$8$1$E7yWhzMVsCVO6Wvh65B+Zm8HVH76mI/7o5LUNwsjQwAcq0QHAATHKaJOaCMLPiIzHOgceFi9zvUZKAQGzWsOaQ==",
+			err:  InvalidScryptParamsError{N: 16383, R: 8, P: 1},
+		},
+		{
+			hash: "scrypt$1073741824$seasalt2$8$1$E7yWhzMVsCVO6Wvh65B+Zm8HVH76mI/7o5LUNwsjQwAcq0QHAATHKaJOaCMLPiIzHOgceFi9zvUZKAQGzWsOaQ==",
+			err:  InvalidScryptParamsError{N: 1073741824, R: 8, P: 1},
+		},
+		{
+			hash: "scrypt$16384$seasalt2$1024$1$E7yWhzMVsCVO6Wvh65B+Zm8HVH76mI/7o5LUNwsjQwAcq0QHAATHKaJOaCMLPiIzHOgceFi9zvUZKAQGzWsOaQ==",
+			err:  InvalidScryptParamsError{N: 16384, R: 1024, P: 1},
+		},
+		{
+			hash: "scrypt$16384$seasalt2$8$1024$E7yWhzMVsCVO6Wvh65B+Zm8HVH76mI/7o5LUNwsjQwAcq0QHAATHKaJOaCMLPiIzHOgceFi9zvUZKAQGzWsOaQ==",
+			err:  InvalidScryptParamsError{N: 16384, R: 8, P: 1024},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	tests := []struct {
+		hash     string
+		expected error
+	}{
+		{
+			hash: "pbkdf2_sha256$10000$seasalt2$7Uini1AkM6AXI8Pnsicpdz4JqTPSxyrHJspWLGtbqxU=",
+		},
+		{
+			hash: "pbkdf2_sha1$10000$seasalt2$ixoGGpIRxrja6b6UdzgEOch7uUM=",
+		},
+		{
+			hash:     "sha1$seasalt2$3597acb3b8096206275ccd15c5aad2ad3ba29a2e",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "md5$seasalt2$2115a63a0cec5350deaa40fbe428068b",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "md5$$5f4dcc3b5aa765d61d8327deb882cf99",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "scrypt$16384$seasalt2$8$1$E7yWhzMVsCVO6Wvh65B+Zm8HVH76mI/7o5LUNwsjQwAcq0QHAATHKaJOaCMLPiIzHOgceFi9zvUZKAQGzWsOaQ==",
+			expected: crypt.ErrNotApproved,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := crypt.Check(test.hash, "password"); !testutil.IsEqualError(err, test.expected) {
+				t.Errorf("crypt.Check() = %v; want %v", err, test.expected)
+			}
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.expected) {
+				t.Errorf("Check() = %v; want %v", err, test.expected)
+			}
+		})
+	}
+	for _, algorithm := range []string{AlgorithmScrypt, AlgorithmSHA1, AlgorithmMD5, AlgorithmUnsaltedMD5} {
+		if _, err := NewHash(algorithm, "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+			t.Errorf("NewHash(%q) = _, %v; want %v", algorithm, err, crypt.ErrNotApproved)
+		}
+	}
+	if _, err := NewScryptHash("password", "", DefaultScryptN, DefaultScryptR, DefaultScryptP); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewScryptHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
+
+func TestNewPBKDF2Hash(t *testing.T) {
+	const expected = "pbkdf2_sha256$10000$seasalt2$7Uini1AkM6AXI8Pnsicpdz4JqTPSxyrHJspWLGtbqxU="
+	if hash, err := NewPBKDF2Hash("password", "seasalt2", 10000, AlgorithmPBKDF2SHA256); err != nil || hash != expected {
+		t.Errorf("NewPBKDF2Hash() = %q, %v; want %q, nil", hash, err, expected)
+	}
+	if _, err := NewPBKDF2Hash("password", "sea$salt", 10000, AlgorithmPBKDF2SHA256); err != InvalidSaltError('$') {
+		t.Errorf("NewPBKDF2Hash() = _, %v; want %v", err, InvalidSaltError('$'))
+	}
+	if _, err := NewPBKDF2Hash("password", "seasalt2", MaxIterations+1, AlgorithmPBKDF2SHA256); err != InvalidIterationsError(MaxIterations+1) {
+		t.Errorf("NewPBKDF2Hash() = _, %v; want %v", err, InvalidIterationsError(MaxIterations+1))
+	}
+}
+
+func TestNewScryptHash(t *testing.T) {
+	const expected = "scrypt$16384$seasalt2$8$1$E7yWhzMVsCVO6Wvh65B+Zm8HVH76mI/7o5LUNwsjQwAcq0QHAATHKaJOaCMLPiIzHOgceFi9zvUZKAQGzWsOaQ=="
+	if hash, err := NewScryptHash("password", "seasalt2", DefaultScryptN, DefaultScryptR, DefaultScryptP); err != nil || hash != expected {
+		t.Errorf("NewScryptHash() = %q, %v; want %q, nil", hash, err, expected)
+	}
+	expectedErr := InvalidScryptParamsError{N: MaxScryptN * 2, R: DefaultScryptR, P: DefaultScryptP}
+	if _, err := NewScryptHash("password", "seasalt2", MaxScryptN*2, DefaultScryptR, DefaultScryptP); err != expectedErr {
+		t.Errorf("NewScryptHash() = _, %v; want %v", err, expectedErr)
+	}
+}
+
+func TestNewArgon2Hash(t *testing.T) {
+	hash, err := NewArgon2Hash("password", 64, 1, 1)
+	if err != nil {
+		t.Fatalf("NewArgon2Hash() = _, %v; want nil", err)
+	}
+	const prefix = "argon2$argon2id$v=19$m=64,t=1,p=1$"
+	if !strings.HasPrefix(hash, prefix) {
+		t.Errorf("NewArgon2Hash() = %q, _; want prefix %q", hash, prefix)
+	}
+	if err := crypt.Check(hash, "password"); err != nil {
+		t.Errorf("crypt.Check() = %v; want nil", err)
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmPBKDF2SHA1, AlgorithmBcryptSHA256, AlgorithmScrypt, AlgorithmSHA1, AlgorithmMD5, AlgorithmUnsaltedMD5} {
+		t.Run(algorithm, func(t *testing.T) {
+			hash, err := NewHash(algorithm, "password")
+			if err != nil {
+				t.Fatalf("NewHash() = _, %v; want nil", err)
+			}
+			if actual, _ := Identify(hash); actual != algorithm {
+				t.Errorf("Identify() = %q, _; want %q", actual, algorithm)
+			}
+			if err := Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+		})
+	}
+	if _, err := NewHash("sha256", "password"); err != UnsupportedAlgorithmError("sha256") {
+		t.Errorf("NewHash() = _, %v; want %v", err, UnsupportedAlgorithmError("sha256"))
+	}
+}
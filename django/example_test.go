@@ -0,0 +1,17 @@
+package django_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt"
+	_ "github.com/sergeymakinen/go-crypt/django"
+)
+
+func Example() {
+	hash := "pbkdf2_sha256$10000$seasalt2$7Uini1AkM6AXI8Pnsicpdz4JqTPSxyrHJspWLGtbqxU="
+	fmt.Println(crypt.Check(hash, "password"))
+	fmt.Println(crypt.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
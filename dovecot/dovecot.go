@@ -0,0 +1,406 @@
+// Package dovecot implements the password schemes of Dovecot and Postfix (via Dovecot SASL):
+// "{SCHEME}" prefixed values with optional ".b64" and ".hex" encoding suffixes,
+// as found in passwd-file databases and the output of doveadm pw.
+//
+// The crypt(3) based schemes are handled by the des, md5, sha256, sha512, bcrypt and argon2 packages.
+package dovecot
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/argon2"
+	"github.com/sergeymakinen/go-crypt/bcrypt"
+	"github.com/sergeymakinen/go-crypt/des"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	md5crypt "github.com/sergeymakinen/go-crypt/md5"
+	sha256crypt "github.com/sergeymakinen/go-crypt/sha256"
+	sha512crypt "github.com/sergeymakinen/go-crypt/sha512"
+)
+
+// Password scheme names.
+const (
+	SchemeCrypt       = "CRYPT"
+	SchemeMD5Crypt    = "MD5-CRYPT"
+	SchemeMD5         = "MD5" // MD5-CRYPT or PLAIN-MD5, depending on the value
+	SchemeSHA256Crypt = "SHA256-CRYPT"
+	SchemeSHA512Crypt = "SHA512-CRYPT"
+	SchemeBlfCrypt    = "BLF-CRYPT"
+	SchemeArgon2I     = "ARGON2I"
+	SchemeArgon2ID    = "ARGON2ID"
+	SchemeSHA         = "SHA"
+	SchemeSHA1        = "SHA1"
+	SchemeSHA256      = "SHA256"
+	SchemeSHA512      = "SHA512"
+	SchemeSSHA        = "SSHA"
+	SchemeSSHA256     = "SSHA256"
+	SchemeSSHA512     = "SSHA512"
+	SchemeSMD5        = "SMD5"
+	SchemePlainMD5    = "PLAIN-MD5"
+	SchemeLDAPMD5     = "LDAP-MD5"
+	SchemeCRAMMD5     = "CRAM-MD5"
+	SchemePlain       = "PLAIN"
+	SchemeClearText   = "CLEARTEXT"
+)
+
+// DefaultScheme is the scheme of passwd-file values without a "{SCHEME}" prefix.
+const DefaultScheme = SchemeCrypt
+
+// UnsupportedSchemeError values describe errors resulting from an unsupported scheme.
+type UnsupportedSchemeError string
+
+func (e UnsupportedSchemeError) Error() string {
+	return "unsupported scheme " + strconv.Quote(string(e))
+}
+
+// SaltLength is the salt length of values generated with the salted digest schemes.
+const SaltLength = 16
+
+type valueEncoding int
+
+const (
+	encodingNone valueEncoding = iota
+	encodingBase64
+	encodingHex
+)
+
+type digestScheme struct {
+	hash     func() hash.Hash
+	salted   bool
+	encoding valueEncoding
+}
+
+var digestSchemes = map[string]digestScheme{
+	SchemeSHA:       {hash: sha1.New, encoding: encodingBase64},
+	SchemeSHA1:      {hash: sha1.New, encoding: encodingBase64},
+	SchemeSHA256:    {hash: sha256.New, encoding: encodingBase64},
+	SchemeSHA512:    {hash: sha512.New, encoding: encodingBase64},
+	SchemeSSHA:      {hash: sha1.New, salted: true, encoding: encodingBase64},
+	SchemeSSHA256:   {hash: sha256.New, salted: true, encoding: encodingBase64},
+	SchemeSSHA512:   {hash: sha512.New, salted: true, encoding: encodingBase64},
+	SchemeSMD5:      {hash: md5.New, salted: true, encoding: encodingBase64},
+	SchemePlainMD5:  {hash: md5.New, encoding: encodingHex},
+	SchemeLDAPMD5:   {hash: md5.New, encoding: encodingBase64},
+	SchemeCRAMMD5:   {encoding: encodingHex},
+	SchemePlain:     {encoding: encodingNone},
+	SchemeClearText: {encoding: encodingNone},
+}
+
+// Split splits the given value into the upper-cased scheme name with an optional encoding suffix
+// and the encoded password. Values without a "{SCHEME}" prefix have the DefaultScheme.
+func Split(hash string) (scheme, encoded string) {
+	if strings.HasPrefix(hash, "{") {
+		if i := strings.IndexByte(hash, '}'); i > 0 {
+			return strings.ToUpper(hash[1:i]), hash[i+1:]
+		}
+	}
+	return DefaultScheme, hash
+}
+
+var encodingSuffixes = [...]string{
+	encodingBase64: ".b64",
+	encodingHex:    ".hex",
+}
+
+func parseScheme(scheme string) (name string, enc valueEncoding, hasEncoding bool, err error) {
+	name = strings.ToUpper(scheme)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		switch name[i+1:] {
+		case "B64", "BASE64":
+			enc = encodingBase64
+		case "HEX":
+			enc = encodingHex
+		default:
+			return "", 0, false, UnsupportedSchemeError(scheme)
+		}
+		name, hasEncoding = name[:i], true
+	}
+	switch name {
+	case SchemeCrypt, SchemeMD5Crypt, SchemeMD5, SchemeSHA256Crypt, SchemeSHA512Crypt, SchemeBlfCrypt,
+		SchemeArgon2I, SchemeArgon2ID:
+		if hasEncoding {
+			return "", 0, false, UnsupportedSchemeError(scheme)
+		}
+		return name, encodingNone, false, nil
+	}
+	s, ok := digestSchemes[name]
+	if !ok {
+		return "", 0, false, UnsupportedSchemeError(scheme)
+	}
+	if !hasEncoding {
+		enc = s.encoding
+	}
+	return name, enc, hasEncoding, nil
+}
+
+func encode(b []byte, enc valueEncoding) string {
+	switch enc {
+	case encodingBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	case encodingHex:
+		return hex.EncodeToString(b)
+	default:
+		return string(b)
+	}
+}
+
+func decode(s string, enc valueEncoding, size int) ([]byte, error) {
+	switch enc {
+	case encodingNone:
+		return []byte(s), nil
+	case encodingBase64:
+		// Like Dovecot, accept hex-encoded fixed-size values regardless of the default encoding
+		if size > 0 && len(s) == hex.EncodedLen(size) {
+			if b, err := hex.DecodeString(s); err == nil {
+				return b, nil
+			}
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, &parse.SyntaxError{Offset: int(err.(base64.CorruptInputError)), Msg: "invalid base64 data"}
+		}
+		return b, nil
+	default:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			offset := strings.IndexFunc(s, func(r rune) bool {
+				return !strings.ContainsRune("0123456789abcdefABCDEF", r)
+			})
+			if offset < 0 {
+				offset = len(s)
+			}
+			return nil, &parse.SyntaxError{Offset: offset, Msg: "invalid hex data"}
+		}
+		return b, nil
+	}
+}
+
+func md5State(h hash.Hash) []byte {
+	// The binary representation of the MD5 state is the "md5\x01" magic
+	// followed by the big-endian state words
+	b, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	return b[4:20]
+}
+
+// CRAMMD5Key returns the HMAC-MD5 context of the password used by CRAM-MD5:
+// the outer and inner MD5 states after processing the padded key.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func CRAMMD5Key(password []byte) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	return cramMD5Key(password), nil
+}
+
+func cramMD5Key(password []byte) []byte {
+	if len(password) > md5.BlockSize {
+		sum := md5.Sum(password)
+		password = sum[:]
+	}
+	var ipad, opad [md5.BlockSize]byte
+	copy(ipad[:], password)
+	copy(opad[:], password)
+	for i := range ipad {
+		ipad[i] ^= 0x36
+		opad[i] ^= 0x5c
+	}
+	b := make([]byte, 0, 2*md5.Size)
+	for _, pad := range [][]byte{opad[:], ipad[:]} {
+		h := md5.New()
+		h.Write(pad)
+		state := md5State(h)
+		// Dovecot stores the state words in little-endian order
+		for i := 0; i < len(state); i += 4 {
+			b = binary.LittleEndian.AppendUint32(b, binary.BigEndian.Uint32(state[i:]))
+		}
+	}
+	return b
+}
+
+func digest(s digestScheme, name string, password, salt []byte) []byte {
+	switch name {
+	case SchemeCRAMMD5:
+		return cramMD5Key(password)
+	case SchemePlain, SchemeClearText:
+		return password
+	}
+	h := s.hash()
+	h.Write(password)
+	h.Write(salt)
+	return append(h.Sum(nil), salt...)
+}
+
+// NewHash returns the "{SCHEME}" prefixed value of the password with the given scheme,
+// optionally with an encoding suffix, like "SHA512-CRYPT" or "SSHA256.HEX".
+// The crypt(3) based schemes use the default parameters of their packages.
+// The digest and plaintext schemes return crypt.ErrNotApproved in FIPS 140 mode.
+func NewHash(scheme, password string) (string, error) {
+	return crypt.ObserveNewHash("dovecot", func() (string, error) {
+		return newHash(scheme, password)
+	}, hookParams)
+}
+
+func newHash(scheme, password string) (string, error) {
+	name, enc, hasEncoding, err := parseScheme(scheme)
+	if err != nil {
+		return "", err
+	}
+	prefix := "{" + name
+	if hasEncoding {
+		prefix += encodingSuffixes[enc]
+	}
+	prefix += "}"
+	var s string
+	switch name {
+	case SchemeCrypt, SchemeSHA512Crypt:
+		s, err = sha512crypt.NewHash(password, sha512crypt.DefaultRounds)
+	case SchemeMD5Crypt, SchemeMD5:
+		s, err = md5crypt.Generate(password)
+	case SchemeSHA256Crypt:
+		s, err = sha256crypt.NewHash(password, sha256crypt.DefaultRounds)
+	case SchemeBlfCrypt:
+		s, err = bcrypt.NewHash(password, bcrypt.DefaultCost)
+	case SchemeArgon2I:
+		s, err = newArgon2I(password)
+	case SchemeArgon2ID:
+		s, err = argon2.NewHash(password, argon2.DefaultMemory, argon2.DefaultTime)
+	default:
+		if cryptoutil.FIPS140Enabled() {
+			return "", crypt.ErrNotApproved
+		}
+		ds := digestSchemes[name]
+		var salt []byte
+		if ds.salted {
+			salt = cryptoutil.Rand(SaltLength)
+		}
+		s = encode(digest(ds, name, []byte(password), salt), enc)
+	}
+	if err != nil {
+		return "", err
+	}
+	return prefix + s, nil
+}
+
+func newArgon2I(password string) (string, error) {
+	salt := []byte(base64.RawStdEncoding.EncodeToString(cryptoutil.Rand(argon2.DefaultSaltLength)))
+	key, err := argon2.Key([]byte(password), salt, argon2.DefaultMemory, argon2.DefaultTime, argon2.DefaultThreads, &argon2.CompatibilityOptions{
+		Prefix:  argon2.Prefix2i,
+		Version: argon2.Version13,
+	})
+	if err != nil {
+		return "", err
+	}
+	return argon2.Prefix2i + "v=" + strconv.Itoa(argon2.Version13) +
+		"$m=" + strconv.Itoa(argon2.DefaultMemory) +
+		",t=" + strconv.Itoa(argon2.DefaultTime) +
+		",p=" + strconv.Itoa(argon2.DefaultThreads) +
+		"$" + string(salt) + "$" + base64.RawStdEncoding.EncodeToString(key), nil
+}
+
+// hookParams returns the scheme of the value for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	scheme, _ := Split(hash)
+	name, _, _, err := parseScheme(scheme)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.String("scheme", name)}
+}
+
+// checkCrypt checks the hash of the CRYPT scheme with the algorithms of the crypt(3) of glibc
+// supported by Dovecot, not with any registered one.
+func checkCrypt(hash, password string) error {
+	switch {
+	case strings.HasPrefix(hash, md5crypt.Prefix):
+		return md5crypt.Check(hash, password)
+	case strings.HasPrefix(hash, sha256crypt.Prefix):
+		return sha256crypt.Check(hash, password)
+	case strings.HasPrefix(hash, sha512crypt.Prefix):
+		return sha512crypt.Check(hash, password)
+	case strings.HasPrefix(hash, bcrypt.Prefix2a), strings.HasPrefix(hash, bcrypt.Prefix2b), strings.HasPrefix(hash, bcrypt.Prefix2y):
+		return bcrypt.Check(hash, password)
+	default:
+		return des.Check(hash, password)
+	}
+}
+
+// Check compares the given value with a new value derived from the password.
+// Returns nil on success, or an error on failure.
+// The digest and plaintext schemes return crypt.ErrNotApproved in FIPS 140 mode.
+func Check(hash, password string) error {
+	return crypt.ObserveCheck("dovecot", hash, password, check, hookParams)
+}
+
+func check(hash, password string) error {
+	scheme, encoded := Split(hash)
+	name, enc, hasEncoding, err := parseScheme(scheme)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case SchemeCrypt:
+		return checkCrypt(encoded, password)
+	case SchemeMD5Crypt:
+		return md5crypt.Check(encoded, password)
+	case SchemeMD5:
+		if strings.HasPrefix(encoded, md5crypt.Prefix) {
+			return md5crypt.Check(encoded, password)
+		}
+		name, enc = SchemePlainMD5, digestSchemes[SchemePlainMD5].encoding
+	case SchemeSHA256Crypt:
+		return sha256crypt.Check(encoded, password)
+	case SchemeSHA512Crypt:
+		return sha512crypt.Check(encoded, password)
+	case SchemeBlfCrypt:
+		return bcrypt.Check(encoded, password)
+	case SchemeArgon2I, SchemeArgon2ID:
+		return argon2.Check(encoded, password)
+	}
+	if cryptoutil.FIPS140Enabled() {
+		return crypt.ErrNotApproved
+	}
+	ds := digestSchemes[name]
+	size := 0
+	switch {
+	case name == SchemeCRAMMD5:
+		size = 2 * md5.Size
+	case ds.hash != nil && !ds.salted:
+		size = ds.hash().Size()
+	}
+	if hasEncoding {
+		// The encoding is explicit, so don't guess
+		size = 0
+	}
+	b, err := decode(encoded, enc, size)
+	if err != nil {
+		return err
+	}
+	var salt []byte
+	if ds.salted {
+		n := ds.hash().Size()
+		if len(b) < n {
+			return &parse.SyntaxError{Offset: len(encoded), Msg: "length mismatch"}
+		}
+		salt = b[n:]
+	}
+	if name == SchemeCRAMMD5 && len(b) != 2*md5.Size {
+		return &parse.SyntaxError{Offset: len(encoded), Msg: "length mismatch"}
+	}
+	if subtle.ConstantTimeCompare(digest(ds, name, []byte(password), salt), b) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package dovecot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []string{
+		"$1$aaa$sZbbxWYvlgYNZhB78yYjM0",
+		"{CRYPT}$6$aaa$I4qE52homEnm0Oc9OlL/XVQbfwhe2/m3vmS0y/a/hkTq01TU4NpqoPGWHKmDCHBpUO/htAXPrpsYE6v2zZon/.",
+		"{CRYPT}KTtvmYZb12iGI",
+		"{CRYPT}$5$rounds=5000$rHBpw9x/p4a6mzEn$0s.0IXTQjx7UVYjK5HCy2S3yWto4GM9m/5wU.Z.PDN1",
+		"{CRYPT}$2b$04$zrv5pdM3D0LpEM8TeYD9H.aGiU9N2fYLo5HEgdQy5aBofgVBUbBKK",
+		"{MD5-CRYPT}$1$aaa$sZbbxWYvlgYNZhB78yYjM0",
+		"{MD5}$1$aaa$sZbbxWYvlgYNZhB78yYjM0",
+		"{MD5}5f4dcc3b5aa765d61d8327deb882cf99",
+		"{SHA512-CRYPT}$6$rounds=6000$aaa$aQGFJ.RGgUKrm8.ppuLyHU7aDfTgsmYaZNmk72xLl8JsKSBzhHai2gwD/m5d.R52wwn6eQ7Qoj6fxY3fpvnbw/",
+		"{BLF-CRYPT}$2y$05$6bNw2HLQYeqHYyBfLMsv/ONb5e3QlsO9LjVau35XuPfu5vB6ACL2G",
+		"{ARGON2ID}$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+		"{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+		"{SHA256}XohImNooBHFR0OVvjcYpJ3NgPQ1qq73WKhHvch0VQtg=",
+		"{SHA256}5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8",
+		"{SSHA}n58gMUf19JPaW4rxIbocveM3ll5zZWFzYWx0Mg==",
+		"{ssha512.hex}687a207643966b0981870f308f9c3db96f91ecdbbe86e92695ad372e3c776bd941b93c0c564dc0021937556e863bdd7af5ed9f70e2de133599380ba90909f71973656173616c7432",
+		"{SMD5}mLhxU13KNgBHI5VQGt1+QXNlYXNhbHQy",
+		"{PLAIN-MD5}5f4dcc3b5aa765d61d8327deb882cf99",
+		"{LDAP-MD5}X03MO1qnZdYdgyfeuILPmQ==",
+		"{CRAM-MD5}9186d855e11eba527a7a52ca82b313e180d62234f0acc9051b527243d41e2740",
+		"{PLAIN}password",
+		"{PLAIN.b64}cGFzc3dvcmQ=",
+		"{CLEARTEXT}password",
+	}
+	for _, hash := range tests {
+		t.Run(hash, func(t *testing.T) {
+			if err := Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(hash, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "{SHA1024}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+			err:  UnsupportedSchemeError("SHA1024"),
+		},
+		{
+			hash: "{SHA.B32}W6ph5Mm5Pz8GgiULbPgzG37mj9g=",
+			err:  UnsupportedSchemeError("SHA.B32"),
+		},
+		{
+			hash: "{SHA512-CRYPT.HEX}$6$aaa$I4qE52homEnm0Oc9OlL/XVQbfwhe2/m3vmS0y/a/hkTq01TU4NpqoPGWHKmDCHBpUO/htAXPrpsYE6v2zZon/.",
+			err:  UnsupportedSchemeError("SHA512-CRYPT.HEX"),
+		},
+		{
+			hash: "{SHA}W6ph5Mm5Pz8Gg@ULbPgzG37mj9g=",
+			err:  &parse.SyntaxError{Offset: 13, Msg: "invalid base64 data"},
+		},
+		{
+			hash: "{PLAIN-MD5}5f4dcc3b5aa765d6xd8327deb882cf99",
+			err:  &parse.SyntaxError{Offset: 16, Msg: "invalid hex data"},
+		},
+		{
+			hash: "{SSHA}n58gMUf19JPaW4rxIbocveM3",
+			err:  &parse.SyntaxError{Offset: 24, Msg: "length mismatch"},
+		},
+		{
+			hash: "{CRAM-MD5}9186d855e11eba527a7a52ca82b313e1",
+			err:  &parse.SyntaxError{Offset: 32, Msg: "length mismatch"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckCryptUnsupported(t *testing.T) {
+	// Argon2 is registered for use by crypt.Check, but not supported by the CRYPT scheme
+	hash := "{CRYPT}$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+	if err, expected := Check(hash, "password"), `unsupported prefix "$argon2id$"`; err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Check() = %v; want %s error", err, expected)
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	tests := []struct {
+		scheme, prefix string
+	}{
+		{SchemeMD5Crypt, "{MD5-CRYPT}$1$"},
+		{SchemeBlfCrypt, "{BLF-CRYPT}$2b$"},
+		{SchemeArgon2I, "{ARGON2I}$argon2i$v=19$m=4096,t=3,p=1$"},
+		{"ssha256.HEX", "{SSHA256.hex}"},
+		{SchemeSMD5, "{SMD5}"},
+		{SchemeCRAMMD5, "{CRAM-MD5}9186d855e11eba527a7a52ca82b313e180d62234f0acc9051b527243d41e2740"},
+		{"PLAIN.b64", "{PLAIN.b64}cGFzc3dvcmQ="},
+	}
+	for _, test := range tests {
+		t.Run(test.scheme, func(t *testing.T) {
+			hash, err := NewHash(test.scheme, "password")
+			if err != nil {
+				t.Fatalf("NewHash() = _, %v; want nil", err)
+			}
+			if len(hash) < len(test.prefix) || hash[:len(test.prefix)] != test.prefix {
+				t.Errorf("NewHash() = %q, _; want prefix %q", hash, test.prefix)
+			}
+			if err := Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	tests := []struct {
+		hash     string
+		expected error
+	}{
+		{
+			hash: "{SHA512-CRYPT}$6$rounds=6000$aaa$aQGFJ.RGgUKrm8.ppuLyHU7aDfTgsmYaZNmk72xLl8JsKSBzhHai2gwD/m5d.R52wwn6eQ7Qoj6fxY3fpvnbw/",
+		},
+		{
+			hash:     "{MD5}5f4dcc3b5aa765d61d8327deb882cf99",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "{SHA256}XohImNooBHFR0OVvjcYpJ3NgPQ1qq73WKhHvch0VQtg=",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "{SSHA}n58gMUf19JPaW4rxIbocveM3ll5zZWFzYWx0Mg==",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "{CRAM-MD5}9186d855e11eba527a7a52ca82b313e180d62234f0acc9051b527243d41e2740",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "{PLAIN}password",
+			expected: crypt.ErrNotApproved,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.expected) {
+				t.Errorf("Check() = %v; want %v", err, test.expected)
+			}
+		})
+	}
+	for _, scheme := range []string{SchemeSHA256, SchemeSSHA512, SchemeCRAMMD5, SchemePlain} {
+		if _, err := NewHash(scheme, "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+			t.Errorf("NewHash(%q) = _, %v; want %v", scheme, err, crypt.ErrNotApproved)
+		}
+	}
+	if _, err := CRAMMD5Key([]byte("password")); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("CRAMMD5Key() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
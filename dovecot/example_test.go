@@ -0,0 +1,26 @@
+package dovecot_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt/dovecot"
+)
+
+func ExampleCheck() {
+	hash := "{SSHA}n58gMUf19JPaW4rxIbocveM3ll5zZWFzYWx0Mg=="
+	fmt.Println(dovecot.Check(hash, "password"))
+	fmt.Println(dovecot.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
+
+func ExampleReadPasswdFile() {
+	entries, _ := dovecot.ReadPasswdFile(strings.NewReader("user1:{PLAIN}password:1000:1000::/home/user1\n"))
+	for _, e := range entries {
+		fmt.Println(e.User, e.Home, dovecot.Check(e.Password, "password"))
+	}
+	// Output:
+	// user1 /home/user1 <nil>
+}
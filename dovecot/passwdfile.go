@@ -0,0 +1,133 @@
+package dovecot
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Entry is a passwd-file entry.
+type Entry struct {
+	User     string
+	Password string // "{SCHEME}" prefixed value
+	UID      string
+	GID      string
+	Gecos    string
+	Home     string
+	Shell    string
+
+	// Extra are the extra fields, like "userdb_quota_rule=*:storage=1G".
+	Extra []string
+}
+
+// InvalidEntryError values describe errors resulting from a passwd-file entry
+// that can't be represented in a passwd-file.
+type InvalidEntryError string
+
+func (e InvalidEntryError) Error() string {
+	return "invalid passwd-file entry: " + string(e)
+}
+
+// LineError records an error on a passwd-file line.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Err.Error()
+}
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+const numFields = 8
+
+// ParseEntry parses a passwd-file line in the
+// user:password:uid:gid:(gecos):home:(shell):extra_fields format.
+// Trailing fields may be omitted.
+func ParseEntry(line string) (*Entry, error) {
+	fields := strings.SplitN(line, ":", numFields)
+	if fields[0] == "" {
+		return nil, InvalidEntryError("missing user")
+	}
+	fields = append(fields, make([]string, numFields-len(fields))...)
+	e := &Entry{
+		User:     fields[0],
+		Password: fields[1],
+		UID:      fields[2],
+		GID:      fields[3],
+		Gecos:    fields[4],
+		Home:     fields[5],
+		Shell:    fields[6],
+	}
+	if fields[7] != "" {
+		e.Extra = strings.Fields(fields[7])
+	}
+	return e, nil
+}
+
+// String returns the passwd-file line of the entry without trailing empty fields.
+func (e *Entry) String() string {
+	fields := []string{e.User, e.Password, e.UID, e.GID, e.Gecos, e.Home, e.Shell, strings.Join(e.Extra, " ")}
+	for len(fields) > 1 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, ":")
+}
+
+func (e *Entry) validate() error {
+	if e.User == "" {
+		return InvalidEntryError("missing user")
+	}
+	for _, s := range []string{e.User, e.Password, e.UID, e.GID, e.Gecos, e.Home, e.Shell} {
+		if strings.ContainsAny(s, ":\r\n") {
+			return InvalidEntryError("field contains a colon or a newline")
+		}
+	}
+	for _, s := range e.Extra {
+		if s == "" || strings.ContainsAny(s, " \t\r\n") {
+			return InvalidEntryError("extra field is empty or contains a whitespace")
+		}
+	}
+	return nil
+}
+
+// ReadPasswdFile reads the passwd-file entries from r.
+// Empty lines and comment lines starting with "#" are skipped.
+func ReadPasswdFile(r io.Reader) ([]*Entry, error) {
+	var (
+		entries []*Entry
+		n       int
+	)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		n++
+		line := strings.TrimSuffix(s.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		e, err := ParseEntry(line)
+		if err != nil {
+			return nil, &LineError{Line: n, Err: err}
+		}
+		entries = append(entries, e)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WritePasswdFile writes the entries in the passwd-file format to w.
+func WritePasswdFile(w io.Writer, entries []*Entry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		if err := e.validate(); err != nil {
+			return err
+		}
+		bw.WriteString(e.String())
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
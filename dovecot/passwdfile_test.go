@@ -0,0 +1,100 @@
+package dovecot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+const testPasswdFile = `# Mail users
+user1:{PLAIN}password:1000:1000::/home/user1::userdb_mail=maildir:~/Maildir userdb_quota_rule=*:storage=1G
+
+user2:{SHA512-CRYPT}$6$aaa$I4qE52homEnm0Oc9OlL/XVQbfwhe2/m3vmS0y/a/hkTq01TU4NpqoPGWHKmDCHBpUO/htAXPrpsYE6v2zZon/.
+user3:{CRAM-MD5}9186d855e11eba527a7a52ca82b313e180d62234f0acc9051b527243d41e2740:::User 3
+`
+
+var testEntries = []*Entry{
+	{
+		User:     "user1",
+		Password: "{PLAIN}password",
+		UID:      "1000",
+		GID:      "1000",
+		Home:     "/home/user1",
+		Extra:    []string{"userdb_mail=maildir:~/Maildir", "userdb_quota_rule=*:storage=1G"},
+	},
+	{
+		User:     "user2",
+		Password: "{SHA512-CRYPT}$6$aaa$I4qE52homEnm0Oc9OlL/XVQbfwhe2/m3vmS0y/a/hkTq01TU4NpqoPGWHKmDCHBpUO/htAXPrpsYE6v2zZon/.",
+	},
+	{
+		User:     "user3",
+		Password: "{CRAM-MD5}9186d855e11eba527a7a52ca82b313e180d62234f0acc9051b527243d41e2740",
+		Gecos:    "User 3",
+	},
+}
+
+func TestReadPasswdFile(t *testing.T) {
+	entries, err := ReadPasswdFile(strings.NewReader(testPasswdFile))
+	if err != nil {
+		t.Fatalf("ReadPasswdFile() = _, %v; want nil", err)
+	}
+	if diff := cmp.Diff(testEntries, entries); diff != "" {
+		t.Errorf("ReadPasswdFile() mismatch (-want +got):\n%s", diff)
+	}
+	for _, e := range entries {
+		if err := Check(e.Password, "password"); err != nil {
+			t.Errorf("Check(%q) = %v; want nil", e.User, err)
+		}
+	}
+}
+
+func TestReadPasswdFileShouldFail(t *testing.T) {
+	expected := &LineError{Line: 2, Err: InvalidEntryError("missing user")}
+	if _, err := ReadPasswdFile(strings.NewReader("user1:{PLAIN}password\n:{PLAIN}password\n")); !testutil.IsEqualError(err, expected) {
+		t.Errorf("ReadPasswdFile() = _, %v; want %v", err, expected)
+	}
+}
+
+func TestWritePasswdFile(t *testing.T) {
+	var b bytes.Buffer
+	if err := WritePasswdFile(&b, testEntries); err != nil {
+		t.Fatalf("WritePasswdFile() = %v; want nil", err)
+	}
+	const expected = `user1:{PLAIN}password:1000:1000::/home/user1::userdb_mail=maildir:~/Maildir userdb_quota_rule=*:storage=1G
+user2:{SHA512-CRYPT}$6$aaa$I4qE52homEnm0Oc9OlL/XVQbfwhe2/m3vmS0y/a/hkTq01TU4NpqoPGWHKmDCHBpUO/htAXPrpsYE6v2zZon/.
+user3:{CRAM-MD5}9186d855e11eba527a7a52ca82b313e180d62234f0acc9051b527243d41e2740:::User 3
+`
+	if s := b.String(); s != expected {
+		t.Errorf("WritePasswdFile() = %q; want %q", s, expected)
+	}
+}
+
+func TestWritePasswdFileShouldFail(t *testing.T) {
+	tests := []struct {
+		entry *Entry
+		err   error
+	}{
+		{
+			entry: &Entry{Password: "{PLAIN}password"},
+			err:   InvalidEntryError("missing user"),
+		},
+		{
+			entry: &Entry{User: "user1", Password: "{PLAIN}pass:word"},
+			err:   InvalidEntryError("field contains a colon or a newline"),
+		},
+		{
+			entry: &Entry{User: "user1", Extra: []string{"userdb_mail=maildir:~/Mail Dir"}},
+			err:   InvalidEntryError("extra field is empty or contains a whitespace"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			if err := WritePasswdFile(&bytes.Buffer{}, []*Entry{test.entry}); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("WritePasswdFile() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package envelope implements the encryption at rest of hashes of any registered algorithm.
+//
+// The hash string is encrypted with AES-GCM using a key encryption key (KEK)
+// held outside the database, and the ID of the key and the nonce are recorded
+// in the hash string:
+//
+//	$envelope$kid=<key ID>$<nonce>$<ciphertext>
+//
+// so a leaked database alone is useless to an attacker.
+// Unlike peppering, the hashes can be re-encrypted with a new key without knowing the passwords.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/hashutil"
+	"github.com/sergeymakinen/go-crypt/internal/keyid"
+)
+
+const Prefix = "$envelope$"
+
+// NonceLength is the length of the AES-GCM nonce.
+const NonceLength = 12
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+// UnknownKeyError values describe errors resulting from a key ID not found in a key provider.
+type UnknownKeyError = keyid.UnknownKeyError
+
+// InvalidKeyIDError values describe errors resulting from a key ID
+// that can't be represented in a hash string.
+type InvalidKeyIDError = keyid.InvalidKeyIDError
+
+// ErrDecrypt is returned when the hash can't be decrypted:
+// either the key is wrong or the hash string is tampered with.
+var ErrDecrypt = errors.New("message authentication failed")
+
+// KeyProvider provides the key encryption keys.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key used to encrypt new hashes.
+	CurrentKeyID() string
+
+	// Key returns the AES-128, AES-192 or AES-256 key with the given ID,
+	// or UnknownKeyError if there is no such key.
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeyProvider is a KeyProvider with a fixed set of keys.
+type StaticKeyProvider = keyid.Static
+
+type scheme struct {
+	KeyID      string
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+// header returns the authenticated part of the hash string.
+func (s *scheme) header() string {
+	return keyid.String(Prefix, s.KeyID)
+}
+
+func (s *scheme) String() string {
+	return s.header() + base64.RawStdEncoding.EncodeToString(s.Nonce) + "$" + base64.RawStdEncoding.EncodeToString(s.Ciphertext)
+}
+
+// $envelope$kid=<key ID>$<nonce>$<ciphertext>
+func parseHash(hash string) (*scheme, error) {
+	if !strings.HasPrefix(hash, Prefix) {
+		return nil, UnsupportedPrefixError(hashutil.Prefix(hash, len(Prefix)))
+	}
+	keyID, s, err := keyid.Parse(hash, Prefix, "nonce")
+	if err != nil {
+		return nil, err
+	}
+	i := strings.IndexByte(s, '$')
+	if i < 0 {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "missing ciphertext"}
+	}
+	nonce, err := base64.RawStdEncoding.Strict().DecodeString(s[:i])
+	if err != nil || len(nonce) != NonceLength {
+		return nil, &parse.SyntaxError{Offset: len(hash) - len(s) + i, Msg: "invalid nonce"}
+	}
+	ciphertext, err := base64.RawStdEncoding.Strict().DecodeString(s[i+1:])
+	if err != nil || len(ciphertext) <= 16 {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "invalid ciphertext"}
+	}
+	return &scheme{
+		KeyID:      keyID,
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+func newAEAD(provider KeyProvider, id string) (cipher.AEAD, error) {
+	key, err := provider.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(inner string, provider KeyProvider, nonce []byte) (string, error) {
+	scheme := scheme{
+		KeyID: provider.CurrentKeyID(),
+		Nonce: nonce,
+	}
+	if err := keyid.Validate(scheme.KeyID); err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(provider, scheme.KeyID)
+	if err != nil {
+		return "", err
+	}
+	scheme.Ciphertext = aead.Seal(nil, scheme.Nonce, []byte(inner), []byte(scheme.header()))
+	return scheme.String(), nil
+}
+
+// Wrap returns the given hash encrypted with the current key of the key provider.
+func Wrap(inner string, provider KeyProvider) (string, error) {
+	return seal(inner, provider, cryptoutil.Rand(NonceLength))
+}
+
+// Unwrap returns the hash decrypted from the given encrypted hash
+// with the key from the key provider.
+func Unwrap(hash string, provider KeyProvider) (string, error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(provider, scheme.KeyID)
+	if err != nil {
+		return "", err
+	}
+	b, err := aead.Open(nil, scheme.Nonce, scheme.Ciphertext, []byte(scheme.header()))
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(b), nil
+}
+
+// Rewrap returns the given encrypted hash re-encrypted with the current key of the key provider.
+// It is used to rotate keys without knowing the passwords.
+func Rewrap(hash string, provider KeyProvider) (string, error) {
+	inner, err := Unwrap(hash, provider)
+	if err != nil {
+		return "", err
+	}
+	return Wrap(inner, provider)
+}
+
+// NeedsRewrap reports whether the given encrypted hash uses a key
+// other than the current key of the key provider.
+func NeedsRewrap(hash string, provider KeyProvider) (bool, error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return false, err
+	}
+	return scheme.KeyID != provider.CurrentKeyID(), nil
+}
+
+// hookParams returns the key ID of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.String("kid", scheme.KeyID)}
+}
+
+// NewHash returns the hash of the password returned by newHash,
+// like the NewHash function of a hash package with the parameters bound,
+// encrypted with the current key of the key provider.
+func NewHash(password string, provider KeyProvider, newHash func(password string) (string, error)) (string, error) {
+	return crypt.ObserveNewHash("envelope", func() (string, error) {
+		return newHashWith(password, provider, newHash)
+	}, hookParams)
+}
+
+func newHashWith(password string, provider KeyProvider, newHash func(password string) (string, error)) (string, error) {
+	inner, err := newHash(password)
+	if err != nil {
+		return "", err
+	}
+	return Wrap(inner, provider)
+}
+
+// Check decrypts the given encrypted hash with the key from the key provider
+// and compares it with a new hash derived from the password.
+// The decrypted hash is checked with the registry, so its algorithm must be registered there
+// and allowed by its allow and deny lists.
+//
+// The r parameter is optional. If nil, crypt.DefaultRegistry is used.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string, provider KeyProvider, r *crypt.Registry) error {
+	if r == nil {
+		r = crypt.DefaultRegistry
+	}
+	return crypt.ObserveCheck("envelope", hash, password, func(hash, password string) error {
+		return check(hash, password, provider, r)
+	}, hookParams)
+}
+
+func check(hash, password string, provider KeyProvider, r *crypt.Registry) error {
+	inner, err := Unwrap(hash, provider)
+	if err != nil {
+		return err
+	}
+	return r.Check(inner, password)
+}
+
+// Register registers encrypted hashes in the registry with the given key provider.
+// The decrypted hashes are checked with the same registry, which also refuses them
+// in FIPS 140 mode if their algorithms are not approved.
+//
+// The r parameter is optional. If nil, crypt.DefaultRegistry is used.
+func Register(provider KeyProvider, r *crypt.Registry) {
+	if r == nil {
+		r = crypt.DefaultRegistry
+	}
+	r.Register(Prefix, &crypt.Hash{
+		Algorithm: "envelope",
+		Check: func(hash, password string) error {
+			return check(hash, password, provider, r)
+		},
+		Params:   hookParams,
+		Approved: true,
+	})
+}
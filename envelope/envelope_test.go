@@ -0,0 +1,271 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+	"github.com/sergeymakinen/go-crypt/md5"
+	"github.com/sergeymakinen/go-crypt/sha512"
+)
+
+func newTestKeyProvider() *StaticKeyProvider {
+	return &StaticKeyProvider{
+		Current: "2",
+		Keys: map[string][]byte{
+			"1": []byte("0123456789abcdef0123456789abcdef"),
+			"2": []byte("fedcba9876543210"),
+		},
+	}
+}
+
+const testInner = "$1$saltsalt$qjXMvbEw8oaL.CzflDtaK/"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		hash        string
+		needsRewrap bool
+	}{
+		{
+			hash:        "$envelope$kid=1$bm9uY2Vfbm9uY2Vf$5PkonLp9fEvvmtVeVW6c3SlMXGOYOhG+1LcmLhA15l4bneYSvecQZEciECJfrz5OBts",
+			needsRewrap: true,
+		},
+		{
+			hash: "$envelope$kid=2$bm9uY2Vfbm9uY2Vf$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9QrQ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			provider := newTestKeyProvider()
+			if err := Check(test.hash, "password", provider, nil); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(test.hash, "test", provider, nil); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+			inner, err := Unwrap(test.hash, provider)
+			if err != nil {
+				t.Fatalf("Unwrap() = _, %v; want nil", err)
+			}
+			if inner != testInner {
+				t.Errorf("Unwrap() = %q, _; want %q", inner, testInner)
+			}
+			needsRewrap, err := NeedsRewrap(test.hash, provider)
+			if err != nil {
+				t.Fatalf("NeedsRewrap() = _, %v; want nil", err)
+			}
+			if needsRewrap != test.needsRewrap {
+				t.Errorf("NeedsRewrap() = %v, _; want %v", needsRewrap, test.needsRewrap)
+			}
+		})
+	}
+}
+
+func TestParseShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  UnsupportedPrefixError(""),
+		},
+		{
+			hash: testInner,
+			err:  UnsupportedPrefixError("$1$"),
+		},
+		{
+			hash: "$envelope$1$bm9uY2Vfbm9uY2Vf$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9QrQ",
+			err:  &parse.SyntaxError{Offset: 10, Msg: "missing key ID"},
+		},
+		{
+			hash: "$envelope$kid=2",
+			err:  &parse.SyntaxError{Offset: 15, Msg: "missing nonce"},
+		},
+		{
+			hash: "$envelope$kid=$bm9uY2Vfbm9uY2Vf$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9QrQ",
+			err:  &parse.SyntaxError{Offset: 14, Msg: "invalid key ID"},
+		},
+		{
+			hash: "$envelope$kid=2$bm9uY2Vfbm9uY2Vf",
+			err:  &parse.SyntaxError{Offset: 32, Msg: "missing ciphertext"},
+		},
+		{
+			hash: "$envelope$kid=2$bm9uY2Vfbm9uY2V$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9QrQ",
+			err:  &parse.SyntaxError{Offset: 31, Msg: "invalid nonce"},
+		},
+		{
+			hash: "$envelope$kid=2$bm9uY2Vfbm9uY2Vf$MHmkBE9xlg0xgrjaemlUFw",
+			err:  &parse.SyntaxError{Offset: 55, Msg: "invalid ciphertext"},
+		},
+		{
+			hash: "$envelope$kid=2$bm9uY2Vfbm9uY2Vf$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9Q@Q",
+			err:  &parse.SyntaxError{Offset: 100, Msg: "invalid ciphertext"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			provider := newTestKeyProvider()
+			if err := Check(test.hash, "password", provider, nil); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+			if _, err := Unwrap(test.hash, provider); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Unwrap() = _, %v; want %v", err, test.err)
+			}
+			if _, err := NeedsRewrap(test.hash, provider); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("NeedsRewrap() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestUnwrapShouldFail(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+		err  error
+	}{
+		{
+			name: "unknown key",
+			hash: "$envelope$kid=3$bm9uY2Vfbm9uY2Vf$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9QrQ",
+			err:  UnknownKeyError("3"),
+		},
+		{
+			name: "swapped key ID",
+			hash: "$envelope$kid=1$bm9uY2Vfbm9uY2Vf$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9QrQ",
+			err:  ErrDecrypt,
+		},
+		{
+			name: "tampered nonce",
+			hash: "$envelope$kid=2$bm9uY2Vfbm9uY2VG$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9QrQ",
+			err:  ErrDecrypt,
+		},
+		{
+			name: "tampered ciphertext",
+			hash: "$envelope$kid=2$bm9uY2Vfbm9uY2Vf$MHmkBE9xlg0xgrjaemlUFwv/AKcA8TSipsCx+Eh+dRrc9KGBIuhu+rReXcpt+dj9QrA",
+			err:  ErrDecrypt,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Unwrap(test.hash, newTestKeyProvider()); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Unwrap() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	provider := newTestKeyProvider()
+	hash, err := Wrap(testInner, provider)
+	if err != nil {
+		t.Fatalf("Wrap() = _, %v; want nil", err)
+	}
+	if inner, err := Unwrap(hash, provider); err != nil || inner != testInner {
+		t.Errorf("Unwrap() = %q, %v; want %q, nil", inner, err, testInner)
+	}
+	if hash2, _ := Wrap(testInner, provider); hash2 == hash {
+		t.Errorf("Wrap() = %q, _; want a different nonce", hash2)
+	}
+}
+
+func TestWrapShouldFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider KeyProvider
+		err      error
+	}{
+		{
+			name:     "invalid key ID",
+			provider: &StaticKeyProvider{Current: "1$"},
+			err:      InvalidKeyIDError("1$"),
+		},
+		{
+			name:     "unknown key",
+			provider: &StaticKeyProvider{Current: "1"},
+			err:      UnknownKeyError("1"),
+		},
+		{
+			name:     "invalid key size",
+			provider: &StaticKeyProvider{Current: "1", Keys: map[string][]byte{"1": []byte("key")}},
+			err:      aes.KeySizeError(3),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Wrap(testInner, test.provider); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Wrap() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	provider := newTestKeyProvider()
+	hash, err := Rewrap("$envelope$kid=1$bm9uY2Vfbm9uY2Vf$5PkonLp9fEvvmtVeVW6c3SlMXGOYOhG+1LcmLhA15l4bneYSvecQZEciECJfrz5OBts", provider)
+	if err != nil {
+		t.Fatalf("Rewrap() = _, %v; want nil", err)
+	}
+	if needsRewrap, err := NeedsRewrap(hash, provider); err != nil || needsRewrap {
+		t.Errorf("NeedsRewrap() = %v, %v; want false, nil", needsRewrap, err)
+	}
+	delete(provider.Keys, "1")
+	if err = Check(hash, "password", provider, nil); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	provider := newTestKeyProvider()
+	hash, err := NewHash("password", provider, func(password string) (string, error) {
+		return sha512.NewHash(password, sha512.ImplicitRounds)
+	})
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err = Check(hash, "password", provider, nil); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	inner, err := Unwrap(hash, provider)
+	if err != nil {
+		t.Fatalf("Unwrap() = _, %v; want nil", err)
+	}
+	if _, rounds, err := sha512.Params(inner); err != nil || rounds != sha512.ImplicitRounds {
+		t.Errorf("Params() = _, %d, %v; want %d, nil", rounds, err, sha512.ImplicitRounds)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(newTestKeyProvider(), nil)
+	hash := "$envelope$kid=1$bm9uY2Vfbm9uY2Vf$5PkonLp9fEvvmtVeVW6c3SlMXGOYOhG+1LcmLhA15l4bneYSvecQZEciECJfrz5OBts"
+	if err := crypt.Check(hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err := crypt.Check(hash, "test"); err != crypt.ErrPasswordMismatch {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
+
+func TestRegisterDeny(t *testing.T) {
+	r := crypt.NewRegistry(&crypt.RegistryOptions{
+		Parent: crypt.DefaultRegistry,
+		Deny:   []string{md5.Prefix},
+	})
+	provider := newTestKeyProvider()
+	Register(provider, r)
+	hash, err := Wrap(testInner, provider)
+	if err != nil {
+		t.Fatalf("Wrap() = _, %v; want nil", err)
+	}
+	if err := r.Check(hash, "password"); err != crypt.ErrHashNotAllowed {
+		t.Errorf("Registry.Check() = %v; want %v", err, crypt.ErrHashNotAllowed)
+	}
+	if err := Check(hash, "password", provider, r); err != crypt.ErrHashNotAllowed {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrHashNotAllowed)
+	}
+	if err := Check(hash, "password", provider, nil); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+}
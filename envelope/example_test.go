@@ -0,0 +1,43 @@
+package envelope_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/envelope"
+	_ "github.com/sergeymakinen/go-crypt/md5"
+)
+
+func ExampleRegister() {
+	provider := &envelope.StaticKeyProvider{
+		Current: "2",
+		Keys: map[string][]byte{
+			"1": []byte("0123456789abcdef0123456789abcdef"),
+			"2": []byte("fedcba9876543210"),
+		},
+	}
+	envelope.Register(provider, nil)
+	hash := "$envelope$kid=1$bm9uY2Vfbm9uY2Vf$5PkonLp9fEvvmtVeVW6c3SlMXGOYOhG+1LcmLhA15l4bneYSvecQZEciECJfrz5OBts"
+	fmt.Println(crypt.Check(hash, "password"))
+	fmt.Println(crypt.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
+
+func ExampleRewrap() {
+	provider := &envelope.StaticKeyProvider{
+		Current: "2",
+		Keys: map[string][]byte{
+			"1": []byte("0123456789abcdef0123456789abcdef"),
+			"2": []byte("fedcba9876543210"),
+		},
+	}
+	hash := "$envelope$kid=1$bm9uY2Vfbm9uY2Vf$5PkonLp9fEvvmtVeVW6c3SlMXGOYOhG+1LcmLhA15l4bneYSvecQZEciECJfrz5OBts"
+	hash, _ = envelope.Rewrap(hash, provider)
+	fmt.Println(hash[:16])
+	fmt.Println(envelope.Unwrap(hash, provider))
+	// Output:
+	// $envelope$kid=2$
+	// $1$saltsalt$qjXMvbEw8oaL.CzflDtaK/ <nil>
+}
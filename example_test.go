@@ -6,6 +6,8 @@ import (
 	"github.com/sergeymakinen/go-crypt"
 	_ "github.com/sergeymakinen/go-crypt/argon2"
 	_ "github.com/sergeymakinen/go-crypt/bcrypt"
+	_ "github.com/sergeymakinen/go-crypt/md5"
+	_ "github.com/sergeymakinen/go-crypt/sha512"
 )
 
 var hashes = []string{
@@ -33,3 +35,76 @@ func ExampleCheck() {
 	// "$unknown$foo" with "password": unknown hash
 	// "$unknown$foo" with "test": unknown hash
 }
+
+func ExampleCrypt() {
+	hash, err := crypt.Crypt("password", "$6$k2XAnEHBqQ1Ct2aM")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(hash)
+	// Output:
+	// $6$k2XAnEHBqQ1Ct2aM$ex5zZyYdY9DpUEGRk79A7sFHEG212AmKzMmu6esxm6V3MWglE9oe9uloWISKk5tSR.3dRXLqwFRwXn0HNmGHe.
+}
+
+func ExampleGenSalt() {
+	setting, err := crypt.GenSalt("$2b$", 10, []byte("0123456789abcdef"))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(setting)
+	// Output:
+	// $2b$10$KBCwKxOzLha2MUDgW0PjXe
+}
+
+func ExampleCheckSalt() {
+	for _, setting := range []string{
+		"$2b$10$KBCwKxOzLha2MUDgW0PjXe",
+		"$1$k2XAnEHB",
+		"$6$k2XA@",
+		"$unknown$foo",
+	} {
+		fmt.Printf("%q: %v\n", setting, crypt.CheckSalt(setting))
+	}
+	// Output:
+	// "$2b$10$KBCwKxOzLha2MUDgW0PjXe": OK
+	// "$1$k2XAnEHB": METHOD_LEGACY
+	// "$6$k2XA@": INVALID
+	// "$unknown$foo": INVALID
+}
+
+func ExampleNewRegistry() {
+	r := crypt.NewRegistry(&crypt.RegistryOptions{
+		Parent: crypt.DefaultRegistry,
+		Allow:  []string{"$2b$", "$argon2id$"},
+	})
+	fmt.Println(r.Check("$2b$12$mBhJFLLDJCBCcmMN4DLyrOV.LLSl/mdwGfzwsqvIL0OQN5yXzRihO", "password"))
+	fmt.Println(r.Check("$1$saltsalt$qjXMvbEw8oaL.CzflDtaK/", "password"))
+	fmt.Println(crypt.Check("$1$saltsalt$qjXMvbEw8oaL.CzflDtaK/", "password"))
+	// Output:
+	// <nil>
+	// hash not allowed
+	// <nil>
+}
+
+func ExampleSetHook() {
+	crypt.SetHook(crypt.HookFunc(func(e crypt.Event) {
+		fmt.Println(e.Op, e.Algorithm, e.Params, e.Outcome)
+	}))
+	defer crypt.SetHook(nil)
+	crypt.Check("$2b$12$mBhJFLLDJCBCcmMN4DLyrOV.LLSl/mdwGfzwsqvIL0OQN5yXzRihO", "test")
+	// Output:
+	// check bcrypt [prefix=$2b$ cost=12] mismatch
+}
+
+func ExampleCheckOrDummy() {
+	policy := crypt.Policy{Prefix: "$2b$", Count: 12}
+	users := map[string]string{
+		"alice": "$2b$12$mBhJFLLDJCBCcmMN4DLyrOV.LLSl/mdwGfzwsqvIL0OQN5yXzRihO",
+	}
+	for _, user := range []string{"alice", "bob"} {
+		fmt.Printf("%s: %v\n", user, crypt.CheckOrDummy(users[user], "password", policy))
+	}
+	// Output:
+	// alice: <nil>
+	// bob: unknown hash
+}
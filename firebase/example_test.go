@@ -0,0 +1,18 @@
+package firebase_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/firebase"
+)
+
+func ExampleCheck() {
+	params, _ := firebase.NewParams("jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==", "Bw==", 8, 14)
+	hash := "lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ=="
+	salt := "42xEC+ixf3L2lw=="
+	fmt.Println(firebase.Check(hash, salt, "user1password", params))
+	fmt.Println(firebase.Check(hash, salt, "test", params))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
@@ -0,0 +1,181 @@
+// Package firebase implements the Firebase Authentication modified scrypt password hashing algorithm.
+//
+// Firebase derives a key from the password and salt with scrypt
+// and stores the project signer key encrypted with that key using AES-256-CTR.
+// The hash, salt and the project-level parameters are found in a Firebase Auth users export
+// and in the password hash parameters of the Firebase project.
+package firebase
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"strconv"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/argon2"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	MinRounds = 1
+	MaxRounds = 8
+)
+
+// InvalidRoundsError values describe errors resulting from an invalid round count.
+type InvalidRoundsError int
+
+func (e InvalidRoundsError) Error() string {
+	return "invalid round count " + strconv.FormatInt(int64(e), 10)
+}
+
+const (
+	MinMemCost = 1
+	MaxMemCost = 14
+)
+
+// InvalidMemCostError values describe errors resulting from an invalid memory cost.
+type InvalidMemCostError int
+
+func (e InvalidMemCostError) Error() string {
+	return "invalid memory cost " + strconv.FormatInt(int64(e), 10)
+}
+
+// Params are the project-level password hash parameters.
+type Params struct {
+	SignerKey     []byte
+	SaltSeparator []byte
+	Rounds        int
+	MemCost       int
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, &parse.SyntaxError{Offset: int(err.(base64.CorruptInputError)), Msg: "invalid base64 data"}
+	}
+	return b, nil
+}
+
+// NewParams returns the project-level password hash parameters
+// from the base64-encoded signer key and salt separator, rounds and memory cost
+// as shown in the Firebase console.
+func NewParams(signerKey, saltSeparator string, rounds, memCost int) (*Params, error) {
+	key, err := decodeBase64(signerKey)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := decodeBase64(saltSeparator)
+	if err != nil {
+		return nil, err
+	}
+	if rounds < MinRounds || rounds > MaxRounds {
+		return nil, InvalidRoundsError(rounds)
+	}
+	if memCost < MinMemCost || memCost > MaxMemCost {
+		return nil, InvalidMemCostError(memCost)
+	}
+	return &Params{
+		SignerKey:     key,
+		SaltSeparator: sep,
+		Rounds:        rounds,
+		MemCost:       memCost,
+	}, nil
+}
+
+const derivedKeyLength = 64
+
+// Key returns a Firebase key derived from the password, raw salt and project-level parameters.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func Key(password, salt []byte, params *Params) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	if params.Rounds < MinRounds || params.Rounds > MaxRounds {
+		return nil, InvalidRoundsError(params.Rounds)
+	}
+	if params.MemCost < MinMemCost || params.MemCost > MaxMemCost {
+		return nil, InvalidMemCostError(params.MemCost)
+	}
+	saltp := make([]byte, 0, len(salt)+len(params.SaltSeparator))
+	saltp = append(append(saltp, salt...), params.SaltSeparator...)
+	dk, err := scrypt.Key(password, saltp, 1<<params.MemCost, params.Rounds, 1, derivedKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(dk[:32])
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, len(params.SignerKey))
+	cipher.NewCTR(c, make([]byte, aes.BlockSize)).XORKeyStream(key, params.SignerKey)
+	return key, nil
+}
+
+// hookParams returns the rounds and memory cost of p for crypt.Hook.
+func (p *Params) hookParams(string) []slog.Attr {
+	return []slog.Attr{
+		slog.Int("rounds", p.Rounds),
+		slog.Int("mem_cost", p.MemCost),
+	}
+}
+
+// NewHash returns the base64-encoded Firebase hash of the password and base64-encoded salt.
+func NewHash(password, salt string, params *Params) (string, error) {
+	return crypt.ObserveNewHash("firebase", func() (string, error) {
+		return newHash(password, salt, params)
+	}, params.hookParams)
+}
+
+func newHash(password, salt string, params *Params) (string, error) {
+	decSalt, err := decodeBase64(salt)
+	if err != nil {
+		return "", err
+	}
+	key, err := Key([]byte(password), decSalt, params)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Check compares the given base64-encoded Firebase hash with a new hash derived from the password,
+// base64-encoded salt and project-level parameters.
+// Returns nil on success, or an error on failure.
+func Check(hash, salt, password string, params *Params) error {
+	return crypt.ObserveCheck("firebase", hash, password, func(hash, password string) error {
+		return check(hash, salt, password, params)
+	}, params.hookParams)
+}
+
+func check(hash, salt, password string, params *Params) error {
+	decHash, err := decodeBase64(hash)
+	if err != nil {
+		return err
+	}
+	decSalt, err := decodeBase64(salt)
+	if err != nil {
+		return err
+	}
+	key, err := Key([]byte(password), decSalt, params)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, decHash) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+// Rehash compares the given Firebase hash with a new hash derived from the password
+// and, on success, returns the crypt(3) Argon2 hash of the password with the given memory and time costs.
+func Rehash(hash, salt, password string, params *Params, memory, time uint32) (string, error) {
+	if err := Check(hash, salt, password, params); err != nil {
+		return "", err
+	}
+	return argon2.NewHash(password, memory, time)
+}
@@ -0,0 +1,117 @@
+package firebase
+
+import (
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+const (
+	testSignerKey     = "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA=="
+	testSaltSeparator = "Bw=="
+	testSalt          = "42xEC+ixf3L2lw=="
+	testHash          = "lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ=="
+)
+
+func testParams(t *testing.T) *Params {
+	params, err := NewParams(testSignerKey, testSaltSeparator, 8, 14)
+	if err != nil {
+		t.Fatalf("NewParams() = _, %v; want nil", err)
+	}
+	return params
+}
+
+func TestCheck(t *testing.T) {
+	params := testParams(t)
+	if err := Check(testHash, testSalt, "user1password", params); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err := Check(testHash, testSalt, "password", params); err != crypt.ErrPasswordMismatch {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	tests := []struct {
+		hash, salt string
+		err        error
+	}{
+		{
+			hash: "lSrfV15cpx95@",
+			salt: testSalt,
+			err:  &parse.SyntaxError{Offset: 12, Msg: "invalid base64 data"},
+		},
+		{
+			hash: testHash,
+			salt: "42xEC+ixf3L2lw",
+			err:  &parse.SyntaxError{Offset: 12, Msg: "invalid base64 data"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash+"/"+test.salt, func(t *testing.T) {
+			if err := Check(test.hash, test.salt, "user1password", testParams(t)); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewParamsShouldFail(t *testing.T) {
+	tests := []struct {
+		rounds, memCost int
+		err             error
+	}{
+		{0, 14, InvalidRoundsError(0)},
+		{MaxRounds + 1, 14, InvalidRoundsError(MaxRounds + 1)},
+		{8, 0, InvalidMemCostError(0)},
+		{8, MaxMemCost + 1, InvalidMemCostError(MaxMemCost + 1)},
+	}
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			if _, err := NewParams(testSignerKey, testSaltSeparator, test.rounds, test.memCost); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("NewParams() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	hash, err := NewHash("user1password", testSalt, testParams(t))
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if hash != testHash {
+		t.Errorf("NewHash() = %q, _; want %q", hash, testHash)
+	}
+}
+
+func TestRehash(t *testing.T) {
+	params := testParams(t)
+	hash, err := Rehash(testHash, testSalt, "user1password", params, 512, 1)
+	if err != nil {
+		t.Fatalf("Rehash() = _, %v; want nil", err)
+	}
+	if err := crypt.Check(hash, "user1password"); err != nil {
+		t.Errorf("crypt.Check() = %v; want nil", err)
+	}
+	if _, err := Rehash(testHash, testSalt, "password", params, 512, 1); err != crypt.ErrPasswordMismatch {
+		t.Errorf("Rehash() = _, %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	params := testParams(t)
+	if err := Check(testHash, testSalt, "user1password", params); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := NewHash("user1password", testSalt, params); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
@@ -0,0 +1,190 @@
+package crypt
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Op is a hash operation reported to a Hook.
+type Op int
+
+const (
+	OpCheck   Op = iota + 1 // a hash is compared with a password
+	OpNewHash               // a new hash is derived from a password
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpCheck:
+		return "check"
+	case OpNewHash:
+		return "new hash"
+	}
+	return "unknown"
+}
+
+// Outcome is the outcome of a hash operation reported to a Hook.
+type Outcome int
+
+const (
+	OutcomeOK        Outcome = iota + 1 // a new hash is derived
+	OutcomeMatch                        // the hash and password match
+	OutcomeMismatch                     // the hash and password mismatch
+	OutcomeMalformed                    // the hash or parameters are malformed or unknown
+	OutcomeRejected                     // the hash is not allowed or not approved in FIPS 140 mode
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeOK:
+		return "ok"
+	case OutcomeMatch:
+		return "match"
+	case OutcomeMismatch:
+		return "mismatch"
+	case OutcomeMalformed:
+		return "malformed"
+	case OutcomeRejected:
+		return "rejected"
+	}
+	return "unknown"
+}
+
+// Event describes a hash operation reported to a Hook.
+// It never contains the password, the salt or the sum of the hash.
+type Event struct {
+	Op Op
+
+	// Algorithm is the name of the package performing the operation, like "sha512",
+	// or "crypt" for the unknown hashes and the hashes registered with RegisterHash
+	// checked by Check and Registry.Check.
+	Algorithm string
+
+	// Params are the cost parameters of the hash, like rounds, if known.
+	// The hashes registered with RegisterHash report the hash prefix as "prefix".
+	Params []slog.Attr
+
+	Duration time.Duration
+	Outcome  Outcome
+}
+
+// LogValue implements slog.LogValuer.
+func (e Event) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("op", e.Op.String()),
+		slog.String("algorithm", e.Algorithm),
+	}
+	if len(e.Params) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "params", Value: slog.GroupValue(e.Params...)})
+	}
+	attrs = append(attrs,
+		slog.Duration("duration", e.Duration),
+		slog.String("outcome", e.Outcome.String()),
+	)
+	return slog.GroupValue(attrs...)
+}
+
+// Hook observes hash operations, for example, to log them or to record metrics.
+// It's called synchronously after each operation, so it must be fast
+// and safe for concurrent use.
+type Hook interface {
+	Observe(e Event)
+}
+
+// HookFunc is an adapter to allow the use of ordinary functions as hooks.
+type HookFunc func(e Event)
+
+// Observe calls f(e).
+func (f HookFunc) Observe(e Event) {
+	f(e)
+}
+
+type hookHolder struct {
+	hook Hook
+}
+
+var hook atomic.Pointer[hookHolder]
+
+// SetHook sets the hook called by Check, Registry.Check and the Check and NewHash functions
+// of the hash packages. If h is nil, the hook is removed.
+//
+// Each check is reported once, also when Check delegates it to a hash package.
+// Operations of hashes wrapping other hashes, like pepper and onion hashes,
+// report the operations of the wrapped hashes too.
+func SetHook(h Hook) {
+	if h == nil {
+		hook.Store(nil)
+		return
+	}
+	hook.Store(&hookHolder{hook: h})
+}
+
+func outcome(op Op, err error) Outcome {
+	switch {
+	case err == nil && op == OpNewHash:
+		return OutcomeOK
+	case err == nil:
+		return OutcomeMatch
+	case errors.Is(err, ErrPasswordMismatch):
+		return OutcomeMismatch
+	case errors.Is(err, ErrHashNotAllowed), errors.Is(err, ErrNotApproved):
+		return OutcomeRejected
+	}
+	return OutcomeMalformed
+}
+
+// ObserveCheck calls check with the hash and password and, if a hook is set,
+// reports the operation of the algorithm to the hook. The parameters are returned by params,
+// which is called only if a hook is set and may be nil.
+// Without a hook, it only calls check.
+//
+// It's meant for hash packages not registered for use by Check, as in:
+//
+//	func Check(hash, password string) error {
+//		return crypt.ObserveCheck("foo", hash, password, check, params)
+//	}
+//
+// Registered hash packages use Hash.ObserveCheck instead.
+func ObserveCheck(algorithm, hash, password string, check func(hash, password string) error, params func(hash string) []slog.Attr) error {
+	holder := hook.Load()
+	if holder == nil {
+		return check(hash, password)
+	}
+	start := time.Now()
+	err := check(hash, password)
+	e := Event{
+		Op:        OpCheck,
+		Algorithm: algorithm,
+		Duration:  time.Since(start),
+		Outcome:   outcome(OpCheck, err),
+	}
+	if params != nil && e.Outcome != OutcomeMalformed {
+		e.Params = params(hash)
+	}
+	holder.hook.Observe(e)
+	return err
+}
+
+// ObserveNewHash is like ObserveCheck but calls newHash
+// and passes the new hash to params.
+func ObserveNewHash(algorithm string, newHash func() (string, error), params func(hash string) []slog.Attr) (string, error) {
+	holder := hook.Load()
+	if holder == nil {
+		return newHash()
+	}
+	start := time.Now()
+	hash, err := newHash()
+	e := Event{
+		Op:        OpNewHash,
+		Algorithm: algorithm,
+		Duration:  time.Since(start),
+		Outcome:   outcome(OpNewHash, err),
+	}
+	if params != nil && err == nil {
+		e.Params = params(hash)
+	}
+	holder.hook.Observe(e)
+	return hash, err
+}
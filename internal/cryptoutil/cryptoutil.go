@@ -1,6 +1,11 @@
 package cryptoutil
 
-import "crypto/rand"
+import (
+	"crypto/fips140"
+	"crypto/rand"
+	"encoding/binary"
+	"unicode/utf16"
+)
 
 // Permute returns rearranged b elements in a order defined by t.
 func Permute(b, t []byte) []byte {
@@ -19,3 +24,17 @@ func Rand(n int) []byte {
 	}
 	return b
 }
+
+// EncodeUTF16LE returns s encoded as UTF-16 in little-endian byte order.
+func EncodeUTF16LE(s string) []byte {
+	a := utf16.Encode([]rune(s))
+	b := make([]byte, len(a)*2)
+	for i, r := range a {
+		binary.LittleEndian.PutUint16(b[i*2:], r)
+	}
+	return b
+}
+
+// FIPS140Enabled reports whether FIPS 140 mode is enabled, see crypto/fips140.Enabled.
+// It's a variable so tests can override it.
+var FIPS140Enabled = fips140.Enabled
@@ -3,6 +3,7 @@ package hashutil
 import (
 	"crypto/rand"
 	"math/big"
+	"strings"
 )
 
 const encoderHash = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
@@ -76,3 +77,12 @@ var (
 	HashEncoding   = NewEncoding(encoderHash)
 	Base64Encoding = NewEncoding(encoderBase64)
 )
+
+// Prefix returns the "$<id>$" prefix of the hash,
+// or its first n bytes if there is no such prefix.
+func Prefix(hash string, n int) string {
+	if i := strings.IndexByte(hash[min(len(hash), 1):], '$'); i >= 0 {
+		return hash[:i+2]
+	}
+	return hash[:min(len(hash), n)]
+}
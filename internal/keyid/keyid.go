@@ -0,0 +1,82 @@
+package keyid
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+)
+
+// UnknownKeyError values describe errors resulting from a key ID not found in a set of keys.
+type UnknownKeyError string
+
+func (e UnknownKeyError) Error() string {
+	return "unknown key " + strconv.Quote(string(e))
+}
+
+// InvalidKeyIDError values describe errors resulting from a key ID
+// that can't be represented in a hash string.
+type InvalidKeyIDError string
+
+func (e InvalidKeyIDError) Error() string {
+	return "invalid key ID " + strconv.Quote(string(e))
+}
+
+// Static is a fixed set of keys.
+type Static struct {
+	// Current is the ID of the key used for new hashes.
+	Current string
+
+	// Keys maps key IDs to keys, including the retired ones.
+	Keys map[string][]byte
+}
+
+func (s *Static) CurrentKeyID() string { return s.Current }
+
+func (s *Static) Key(id string) ([]byte, error) {
+	key, ok := s.Keys[id]
+	if !ok {
+		return nil, UnknownKeyError(id)
+	}
+	return key, nil
+}
+
+// Validate returns InvalidKeyIDError if the key ID is empty
+// or contains characters other than [0-9A-Za-z./_-].
+func Validate(id string) error {
+	if id == "" {
+		return InvalidKeyIDError(id)
+	}
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case c >= '0' && c <= '9', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c == '.', c == '/', c == '-', c == '_':
+		default:
+			return InvalidKeyIDError(id)
+		}
+	}
+	return nil
+}
+
+// Parse parses the "kid=<key ID>$" field following the prefix of the hash
+// and returns the key ID and the rest of the hash.
+// The next parameter names the field following the key ID in syntax errors.
+func Parse(hash, prefix, next string) (id, rest string, err error) {
+	s := hash[len(prefix):]
+	if !strings.HasPrefix(s, "kid=") {
+		return "", "", &parse.SyntaxError{Offset: len(prefix), Msg: "missing key ID"}
+	}
+	s = s[len("kid="):]
+	i := strings.IndexByte(s, '$')
+	if i < 0 {
+		return "", "", &parse.SyntaxError{Offset: len(hash), Msg: "missing " + next}
+	}
+	if err := Validate(s[:i]); err != nil {
+		return "", "", &parse.SyntaxError{Offset: len(hash) - len(s) + i, Msg: "invalid key ID"}
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// String returns the prefix and the "kid=<key ID>$" field of a hash string.
+func String(prefix, id string) string {
+	return prefix + "kid=" + id + "$"
+}
@@ -0,0 +1,29 @@
+package kerberos_test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/sergeymakinen/go-crypt/kerberos"
+)
+
+func ExampleKey() {
+	p, _ := kerberos.ParsePrincipal("raeburn@ATHENA.MIT.EDU")
+	key, _ := kerberos.Key([]byte("password"), p.Salt(), 1200, kerberos.AES128CTSHMACSHA196)
+	fmt.Println(hex.EncodeToString(key))
+	// Output:
+	// 4c01cd46d632d01e6dbe230a01ed642a
+}
+
+func ExampleWriteKeytab() {
+	p, _ := kerberos.ParsePrincipal("HTTP/www.example.com@EXAMPLE.COM")
+	var entries []kerberos.Entry
+	for _, encType := range []kerberos.EncType{kerberos.AES256CTSHMACSHA196, kerberos.AES128CTSHMACSHA196} {
+		entry, _ := kerberos.NewEntry(p, 1, "password", encType)
+		entries = append(entries, entry)
+	}
+	f, _ := os.Create("krb5.keytab")
+	defer f.Close()
+	kerberos.WriteKeytab(f, entries)
+}
@@ -0,0 +1,144 @@
+// Package kerberos implements the Kerberos string-to-key functions
+// of the rc4-hmac, aes128/aes256-cts-hmac-sha1-96 and aes-sha2 encryption types
+// and writing of MIT keytab files.
+package kerberos
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+	"strconv"
+
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/nthash"
+)
+
+// EncType is a Kerberos encryption type number.
+type EncType int32
+
+const (
+	AES128CTSHMACSHA196    EncType = 17 // aes128-cts-hmac-sha1-96
+	AES256CTSHMACSHA196    EncType = 18 // aes256-cts-hmac-sha1-96
+	AES128CTSHMACSHA256128 EncType = 19 // aes128-cts-hmac-sha256-128
+	AES256CTSHMACSHA384192 EncType = 20 // aes256-cts-hmac-sha384-192
+	RC4HMAC                EncType = 23 // rc4-hmac
+)
+
+var encTypeNames = map[EncType]string{
+	AES128CTSHMACSHA196:    "aes128-cts-hmac-sha1-96",
+	AES256CTSHMACSHA196:    "aes256-cts-hmac-sha1-96",
+	AES128CTSHMACSHA256128: "aes128-cts-hmac-sha256-128",
+	AES256CTSHMACSHA384192: "aes256-cts-hmac-sha384-192",
+	RC4HMAC:                "rc4-hmac",
+}
+
+func (e EncType) String() string {
+	if s, ok := encTypeNames[e]; ok {
+		return s
+	}
+	return "enctype(" + strconv.FormatInt(int64(e), 10) + ")"
+}
+
+// UnsupportedEncTypeError values describe errors resulting from an unsupported encryption type.
+type UnsupportedEncTypeError EncType
+
+func (e UnsupportedEncTypeError) Error() string {
+	return "unsupported encryption type " + EncType(e).String()
+}
+
+const (
+	MinIterations = 1
+
+	// DefaultIterations is the default iteration count
+	// of the aes128/aes256-cts-hmac-sha1-96 encryption types.
+	DefaultIterations = 4096
+
+	// DefaultSHA2Iterations is the default iteration count
+	// of the aes128-cts-hmac-sha256-128 and aes256-cts-hmac-sha384-192 encryption types.
+	DefaultSHA2Iterations = 32768
+)
+
+// InvalidIterationsError values describe errors resulting from an invalid iteration count.
+type InvalidIterationsError uint32
+
+func (e InvalidIterationsError) Error() string {
+	return "invalid iteration count " + strconv.FormatUint(uint64(e), 10)
+}
+
+// DefaultIterationsFor returns the default iteration count of the encryption type.
+// It returns 0 for encryption types that don't use an iteration count.
+func DefaultIterationsFor(encType EncType) uint32 {
+	switch encType {
+	case AES128CTSHMACSHA196, AES256CTSHMACSHA196:
+		return DefaultIterations
+	case AES128CTSHMACSHA256128, AES256CTSHMACSHA384192:
+		return DefaultSHA2Iterations
+	default:
+		return 0
+	}
+}
+
+// Key returns a long-term key of the encryption type derived from the password, salt and iterations.
+// The rc4-hmac key is the NT hash of the password: salt and iterations are ignored.
+func Key(password, salt []byte, iterations uint32, encType EncType) ([]byte, error) {
+	switch encType {
+	case RC4HMAC:
+		return nthash.Key(cryptoutil.EncodeUTF16LE(string(password)))
+	case AES128CTSHMACSHA196, AES256CTSHMACSHA196:
+		if iterations < MinIterations {
+			return nil, InvalidIterationsError(iterations)
+		}
+		keyLen := 16
+		if encType == AES256CTSHMACSHA196 {
+			keyLen = 32
+		}
+		key, err := pbkdf2.Key(sha1.New, string(password), salt, int(iterations), keyLen)
+		if err != nil {
+			return nil, err
+		}
+		return deriveKey(key, []byte("kerberos")), nil
+	case AES128CTSHMACSHA256128, AES256CTSHMACSHA384192:
+		if iterations < MinIterations {
+			return nil, InvalidIterationsError(iterations)
+		}
+		h, keyLen := sha256.New, 16
+		if encType == AES256CTSHMACSHA384192 {
+			h, keyLen = sha512.New384, 32
+		}
+		saltp := append([]byte(encType.String()+"\x00"), salt...)
+		key, err := pbkdf2.Key(h, string(password), saltp, int(iterations), keyLen)
+		if err != nil {
+			return nil, err
+		}
+		return kdfHMACSHA2(h, key, []byte("kerberos"), keyLen), nil
+	default:
+		return nil, UnsupportedEncTypeError(encType)
+	}
+}
+
+// deriveKey implements the DK function of RFC 3961 for AES keys.
+func deriveKey(key, constant []byte) []byte {
+	c, _ := aes.NewCipher(key)
+	block := nfold(constant, aes.BlockSize)
+	b := make([]byte, 0, len(key)+aes.BlockSize)
+	for len(b) < len(key) {
+		c.Encrypt(block, block)
+		b = append(b, block...)
+	}
+	return b[:len(key)]
+}
+
+// kdfHMACSHA2 implements the KDF-HMAC-SHA2 function of RFC 8009 with an empty context.
+func kdfHMACSHA2(h func() hash.Hash, key, label []byte, keyLen int) []byte {
+	mac := hmac.New(h, key)
+	mac.Write([]byte{0, 0, 0, 1})
+	mac.Write(label)
+	mac.Write([]byte{0})
+	mac.Write(binary.BigEndian.AppendUint32(nil, uint32(keyLen*8)))
+	return mac.Sum(nil)[:keyLen]
+}
@@ -0,0 +1,169 @@
+package kerberos
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestNFold(t *testing.T) {
+	tests := []struct {
+		b        string
+		n        int
+		expected string
+	}{
+		{"012345", 8, "be072631276b1955"},
+		{"password", 7, "78a07b6caf85fa"},
+		{"Rough Consensus, and Running Code", 8, "bb6ed30870b7f0e0"},
+		{"password", 21, "59e4a8ca7c0385c3c37b3f6d2000247cb6e6bd5b3e"},
+		{"MASSACHVSETTS INSTITVTE OF TECHNOLOGY", 24, "db3b0d8f0b061e603282b308a50841229ad798fab9540c1b"},
+		{"Q", 21, "518a54a215a8452a518a54a215a8452a518a54a215"},
+		{"ba", 21, "fb25d531ae8974499f52fd92ea9857c4ba24cf297e"},
+		{"kerberos", 8, "6b65726265726f73"},
+		{"kerberos", 16, "6b65726265726f737b9b5b2b93132b93"},
+		{"kerberos", 21, "8372c236344e5f1550cd0747e15d62ca7a5a3bcea4"},
+		{"kerberos", 32, "6b65726265726f737b9b5b2b93132b935c9bdcdad95c9899c4cae4dee6d6cae4"},
+	}
+	for _, test := range tests {
+		t.Run(test.b+"/"+strconv.Itoa(test.n), func(t *testing.T) {
+			if b := hex.EncodeToString(nfold([]byte(test.b), test.n)); b != test.expected {
+				t.Errorf("nfold() = %q; want %q", b, test.expected)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	sha2Salt, _ := hex.DecodeString("10df9dd783e5bc8acea1730e74355f61")
+	sha2Salt = append(sha2Salt, "ATHENA.MIT.EDUraeburn"...)
+	tests := []struct {
+		salt       []byte
+		iterations uint32
+		encType    EncType
+		expected   string
+	}{
+		{[]byte("ATHENA.MIT.EDUraeburn"), 1, AES128CTSHMACSHA196, "42263c6e89f4fc28b8df68ee09799f15"},
+		{[]byte("ATHENA.MIT.EDUraeburn"), 1, AES256CTSHMACSHA196, "fe697b52bc0d3ce14432ba036a92e65bbb52280990a2fa27883998d72af30161"},
+		{[]byte("ATHENA.MIT.EDUraeburn"), 1200, AES128CTSHMACSHA196, "4c01cd46d632d01e6dbe230a01ed642a"},
+		{[]byte("ATHENA.MIT.EDUraeburn"), 1200, AES256CTSHMACSHA196, "55a6ac740ad17b4846941051e1e8b0a7548d93b0ab30a8bc3ff16280382b8c2a"},
+		{sha2Salt, 32768, AES128CTSHMACSHA256128, "089bca48b105ea6ea77ca5d2f39dc5e7"},
+		{sha2Salt, 32768, AES256CTSHMACSHA384192, "45bd806dbf6a833a9cffc1c94589a222367a79bc21c413718906e9f578a78467"},
+		{nil, 0, RC4HMAC, "8846f7eaee8fb117ad06bdd830b7586c"},
+	}
+	for _, test := range tests {
+		t.Run(test.encType.String()+"/"+strconv.FormatUint(uint64(test.iterations), 10), func(t *testing.T) {
+			key, err := Key([]byte("password"), test.salt, test.iterations, test.encType)
+			if err != nil {
+				t.Fatalf("Key() = _, %v; want nil", err)
+			}
+			if encKey := hex.EncodeToString(key); encKey != test.expected {
+				t.Errorf("Key() = %q, _; want %q", encKey, test.expected)
+			}
+		})
+	}
+}
+
+func TestKeyShouldFail(t *testing.T) {
+	tests := []struct {
+		iterations uint32
+		encType    EncType
+		err        error
+	}{
+		{0, AES256CTSHMACSHA196, InvalidIterationsError(0)},
+		{0, AES256CTSHMACSHA384192, InvalidIterationsError(0)},
+		{DefaultIterations, 16, UnsupportedEncTypeError(16)},
+	}
+	for _, test := range tests {
+		t.Run(test.encType.String(), func(t *testing.T) {
+			if _, err := Key([]byte("password"), []byte("salt"), test.iterations, test.encType); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Key() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParsePrincipal(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Principal
+	}{
+		{"user@EXAMPLE.COM", Principal{Components: []string{"user"}, Realm: "EXAMPLE.COM", NameType: NameTypePrincipal}},
+		{"HTTP/www.example.com@EXAMPLE.COM", Principal{Components: []string{"HTTP", "www.example.com"}, Realm: "EXAMPLE.COM", NameType: NameTypePrincipal}},
+		{`a\/b\@c@EXAMPLE.COM`, Principal{Components: []string{"a/b@c"}, Realm: "EXAMPLE.COM", NameType: NameTypePrincipal}},
+	}
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			p, err := ParsePrincipal(test.s)
+			if err != nil {
+				t.Fatalf("ParsePrincipal() = _, %v; want nil", err)
+			}
+			if diff := cmp.Diff(test.expected, p); diff != "" {
+				t.Errorf("ParsePrincipal() mismatch (-want +got):\n%s", diff)
+			}
+			if s := p.String(); s != test.s {
+				t.Errorf("String() = %q; want %q", s, test.s)
+			}
+		})
+	}
+}
+
+func TestParsePrincipalShouldFail(t *testing.T) {
+	tests := []string{
+		"",
+		"user",
+		"user@",
+		"@EXAMPLE.COM",
+		"HTTP/@EXAMPLE.COM",
+		`user@EXAMPLE.COM\`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			if _, err := ParsePrincipal(test); !testutil.IsEqualError(err, InvalidPrincipalError(test)) {
+				t.Errorf("ParsePrincipal() = _, %v; want %v", err, InvalidPrincipalError(test))
+			}
+		})
+	}
+}
+
+func TestWriteKeytab(t *testing.T) {
+	p, _ := ParsePrincipal("HTTP/www@EXAMPLE.COM")
+	if salt := string(p.Salt()); salt != "EXAMPLE.COMHTTPwww" {
+		t.Errorf("Salt() = %q; want %q", salt, "EXAMPLE.COMHTTPwww")
+	}
+	entry, err := NewEntry(p, 258, "password", RC4HMAC)
+	if err != nil {
+		t.Fatalf("NewEntry() = _, %v; want nil", err)
+	}
+	entry.Timestamp = time.Unix(0x01020304, 0)
+	var buf bytes.Buffer
+	if err := WriteKeytab(&buf, []Entry{entry}); err != nil {
+		t.Fatalf("WriteKeytab() = %v; want nil", err)
+	}
+	expected := "0502" + // version
+		"0000003b" + // entry length
+		"0002" + // components
+		"000b" + hex.EncodeToString([]byte("EXAMPLE.COM")) +
+		"0004" + hex.EncodeToString([]byte("HTTP")) +
+		"0003" + hex.EncodeToString([]byte("www")) +
+		"00000001" + // name type
+		"01020304" + // timestamp
+		"02" + // 8-bit kvno
+		"0017" + // enctype
+		"0010" + "8846f7eaee8fb117ad06bdd830b7586c" +
+		"00000102" // 32-bit kvno
+	if b := hex.EncodeToString(buf.Bytes()); b != expected {
+		t.Errorf("WriteKeytab() = %q; want %q", b, expected)
+	}
+}
+
+func TestWriteKeytabShouldFail(t *testing.T) {
+	err := WriteKeytab(&bytes.Buffer{}, []Entry{{Principal: Principal{Realm: "EXAMPLE.COM"}}})
+	if expected := InvalidEntryError("invalid number of principal components"); !testutil.IsEqualError(err, expected) {
+		t.Errorf("WriteKeytab() = %v; want %v", err, expected)
+	}
+}
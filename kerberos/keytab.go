@@ -0,0 +1,170 @@
+package kerberos
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Principal name types.
+const (
+	NameTypeUnknown   = 0
+	NameTypePrincipal = 1
+	NameTypeSrvInst   = 2
+	NameTypeSrvHost   = 3
+)
+
+// InvalidPrincipalError values describe errors resulting from an invalid principal name.
+type InvalidPrincipalError string
+
+func (e InvalidPrincipalError) Error() string {
+	return "invalid principal " + strconv.Quote(string(e))
+}
+
+// Principal is a Kerberos principal name.
+type Principal struct {
+	Components []string
+	Realm      string
+	NameType   int32
+}
+
+var principalEscaper = strings.NewReplacer(`\`, `\\`, "/", `\/`, "@", `\@`)
+
+// String returns the principal in the "primary/instance@REALM" form.
+func (p Principal) String() string {
+	components := make([]string, len(p.Components))
+	for i, c := range p.Components {
+		components[i] = principalEscaper.Replace(c)
+	}
+	return strings.Join(components, "/") + "@" + principalEscaper.Replace(p.Realm)
+}
+
+// Salt returns the default salt of the principal:
+// the realm followed by all the name components.
+func (p Principal) Salt() []byte {
+	return []byte(p.Realm + strings.Join(p.Components, ""))
+}
+
+// ParsePrincipal parses a principal in the "primary/instance@REALM" form.
+// Backslash may be used to escape '/', '@' and '\'. The name type is NameTypePrincipal.
+func ParsePrincipal(s string) (Principal, error) {
+	p := Principal{NameType: NameTypePrincipal}
+	var (
+		b       strings.Builder
+		inRealm bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+			if i == len(s) {
+				return Principal{}, InvalidPrincipalError(s)
+			}
+			b.WriteByte(s[i])
+		case c == '/' && !inRealm:
+			p.Components = append(p.Components, b.String())
+			b.Reset()
+		case c == '@' && !inRealm:
+			p.Components = append(p.Components, b.String())
+			b.Reset()
+			inRealm = true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if !inRealm || b.Len() == 0 {
+		return Principal{}, InvalidPrincipalError(s)
+	}
+	for _, c := range p.Components {
+		if c == "" {
+			return Principal{}, InvalidPrincipalError(s)
+		}
+	}
+	p.Realm = b.String()
+	return p, nil
+}
+
+// Entry is a keytab entry.
+type Entry struct {
+	Principal Principal
+	Timestamp time.Time
+	KVNO      uint32
+	EncType   EncType
+	Key       []byte
+}
+
+// NewEntry returns a keytab entry for the principal with a key derived from the password
+// using the default salt of the principal and the default iteration count of the encryption type.
+func NewEntry(principal Principal, kvno uint32, password string, encType EncType) (Entry, error) {
+	key, err := Key([]byte(password), principal.Salt(), DefaultIterationsFor(encType), encType)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Principal: principal,
+		Timestamp: time.Now(),
+		KVNO:      kvno,
+		EncType:   encType,
+		Key:       key,
+	}, nil
+}
+
+const keytabVersion = 0x0502
+
+// InvalidEntryError values describe errors resulting from a keytab entry
+// that can't be represented in a keytab file.
+type InvalidEntryError string
+
+func (e InvalidEntryError) Error() string {
+	return "invalid keytab entry: " + string(e)
+}
+
+// WriteKeytab writes the entries in the MIT keytab file format (version 0x502) to w.
+func WriteKeytab(w io.Writer, entries []Entry) error {
+	b := binary.BigEndian.AppendUint16(nil, keytabVersion)
+	for _, e := range entries {
+		entry, err := appendEntry(nil, e)
+		if err != nil {
+			return err
+		}
+		b = binary.BigEndian.AppendUint32(b, uint32(len(entry)))
+		b = append(b, entry...)
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func appendEntry(b []byte, e Entry) ([]byte, error) {
+	if len(e.Principal.Components) == 0 || len(e.Principal.Components) > math.MaxUint16 {
+		return nil, InvalidEntryError("invalid number of principal components")
+	}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(e.Principal.Components)))
+	var err error
+	if b, err = appendData(b, []byte(e.Principal.Realm)); err != nil {
+		return nil, err
+	}
+	for _, c := range e.Principal.Components {
+		if b, err = appendData(b, []byte(c)); err != nil {
+			return nil, err
+		}
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(e.Principal.NameType))
+	b = binary.BigEndian.AppendUint32(b, uint32(e.Timestamp.Unix()))
+	b = append(b, byte(e.KVNO))
+	b = binary.BigEndian.AppendUint16(b, uint16(e.EncType))
+	if b, err = appendData(b, e.Key); err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint32(b, e.KVNO), nil
+}
+
+func appendData(b, data []byte) ([]byte, error) {
+	if len(data) > math.MaxUint16 {
+		return nil, InvalidEntryError("data too long")
+	}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...), nil
+}
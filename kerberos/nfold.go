@@ -0,0 +1,52 @@
+package kerberos
+
+// nfold implements the n-fold operation of RFC 3961
+// and returns b folded to n bytes.
+func nfold(b []byte, n int) []byte {
+	inBits, outBits := len(b)*8, n*8
+	lcm := inBits * outBits / gcd(inBits, outBits)
+	// Concatenate lcm/inBits copies of b, each rotated 13 bits to the right
+	// relative to the previous one
+	buf := make([]byte, lcm/8)
+	for i := 0; i < lcm/inBits; i++ {
+		rotateRight(buf[i*len(b):(i+1)*len(b)], b, 13*i)
+	}
+	// Add the n-byte blocks together with one's complement addition
+	sum := make([]byte, n)
+	for off := 0; off < len(buf); off += n {
+		var carry int
+		for i := n - 1; i >= 0; i-- {
+			carry += int(sum[i]) + int(buf[off+i])
+			sum[i] = byte(carry)
+			carry >>= 8
+		}
+		// End-around carry
+		for carry != 0 {
+			for i := n - 1; carry != 0 && i >= 0; i-- {
+				carry += int(sum[i])
+				sum[i] = byte(carry)
+				carry >>= 8
+			}
+		}
+	}
+	return sum
+}
+
+// rotateRight stores src rotated right by k bits into dst.
+func rotateRight(dst, src []byte, k int) {
+	bits := len(src) * 8
+	k %= bits
+	for i := 0; i < bits; i++ {
+		j := (i - k + bits) % bits
+		if src[j/8]&(0x80>>(j%8)) != 0 {
+			dst[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,22 @@
+package keycloak_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/keycloak"
+)
+
+func ExampleCheck() {
+	var c keycloak.Credential
+	json.Unmarshal([]byte(`{
+		"type": "password",
+		"secretData": "{\"value\":\"gYW3K2hDotixLdnZnDA7ggXjDdS/WGoafjk4eoTrPXg=\",\"salt\":\"AAECAwQFBgcICQoLDA0ODw==\",\"additionalParameters\":{}}",
+		"credentialData": "{\"hashIterations\":27500,\"algorithm\":\"pbkdf2-sha256\",\"additionalParameters\":{}}"
+	}`), &c)
+	fmt.Println(keycloak.Check(&c, "password"))
+	fmt.Println(keycloak.Check(&c, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
@@ -0,0 +1,344 @@
+// Package keycloak implements the Keycloak password credential representation
+// and its PBKDF2 and Argon2 password hashing providers.
+package keycloak
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/json"
+	"hash"
+	"log/slog"
+	"strconv"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/argon2/argon2crypto"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+const (
+	AlgorithmPBKDF2       = "pbkdf2"
+	AlgorithmPBKDF2SHA256 = "pbkdf2-sha256"
+	AlgorithmPBKDF2SHA512 = "pbkdf2-sha512"
+	AlgorithmArgon2       = "argon2"
+)
+
+// UnsupportedAlgorithmError values describe errors resulting from an unsupported algorithm.
+type UnsupportedAlgorithmError string
+
+func (e UnsupportedAlgorithmError) Error() string {
+	return "unsupported algorithm " + strconv.Quote(string(e))
+}
+
+const (
+	MinIterations = 1
+
+	DefaultPBKDF2Iterations       = 1300000
+	DefaultPBKDF2SHA256Iterations = 600000
+	DefaultPBKDF2SHA512Iterations = 210000
+	DefaultArgon2Iterations       = 5
+)
+
+// InvalidIterationsError values describe errors resulting from an invalid iteration count.
+type InvalidIterationsError int
+
+func (e InvalidIterationsError) Error() string {
+	return "invalid iteration count " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidParameterError values describe errors resulting from an invalid additional parameter.
+type InvalidParameterError string
+
+func (e InvalidParameterError) Error() string {
+	return "invalid parameter " + strconv.Quote(string(e))
+}
+
+const SaltLength = 16
+
+// Argon2 additional parameters.
+const (
+	ParamType        = "type"
+	ParamVersion     = "version"
+	ParamHashLength  = "hashLength"
+	ParamMemory      = "memory"
+	ParamParallelism = "parallelism"
+)
+
+// Credential is a Keycloak password credential.
+type Credential struct {
+	Algorithm      string
+	HashIterations int
+	Salt           []byte
+	Value          []byte
+
+	// AdditionalParameters are the algorithm parameters, like the Argon2 memory cost.
+	AdditionalParameters map[string][]string
+}
+
+const credentialType = "password"
+
+// UnsupportedTypeError values describe errors resulting from an unsupported credential type.
+type UnsupportedTypeError string
+
+func (e UnsupportedTypeError) Error() string {
+	return "unsupported credential type " + strconv.Quote(string(e))
+}
+
+type representation struct {
+	Type           string `json:"type"`
+	SecretData     string `json:"secretData,omitempty"`
+	CredentialData string `json:"credentialData,omitempty"`
+
+	// Legacy representation
+	HashedSaltedValue []byte `json:"hashedSaltedValue,omitempty"`
+	Salt              []byte `json:"salt,omitempty"`
+	HashIterations    int    `json:"hashIterations,omitempty"`
+	Algorithm         string `json:"algorithm,omitempty"`
+}
+
+type secretData struct {
+	Value                []byte              `json:"value"`
+	Salt                 []byte              `json:"salt"`
+	AdditionalParameters map[string][]string `json:"additionalParameters"`
+}
+
+type credentialData struct {
+	HashIterations       int                 `json:"hashIterations"`
+	Algorithm            string              `json:"algorithm"`
+	AdditionalParameters map[string][]string `json:"additionalParameters"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The credential is encoded as a CredentialRepresentation of the admin REST API
+// and realm exports, with JSON-encoded secretData and credentialData.
+func (c *Credential) MarshalJSON() ([]byte, error) {
+	secret, err := json.Marshal(secretData{
+		Value:                c.Value,
+		Salt:                 c.Salt,
+		AdditionalParameters: map[string][]string{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	params := c.AdditionalParameters
+	if params == nil {
+		params = map[string][]string{}
+	}
+	data, err := json.Marshal(credentialData{
+		HashIterations:       c.HashIterations,
+		Algorithm:            c.Algorithm,
+		AdditionalParameters: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(representation{
+		Type:           credentialType,
+		SecretData:     string(secret),
+		CredentialData: string(data),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Both the current representation and the legacy one
+// (with hashedSaltedValue, salt, hashIterations and algorithm fields) are supported.
+func (c *Credential) UnmarshalJSON(b []byte) error {
+	var r representation
+	if err := json.Unmarshal(b, &r); err != nil {
+		return err
+	}
+	if r.Type != credentialType {
+		return UnsupportedTypeError(r.Type)
+	}
+	if r.SecretData == "" {
+		*c = Credential{
+			Algorithm:      r.Algorithm,
+			HashIterations: r.HashIterations,
+			Salt:           r.Salt,
+			Value:          r.HashedSaltedValue,
+		}
+		return nil
+	}
+	var (
+		secret secretData
+		data   credentialData
+	)
+	if err := json.Unmarshal([]byte(r.SecretData), &secret); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(r.CredentialData), &data); err != nil {
+		return err
+	}
+	*c = Credential{
+		Algorithm:            data.Algorithm,
+		HashIterations:       data.HashIterations,
+		Salt:                 secret.Salt,
+		Value:                secret.Value,
+		AdditionalParameters: data.AdditionalParameters,
+	}
+	return nil
+}
+
+// pbkdf2KeyLength is the default derived key size of all PBKDF2 providers of Keycloak, 512 bits.
+// Keys of other lengths, like the 256-bit keys of older pbkdf2-sha256 credentials,
+// are checked with their stored length.
+const pbkdf2KeyLength = 64
+
+func pbkdf2Hash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case AlgorithmPBKDF2:
+		return sha1.New
+	case AlgorithmPBKDF2SHA256:
+		return sha256.New
+	case AlgorithmPBKDF2SHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// Argon2 defaults of Keycloak.
+var defaultArgon2Params = map[string][]string{
+	ParamType:        {"id"},
+	ParamVersion:     {"1.3"},
+	ParamHashLength:  {"32"},
+	ParamMemory:      {"7168"},
+	ParamParallelism: {"1"},
+}
+
+func argon2Param(params map[string][]string, name string) string {
+	if v := params[name]; len(v) > 0 {
+		return v[0]
+	}
+	return defaultArgon2Params[name][0]
+}
+
+func argon2Uint(params map[string][]string, name string, bitSize int) (uint64, error) {
+	n, err := strconv.ParseUint(argon2Param(params, name), 10, bitSize)
+	if err != nil || n == 0 {
+		return 0, InvalidParameterError(name)
+	}
+	return n, nil
+}
+
+// Key returns a key derived from the password using the algorithm, salt, iterations
+// and additional parameters of the credential. The keyLen parameter is the PBKDF2 key length:
+// if 0, the default key length of Keycloak, 512 bits, is used.
+// Returns crypt.ErrNotApproved for Argon2 in FIPS 140 mode.
+func Key(password []byte, c *Credential, keyLen int) ([]byte, error) {
+	if c.HashIterations < MinIterations {
+		return nil, InvalidIterationsError(c.HashIterations)
+	}
+	if c.Algorithm == AlgorithmArgon2 {
+		if cryptoutil.FIPS140Enabled() {
+			return nil, crypt.ErrNotApproved
+		}
+		var mode int
+		switch argon2Param(c.AdditionalParameters, ParamType) {
+		case "d":
+			mode = argon2crypto.Argon2d
+		case "i":
+			mode = argon2crypto.Argon2i
+		case "id":
+			mode = argon2crypto.Argon2id
+		default:
+			return nil, InvalidParameterError(ParamType)
+		}
+		var version int
+		switch argon2Param(c.AdditionalParameters, ParamVersion) {
+		case "1.0":
+			version = argon2crypto.Version10
+		case "1.3":
+			version = argon2crypto.Version13
+		default:
+			return nil, InvalidParameterError(ParamVersion)
+		}
+		hashLen, err := argon2Uint(c.AdditionalParameters, ParamHashLength, 32)
+		if err != nil {
+			return nil, err
+		}
+		memory, err := argon2Uint(c.AdditionalParameters, ParamMemory, 32)
+		if err != nil {
+			return nil, err
+		}
+		parallelism, err := argon2Uint(c.AdditionalParameters, ParamParallelism, 8)
+		if err != nil {
+			return nil, err
+		}
+		return argon2crypto.Key(mode, version, password, c.Salt, uint32(c.HashIterations), uint32(memory), uint8(parallelism), uint32(hashLen)), nil
+	}
+	h := pbkdf2Hash(c.Algorithm)
+	if h == nil {
+		return nil, UnsupportedAlgorithmError(c.Algorithm)
+	}
+	if keyLen == 0 {
+		keyLen = pbkdf2KeyLength
+	}
+	return pbkdf2.Key(h, string(password), c.Salt, c.HashIterations, keyLen)
+}
+
+// hookParams returns the algorithm and iteration count of the credential for crypt.Hook.
+func (c *Credential) hookParams() []slog.Attr {
+	return []slog.Attr{
+		slog.String("algorithm", c.Algorithm),
+		slog.Int("iterations", c.HashIterations),
+	}
+}
+
+// NewCredential returns a new credential of the password hashed with the algorithm and iterations
+// using the default parameters of Keycloak.
+func NewCredential(password, algorithm string, iterations int) (*Credential, error) {
+	var c *Credential
+	_, err := crypt.ObserveNewHash("keycloak", func() (string, error) {
+		var err error
+		c, err = newCredential(password, algorithm, iterations)
+		return "", err
+	}, func(string) []slog.Attr {
+		return c.hookParams()
+	})
+	return c, err
+}
+
+func newCredential(password, algorithm string, iterations int) (*Credential, error) {
+	c := &Credential{
+		Algorithm:      algorithm,
+		HashIterations: iterations,
+		Salt:           cryptoutil.Rand(SaltLength),
+	}
+	if algorithm == AlgorithmArgon2 {
+		c.AdditionalParameters = make(map[string][]string, len(defaultArgon2Params))
+		for k, v := range defaultArgon2Params {
+			c.AdditionalParameters[k] = v
+		}
+	}
+	key, err := Key([]byte(password), c, 0)
+	if err != nil {
+		return nil, err
+	}
+	c.Value = key
+	return c, nil
+}
+
+// Check compares the given credential with a new credential derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(c *Credential, password string) error {
+	return crypt.ObserveCheck("keycloak", "", password, func(_, password string) error {
+		return check(c, password)
+	}, func(string) []slog.Attr {
+		return c.hookParams()
+	})
+}
+
+func check(c *Credential, password string) error {
+	// Keycloak derives PBKDF2 keys of the stored key length
+	key, err := Key([]byte(password), c, len(c.Value))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, c.Value) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
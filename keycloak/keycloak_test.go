@@ -0,0 +1,220 @@
+package keycloak
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+const testCredential = `{
+	"type": "password",
+	"createdDate": 1700000000000,
+	"secretData": "{\"value\":\"gYW3K2hDotixLdnZnDA7ggXjDdS/WGoafjk4eoTrPXg=\",\"salt\":\"AAECAwQFBgcICQoLDA0ODw==\",\"additionalParameters\":{}}",
+	"credentialData": "{\"hashIterations\":27500,\"algorithm\":\"pbkdf2-sha256\",\"additionalParameters\":{}}"
+}`
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name, json string
+	}{
+		{
+			name: "current",
+			json: testCredential,
+		},
+		{
+			name: "legacy",
+			json: `{"type":"password","hashedSaltedValue":"gYW3K2hDotixLdnZnDA7ggXjDdS/WGoafjk4eoTrPXg=","salt":"AAECAwQFBgcICQoLDA0ODw==","hashIterations":27500,"algorithm":"pbkdf2-sha256"}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var c Credential
+			if err := json.Unmarshal([]byte(test.json), &c); err != nil {
+				t.Fatalf("json.Unmarshal() = %v; want nil", err)
+			}
+			if c.Algorithm != AlgorithmPBKDF2SHA256 || c.HashIterations != 27500 {
+				t.Errorf("json.Unmarshal() = %+v; want %q algorithm and 27500 iterations", c, AlgorithmPBKDF2SHA256)
+			}
+			if err := Check(&c, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(&c, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONShouldFail(t *testing.T) {
+	var c Credential
+	err := json.Unmarshal([]byte(`{"type":"otp"}`), &c)
+	if expected := UnsupportedTypeError("otp"); !testutil.IsEqualError(err, expected) {
+		t.Errorf("json.Unmarshal() = %v; want %v", err, expected)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	var c Credential
+	if err := json.Unmarshal([]byte(testCredential), &c); err != nil {
+		t.Fatalf("json.Unmarshal() = %v; want nil", err)
+	}
+	b, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatalf("json.Marshal() = _, %v; want nil", err)
+	}
+	expected := `{"type":"password",` +
+		`"secretData":"{\"value\":\"gYW3K2hDotixLdnZnDA7ggXjDdS/WGoafjk4eoTrPXg=\",\"salt\":\"AAECAwQFBgcICQoLDA0ODw==\",\"additionalParameters\":{}}",` +
+		`"credentialData":"{\"hashIterations\":27500,\"algorithm\":\"pbkdf2-sha256\",\"additionalParameters\":{}}"}`
+	if string(b) != expected {
+		t.Errorf("json.Marshal() = %s, _; want %s", b, expected)
+	}
+}
+
+func TestKey(t *testing.T) {
+	c := &Credential{
+		Algorithm:      AlgorithmPBKDF2,
+		HashIterations: 20000,
+		Salt:           []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	}
+	c.Value, _ = Key([]byte("password"), c, 0)
+	var expected Credential
+	json.Unmarshal([]byte(`{"type":"password","hashedSaltedValue":"Kn1FJuAQG/R44FhuUf0WpZ9bjHi/hCB7sgunu5OjUTH03qiyjO4EBzmmMt8zC7U7jLLKXTyKF26fLEo+zrg5Jg==","salt":"AAECAwQFBgcICQoLDA0ODw==","hashIterations":20000,"algorithm":"pbkdf2"}`), &expected)
+	if diff := cmp.Diff(&expected, c); diff != "" {
+		t.Errorf("Key() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	tests := []struct {
+		name       string
+		credential Credential
+		err        error
+	}{
+		{
+			name:       "algorithm",
+			credential: Credential{Algorithm: "md5", HashIterations: 1},
+			err:        UnsupportedAlgorithmError("md5"),
+		},
+		{
+			name:       "iterations",
+			credential: Credential{Algorithm: AlgorithmPBKDF2SHA256},
+			err:        InvalidIterationsError(0),
+		},
+		{
+			name: "type",
+			credential: Credential{
+				Algorithm:            AlgorithmArgon2,
+				HashIterations:       1,
+				AdditionalParameters: map[string][]string{ParamType: {"x"}},
+			},
+			err: InvalidParameterError(ParamType),
+		},
+		{
+			name: "memory",
+			credential: Credential{
+				Algorithm:            AlgorithmArgon2,
+				HashIterations:       1,
+				AdditionalParameters: map[string][]string{ParamMemory: {"-1"}},
+			},
+			err: InvalidParameterError(ParamMemory),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := Check(&test.credential, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewCredential(t *testing.T) {
+	tests := []struct {
+		algorithm  string
+		iterations int
+		keyLen     int
+	}{
+		{AlgorithmPBKDF2, 1000, 64},
+		{AlgorithmPBKDF2SHA256, 1000, 64},
+		{AlgorithmPBKDF2SHA512, 1000, 64},
+		{AlgorithmArgon2, DefaultArgon2Iterations, 32},
+	}
+	for _, test := range tests {
+		t.Run(test.algorithm, func(t *testing.T) {
+			c, err := NewCredential("password", test.algorithm, test.iterations)
+			if err != nil {
+				t.Fatalf("NewCredential() = _, %v; want nil", err)
+			}
+			if len(c.Salt) != SaltLength || len(c.Value) != test.keyLen {
+				t.Errorf("NewCredential() = %+v, _; want %d-byte salt and %d-byte value", c, SaltLength, test.keyLen)
+			}
+			b, err := json.Marshal(c)
+			if err != nil {
+				t.Fatalf("json.Marshal() = _, %v; want nil", err)
+			}
+			var c2 Credential
+			if err := json.Unmarshal(b, &c2); err != nil {
+				t.Fatalf("json.Unmarshal() = %v; want nil", err)
+			}
+			if err := Check(&c2, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestHook(t *testing.T) {
+	var events []crypt.Event
+	crypt.SetHook(crypt.HookFunc(func(e crypt.Event) {
+		events = append(events, e)
+	}))
+	defer crypt.SetHook(nil)
+	c, err := NewCredential("password", AlgorithmPBKDF2SHA256, 1000)
+	if err != nil {
+		t.Fatalf("NewCredential() = _, %v; want nil", err)
+	}
+	if err := Check(c, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	tests := []struct {
+		op      crypt.Op
+		outcome crypt.Outcome
+	}{
+		{op: crypt.OpNewHash, outcome: crypt.OutcomeOK},
+		{op: crypt.OpCheck, outcome: crypt.OutcomeMatch},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("got %d events; want %d", len(events), len(tests))
+	}
+	for i, test := range tests {
+		e := events[i]
+		if e.Op != test.op || e.Algorithm != "keycloak" || e.Outcome != test.outcome {
+			t.Errorf("events[%d] = %v %q %v; want %v %q %v", i, e.Op, e.Algorithm, e.Outcome, test.op, "keycloak", test.outcome)
+		}
+		if params, expected := fmt.Sprint(e.Params), "[algorithm=pbkdf2-sha256 iterations=1000]"; params != expected {
+			t.Errorf("events[%d].Params = %s; want %s", i, params, expected)
+		}
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	c, err := NewCredential("password", AlgorithmPBKDF2SHA512, 1000)
+	if err != nil {
+		t.Fatalf("NewCredential() = _, %v; want nil", err)
+	}
+	if err = Check(c, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if _, err = NewCredential("password", AlgorithmArgon2, DefaultArgon2Iterations); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewCredential() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
@@ -0,0 +1,29 @@
+package logindefs_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/logindefs"
+	"github.com/sergeymakinen/go-crypt/sha512"
+)
+
+func ExampleDefs_Generator() {
+	defs, err := logindefs.ReadFile("testdata/login.defs")
+	if err != nil {
+		panic(err)
+	}
+	generate, err := defs.Generator()
+	if err != nil {
+		panic(err)
+	}
+	hash, err := generate("password")
+	if err != nil {
+		panic(err)
+	}
+	_, rounds, _ := sha512.Params(hash)
+	fmt.Println(defs.Method(), rounds >= 5000 && rounds <= 6000)
+	fmt.Println(sha512.Check(hash, "password"))
+	// Output:
+	// SHA512 true
+	// <nil>
+}
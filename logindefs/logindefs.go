@@ -0,0 +1,229 @@
+// Package logindefs implements the parsing of the shadow password suite configuration, /etc/login.defs,
+// and the hashing of passwords with the method and parameters configured there, like chpasswd(8) does.
+package logindefs
+
+import (
+	"bufio"
+	"crypto/rand"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt/bcrypt"
+	"github.com/sergeymakinen/go-crypt/des"
+	"github.com/sergeymakinen/go-crypt/md5"
+	"github.com/sergeymakinen/go-crypt/sha256"
+	"github.com/sergeymakinen/go-crypt/sha512"
+)
+
+// DefaultPath is the default login.defs location.
+const DefaultPath = "/etc/login.defs"
+
+// Methods of the ENCRYPT_METHOD setting.
+const (
+	MethodDES      = "DES"
+	MethodMD5      = "MD5"
+	MethodSHA256   = "SHA256"
+	MethodSHA512   = "SHA512"
+	MethodBcrypt   = "BCRYPT"
+	MethodYescrypt = "YESCRYPT"
+)
+
+// UnsupportedMethodError values describe errors resulting from an unsupported ENCRYPT_METHOD value.
+type UnsupportedMethodError string
+
+func (e UnsupportedMethodError) Error() string {
+	return "unsupported method " + strconv.Quote(string(e))
+}
+
+// InvalidValueError describes a setting with a value that can't be used.
+type InvalidValueError struct {
+	Name  string
+	Value string
+}
+
+func (e *InvalidValueError) Error() string {
+	return "invalid " + e.Name + " value " + strconv.Quote(e.Value)
+}
+
+// Defs are login.defs settings.
+type Defs struct {
+	values map[string]string
+}
+
+// Parse parses login.defs settings from r.
+// Empty lines and comment lines starting with "#" are skipped,
+// values may be enclosed in double quotes.
+// If a setting is repeated, the last value is used.
+func Parse(r io.Reader) (*Defs, error) {
+	d := &Defs{values: make(map[string]string)}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			name, value = line[:i], strings.TrimSpace(line[i:])
+		}
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		d.values[name] = value
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ReadFile parses login.defs settings from the named file.
+func ReadFile(name string) (*Defs, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Get returns the value of the named setting and whether it is set.
+func (d *Defs) Get(name string) (value string, ok bool) {
+	value, ok = d.values[name]
+	return
+}
+
+// Bool reports whether the named setting is set to "yes", case-insensitively.
+func (d *Defs) Bool(name string) bool {
+	return strings.EqualFold(d.values[name], "yes")
+}
+
+// Int returns the integer value of the named setting
+// or -1 if it is not set.
+func (d *Defs) Int(name string) (int, error) {
+	value, ok := d.values[name]
+	if !ok {
+		return -1, nil
+	}
+	n, err := strconv.ParseInt(value, 0, 0)
+	if err != nil || n < 0 {
+		return 0, &InvalidValueError{Name: name, Value: value}
+	}
+	return int(n), nil
+}
+
+// Method returns the hashing method used for new passwords:
+// ENCRYPT_METHOD if set, otherwise MD5 if MD5_CRYPT_ENAB is enabled, otherwise DES.
+func (d *Defs) Method() string {
+	if method, ok := d.values["ENCRYPT_METHOD"]; ok && method != "" {
+		return method
+	}
+	if d.Bool("MD5_CRYPT_ENAB") {
+		return MethodMD5
+	}
+	return MethodDES
+}
+
+// Generator returns the hash of the password.
+type Generator func(password string) (string, error)
+
+const (
+	shaDefaultRounds    = sha512.ImplicitRounds
+	bcryptDefaultRounds = 13
+)
+
+// Generator returns a generator hashing passwords with Method and its settings:
+//
+//	SHA_CRYPT_MIN_ROUNDS, SHA_CRYPT_MAX_ROUNDS  for SHA256 and SHA512
+//	BCRYPT_MIN_ROUNDS, BCRYPT_MAX_ROUNDS        for BCRYPT
+//
+// As chpasswd(8) does, a round count is chosen randomly between the minimum and maximum
+// for every password, a single setting is used as both the minimum and maximum
+// and the round count is clamped to the algorithm limits.
+//
+// YESCRYPT and its YESCRYPT_COST_FACTOR setting are not supported.
+func (d *Defs) Generator() (Generator, error) {
+	switch method := d.Method(); method {
+	case MethodDES:
+		return func(password string) (string, error) {
+			// crypt(3) uses the first 8 characters of the password
+			if len(password) > des.MaxPasswordLength {
+				password = password[:des.MaxPasswordLength]
+			}
+			return des.Generate(password)
+		}, nil
+	case MethodMD5:
+		return func(password string) (string, error) {
+			return md5.Generate(password)
+		}, nil
+	case MethodSHA256, MethodSHA512:
+		minRounds, maxRounds, err := d.rounds("SHA_CRYPT_MIN_ROUNDS", "SHA_CRYPT_MAX_ROUNDS", shaDefaultRounds, sha512.MinRounds, sha512.MaxRounds)
+		if err != nil {
+			return nil, err
+		}
+		newHash := sha512.NewHash
+		if method == MethodSHA256 {
+			newHash = sha256.NewHash
+		}
+		return func(password string) (string, error) {
+			return newHash(password, uint32(randRange(minRounds, maxRounds)))
+		}, nil
+	case MethodBcrypt:
+		minRounds, maxRounds, err := d.rounds("BCRYPT_MIN_ROUNDS", "BCRYPT_MAX_ROUNDS", bcryptDefaultRounds, bcrypt.MinCost, bcrypt.MaxCost)
+		if err != nil {
+			return nil, err
+		}
+		return func(password string) (string, error) {
+			return bcrypt.NewHash(password, uint8(randRange(minRounds, maxRounds)))
+		}, nil
+	default:
+		return nil, UnsupportedMethodError(method)
+	}
+}
+
+// rounds returns the round count range of the minName and maxName settings
+// clamped to [lower, upper].
+func (d *Defs) rounds(minName, maxName string, def, lower, upper int) (minRounds, maxRounds int, err error) {
+	if minRounds, err = d.Int(minName); err != nil {
+		return
+	}
+	if maxRounds, err = d.Int(maxName); err != nil {
+		return
+	}
+	switch {
+	case minRounds == -1 && maxRounds == -1:
+		minRounds, maxRounds = def, def
+	case minRounds == -1:
+		minRounds = maxRounds
+	case maxRounds == -1:
+		maxRounds = minRounds
+	}
+	if minRounds > maxRounds {
+		maxRounds = minRounds
+	}
+	return clamp(minRounds, lower, upper), clamp(maxRounds, lower, upper), nil
+}
+
+func clamp(n, lower, upper int) int {
+	if n < lower {
+		return lower
+	}
+	if n > upper {
+		return upper
+	}
+	return n
+}
+
+func randRange(lower, upper int) int {
+	if lower == upper {
+		return lower
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(upper-lower+1)))
+	if err != nil {
+		panic(err)
+	}
+	return lower + int(n.Int64())
+}
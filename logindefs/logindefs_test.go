@@ -0,0 +1,214 @@
+package logindefs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/bcrypt"
+	_ "github.com/sergeymakinen/go-crypt/des"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+	_ "github.com/sergeymakinen/go-crypt/md5"
+	"github.com/sergeymakinen/go-crypt/sha256"
+	"github.com/sergeymakinen/go-crypt/sha512"
+)
+
+func TestReadFile(t *testing.T) {
+	d, err := ReadFile("testdata/login.defs")
+	if err != nil {
+		t.Fatalf("ReadFile() = _, %v; want nil", err)
+	}
+	expected := map[string]string{
+		"MAIL_DIR":             "/var/mail",
+		"FAILLOG_ENAB":         "yes",
+		"LOG_UNKFAIL_ENAB":     "no",
+		"ERASECHAR":            "0177",
+		"KILLCHAR":             "025",
+		"UMASK":                "022",
+		"ENV_SUPATH":           "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"TTYGROUP":             "tty",
+		"PASS_MAX_DAYS":        "99999",
+		"PASS_MIN_DAYS":        "0",
+		"PASS_WARN_AGE":        "7",
+		"ENCRYPT_METHOD":       "SHA512",
+		"SHA_CRYPT_MIN_ROUNDS": "5000",
+		"SHA_CRYPT_MAX_ROUNDS": "6000",
+	}
+	if diff := cmp.Diff(expected, d.values); diff != "" {
+		t.Errorf("ReadFile() mismatch (-want +got):\n%s", diff)
+	}
+	if !d.Bool("FAILLOG_ENAB") {
+		t.Errorf("Bool(%q) = false; want true", "FAILLOG_ENAB")
+	}
+	if n, err := d.Int("ERASECHAR"); err != nil || n != 0177 {
+		t.Errorf("Int(%q) = %d, %v; want %d, nil", "ERASECHAR", n, err, 0177)
+	}
+	if n, err := d.Int("BCRYPT_MIN_ROUNDS"); err != nil || n != -1 {
+		t.Errorf("Int(%q) = %d, %v; want -1, nil", "BCRYPT_MIN_ROUNDS", n, err)
+	}
+}
+
+func TestReadFileShouldFail(t *testing.T) {
+	if _, err := ReadFile("testdata/missing"); err == nil {
+		t.Error("ReadFile() = _, nil; want error")
+	}
+}
+
+func TestMethod(t *testing.T) {
+	tests := []struct {
+		defs   string
+		method string
+	}{
+		{
+			defs:   "ENCRYPT_METHOD BCRYPT\nMD5_CRYPT_ENAB yes\n",
+			method: MethodBcrypt,
+		},
+		{
+			defs:   "MD5_CRYPT_ENAB yes\n",
+			method: MethodMD5,
+		},
+		{
+			defs:   "MD5_CRYPT_ENAB no\n",
+			method: MethodDES,
+		},
+		{
+			defs:   "",
+			method: MethodDES,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.defs, func(t *testing.T) {
+			d, _ := Parse(strings.NewReader(test.defs))
+			if method := d.Method(); method != test.method {
+				t.Errorf("Method() = %q; want %q", method, test.method)
+			}
+		})
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	tests := []struct {
+		defs   string
+		prefix string
+		params func(hash string) (uint64, error)
+		min    uint64
+		max    uint64
+	}{
+		{
+			defs:   "ENCRYPT_METHOD DES\n",
+			prefix: "",
+		},
+		{
+			defs:   "MD5_CRYPT_ENAB yes\n",
+			prefix: "$1$",
+		},
+		{
+			defs:   "ENCRYPT_METHOD SHA256\nSHA_CRYPT_MIN_ROUNDS 6000\n",
+			prefix: sha256.Prefix,
+			params: func(hash string) (uint64, error) {
+				_, rounds, err := sha256.Params(hash)
+				return uint64(rounds), err
+			},
+			min: 6000,
+			max: 6000,
+		},
+		{
+			defs:   "ENCRYPT_METHOD SHA512\nSHA_CRYPT_MIN_ROUNDS 5000\nSHA_CRYPT_MAX_ROUNDS 5010\n",
+			prefix: sha512.Prefix,
+			params: func(hash string) (uint64, error) {
+				_, rounds, err := sha512.Params(hash)
+				return uint64(rounds), err
+			},
+			min: 5000,
+			max: 5010,
+		},
+		{
+			defs:   "ENCRYPT_METHOD SHA512\nSHA_CRYPT_MAX_ROUNDS 100\n",
+			prefix: sha512.Prefix,
+			params: func(hash string) (uint64, error) {
+				_, rounds, err := sha512.Params(hash)
+				return uint64(rounds), err
+			},
+			min: sha512.MinRounds,
+			max: sha512.MinRounds,
+		},
+		{
+			defs:   "ENCRYPT_METHOD SHA512\n",
+			prefix: sha512.Prefix,
+			params: func(hash string) (uint64, error) {
+				_, rounds, err := sha512.Params(hash)
+				return uint64(rounds), err
+			},
+			min: sha512.ImplicitRounds,
+			max: sha512.ImplicitRounds,
+		},
+		{
+			defs:   "ENCRYPT_METHOD BCRYPT\nBCRYPT_MIN_ROUNDS 4\nBCRYPT_MAX_ROUNDS 5\n",
+			prefix: bcrypt.Prefix2b,
+			params: func(hash string) (uint64, error) {
+				_, cost, _, err := bcrypt.Params(hash)
+				return uint64(cost), err
+			},
+			min: 4,
+			max: 5,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.defs, func(t *testing.T) {
+			d, _ := Parse(strings.NewReader(test.defs))
+			generate, err := d.Generator()
+			if err != nil {
+				t.Fatalf("Generator() = _, %v; want nil", err)
+			}
+			hash, err := generate("password")
+			if err != nil {
+				t.Fatalf("generate() = _, %v; want nil", err)
+			}
+			if !strings.HasPrefix(hash, test.prefix) {
+				t.Errorf("generate() = %q, _; want %q prefix", hash, test.prefix)
+			}
+			if err = crypt.Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if test.params == nil {
+				return
+			}
+			rounds, err := test.params(hash)
+			if err != nil {
+				t.Fatalf("Params() = %v; want nil", err)
+			}
+			if rounds < test.min || rounds > test.max {
+				t.Errorf("Params() = %d; want [%d, %d]", rounds, test.min, test.max)
+			}
+		})
+	}
+}
+
+func TestGeneratorShouldFail(t *testing.T) {
+	tests := []struct {
+		defs string
+		err  error
+	}{
+		{
+			defs: "ENCRYPT_METHOD YESCRYPT\nYESCRYPT_COST_FACTOR 5\n",
+			err:  UnsupportedMethodError(MethodYescrypt),
+		},
+		{
+			defs: "ENCRYPT_METHOD SHA512\nSHA_CRYPT_MIN_ROUNDS many\n",
+			err:  &InvalidValueError{Name: "SHA_CRYPT_MIN_ROUNDS", Value: "many"},
+		},
+		{
+			defs: "ENCRYPT_METHOD BCRYPT\nBCRYPT_MAX_ROUNDS -1\n",
+			err:  &InvalidValueError{Name: "BCRYPT_MAX_ROUNDS", Value: "-1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.defs, func(t *testing.T) {
+			d, _ := Parse(strings.NewReader(test.defs))
+			if _, err := d.Generator(); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Generator() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package macos_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/macos"
+)
+
+func ExampleCheck() {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>SALTED-SHA512-PBKDF2</key>
+	<dict>
+		<key>entropy</key>
+		<data>3h8jO9ZGMeq4XAZJY77hbJRfiANx0kUiG1MkwwiHZ4QCkuXIhbmnH40vUW7qSgW6drRX3QVXTp4j1gncKC9j1SOxkIkT0M3ytwK32s+m32DfZ51kJRzeyR7W2if/cF+N1ZcHtNYDfpfIgSwvPEG1hLadxFDhGoJNbjlQLhvVGrQ=</data>
+		<key>iterations</key>
+		<integer>1000</integer>
+		<key>salt</key>
+		<data>AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=</data>
+	</dict>
+</dict>
+</plist>`)
+	fmt.Println(macos.Check(data, "password"))
+	fmt.Println(macos.Check(data, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
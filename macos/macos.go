@@ -0,0 +1,313 @@
+// Package macos implements the password hashes of the ShadowHashData user attribute of macOS:
+// SALTED-SHA512-PBKDF2 and the legacy SALTED-SHA512 (Mac OS X 10.7).
+//
+// ShadowHashData is a property list, stored in the binary format by Directory Services
+// (see the hex-encoded output of "dscl . -read /Users/<user> ShadowHashData")
+// and in the XML format in exports.
+package macos
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+const (
+	AlgorithmSaltedSHA512PBKDF2 = "SALTED-SHA512-PBKDF2"
+	AlgorithmSaltedSHA512       = "SALTED-SHA512"
+)
+
+const (
+	SaltLength       = 32
+	LegacySaltLength = 4
+)
+
+// InvalidSaltLengthError values describe errors resulting from an invalid length of a salt.
+type InvalidSaltLengthError int
+
+func (e InvalidSaltLengthError) Error() string {
+	return "invalid salt length " + strconv.FormatInt(int64(e), 10)
+}
+
+const (
+	MinIterations     = 1
+	DefaultIterations = 45000
+)
+
+// InvalidIterationsError values describe errors resulting from an invalid iteration count.
+type InvalidIterationsError int
+
+func (e InvalidIterationsError) Error() string {
+	return "invalid iteration count " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidEntryError values describe errors resulting from an invalid ShadowHashData entry.
+type InvalidEntryError string
+
+func (e InvalidEntryError) Error() string {
+	return "invalid " + string(e) + " entry"
+}
+
+// ErrNoHash is returned when ShadowHashData contains neither a SALTED-SHA512-PBKDF2
+// nor a SALTED-SHA512 entry.
+var ErrNoHash = errors.New("no supported hash")
+
+const (
+	entropyLength = 128
+	digestLength  = sha512.Size
+)
+
+// PBKDF2 is a SALTED-SHA512-PBKDF2 entry.
+type PBKDF2 struct {
+	Entropy    []byte
+	Salt       []byte
+	Iterations int
+}
+
+// ShadowHashData is the ShadowHashData user attribute.
+// Entries of other algorithms, like SRP-RFC5054-4096-SHA512-PBKDF2, are preserved as is.
+type ShadowHashData struct {
+	PBKDF2 *PBKDF2
+
+	// SaltedSHA512 is the legacy SALTED-SHA512 entry:
+	// a 4-byte salt followed by the SHA-512 digest of the salt and password.
+	SaltedSHA512 []byte
+
+	other map[string]any
+}
+
+// Format is a property list format.
+type Format int
+
+const (
+	FormatBinary Format = iota
+	FormatXML
+)
+
+// Key returns a SALTED-SHA512-PBKDF2 entropy derived from the password, salt and iterations.
+func Key(password, salt []byte, iterations int) ([]byte, error) {
+	if n := len(salt); n != SaltLength {
+		return nil, InvalidSaltLengthError(n)
+	}
+	if iterations < MinIterations {
+		return nil, InvalidIterationsError(iterations)
+	}
+	return pbkdf2.Key(sha512.New, string(password), salt, iterations, entropyLength)
+}
+
+// LegacyKey returns a SALTED-SHA512 entry derived from the password and salt.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func LegacyKey(password, salt []byte) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	if n := len(salt); n != LegacySaltLength {
+		return nil, InvalidSaltLengthError(n)
+	}
+	h := sha512.New()
+	h.Write(salt)
+	h.Write(password)
+	return h.Sum(append([]byte(nil), salt...)), nil
+}
+
+// Parse parses the ShadowHashData property list in the binary or XML format.
+func Parse(b []byte) (*ShadowHashData, error) {
+	var (
+		v   any
+		err error
+	)
+	if bytes.HasPrefix(b, []byte(binaryMagic)) {
+		v, err = decodeBinary(b)
+	} else {
+		v, err = decodeXML(b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, &parse.SyntaxError{Offset: 0, Msg: "property list is not a dictionary"}
+	}
+	d := &ShadowHashData{}
+	for k, v := range m {
+		switch k {
+		case AlgorithmSaltedSHA512PBKDF2:
+			e, ok := v.(map[string]any)
+			if !ok {
+				return nil, InvalidEntryError(k)
+			}
+			entropy, ok1 := e["entropy"].([]byte)
+			salt, ok2 := e["salt"].([]byte)
+			iterations, ok3 := e["iterations"].(int64)
+			if !ok1 || !ok2 || !ok3 || int64(int(iterations)) != iterations {
+				return nil, InvalidEntryError(k)
+			}
+			d.PBKDF2 = &PBKDF2{
+				Entropy:    entropy,
+				Salt:       salt,
+				Iterations: int(iterations),
+			}
+		case AlgorithmSaltedSHA512:
+			if d.SaltedSHA512, ok = v.([]byte); !ok {
+				return nil, InvalidEntryError(k)
+			}
+		default:
+			if d.other == nil {
+				d.other = make(map[string]any)
+			}
+			d.other[k] = v
+		}
+	}
+	return d, nil
+}
+
+// Marshal returns the ShadowHashData property list in the given format.
+func (d *ShadowHashData) Marshal(format Format) []byte {
+	m := make(map[string]any, len(d.other)+2)
+	for k, v := range d.other {
+		m[k] = v
+	}
+	if d.PBKDF2 != nil {
+		m[AlgorithmSaltedSHA512PBKDF2] = map[string]any{
+			"entropy":    d.PBKDF2.Entropy,
+			"iterations": int64(d.PBKDF2.Iterations),
+			"salt":       d.PBKDF2.Salt,
+		}
+	}
+	if d.SaltedSHA512 != nil {
+		m[AlgorithmSaltedSHA512] = d.SaltedSHA512
+	}
+	if format == FormatXML {
+		return encodeXML(m)
+	}
+	return encodeBinary(m)
+}
+
+// New returns ShadowHashData with the SALTED-SHA512-PBKDF2 entry of the password and iterations.
+func New(password string, iterations int) (*ShadowHashData, error) {
+	var d *ShadowHashData
+	_, err := crypt.ObserveNewHash("macos", func() (string, error) {
+		var err error
+		d, err = newData(password, iterations)
+		return "", err
+	}, func(string) []slog.Attr {
+		return d.hookParams()
+	})
+	return d, err
+}
+
+func newData(password string, iterations int) (*ShadowHashData, error) {
+	salt := cryptoutil.Rand(SaltLength)
+	entropy, err := Key([]byte(password), salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	return &ShadowHashData{
+		PBKDF2: &PBKDF2{
+			Entropy:    entropy,
+			Salt:       salt,
+			Iterations: iterations,
+		},
+	}, nil
+}
+
+// NewHash returns the ShadowHashData property list in the given format
+// with the SALTED-SHA512-PBKDF2 entry of the password and iterations.
+func NewHash(password string, iterations int, format Format) ([]byte, error) {
+	d, err := New(password, iterations)
+	if err != nil {
+		return nil, err
+	}
+	return d.Marshal(format), nil
+}
+
+// hookParams returns the algorithm and iteration count of the preferred entry for crypt.Hook.
+func (d *ShadowHashData) hookParams() []slog.Attr {
+	switch {
+	case d.PBKDF2 != nil:
+		return []slog.Attr{
+			slog.String("algorithm", AlgorithmSaltedSHA512PBKDF2),
+			slog.Int("iterations", d.PBKDF2.Iterations),
+		}
+	case d.SaltedSHA512 != nil:
+		return []slog.Attr{slog.String("algorithm", AlgorithmSaltedSHA512)}
+	}
+	return nil
+}
+
+// Params returns the hashing salt and iterations used to create
+// the SALTED-SHA512-PBKDF2 entry of the given ShadowHashData property list.
+func Params(b []byte) (salt []byte, iterations int, err error) {
+	d, err := Parse(b)
+	if err != nil {
+		return
+	}
+	if d.PBKDF2 == nil {
+		return nil, 0, ErrNoHash
+	}
+	return d.PBKDF2.Salt, d.PBKDF2.Iterations, nil
+}
+
+// Check compares the ShadowHashData with a new hash derived from the password.
+// The SALTED-SHA512-PBKDF2 entry is preferred over the SALTED-SHA512 one.
+// Returns nil on success, or an error on failure.
+func (d *ShadowHashData) Check(password string) error {
+	return crypt.ObserveCheck("macos", "", password, func(_, password string) error {
+		return d.check(password)
+	}, func(string) []slog.Attr {
+		return d.hookParams()
+	})
+}
+
+func (d *ShadowHashData) check(password string) error {
+	var key, sum []byte
+	switch {
+	case d.PBKDF2 != nil:
+		if len(d.PBKDF2.Entropy) != entropyLength {
+			return InvalidEntryError(AlgorithmSaltedSHA512PBKDF2)
+		}
+		var err error
+		if key, err = Key([]byte(password), d.PBKDF2.Salt, d.PBKDF2.Iterations); err != nil {
+			return err
+		}
+		sum = d.PBKDF2.Entropy
+	case d.SaltedSHA512 != nil:
+		if len(d.SaltedSHA512) != LegacySaltLength+digestLength {
+			return InvalidEntryError(AlgorithmSaltedSHA512)
+		}
+		var err error
+		if key, err = LegacyKey([]byte(password), d.SaltedSHA512[:LegacySaltLength]); err != nil {
+			return err
+		}
+		sum = d.SaltedSHA512
+	default:
+		return ErrNoHash
+	}
+	if subtle.ConstantTimeCompare(key, sum) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+// Check compares the given ShadowHashData property list with a new hash derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(b []byte, password string) error {
+	var d *ShadowHashData
+	return crypt.ObserveCheck("macos", "", password, func(_, password string) error {
+		var err error
+		if d, err = Parse(b); err != nil {
+			return err
+		}
+		return d.check(password)
+	}, func(string) []slog.Attr {
+		return d.hookParams()
+	})
+}
@@ -0,0 +1,279 @@
+package macos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+// Generated with Python plistlib, with all of SALTED-SHA512-PBKDF2 (password "password", 1000 iterations),
+// SALTED-SHA512 (password "password") and SRP-RFC5054-4096-SHA512-PBKDF2 entries.
+const testBinary = "62706c6973743030d301020304050c5d53414c5445442d5348413531325f101453414c5445442d5348413531322d50424b4446325f101e5352502d524643353035342d343039362d5348413531322d50424b4446324f104401020304ba2e3a16bfdc0bee887e394e382ae19220d8cd8ae400af470f820a8235b5ef73a6c9f27f99df01d98d56deb52168cf6cc2b00f730327c0524408ce058e3afaedd3060708090a0b57656e74726f70795a697465726174696f6e735473616c744f1080de1f233bd64631eab85c064963bee16c945f880371d245221b5324c3088767840292e5c885b9a71f8d2f516eea4a05ba76b457dd05574e9e23d609dc282f63d523b1908913d0cdf2b702b7dacfa6df60df679d64251cdec91ed6da27ff705f8dd59707b4d6037e97c8812c2f3c41b584b69dc450e11a824d6e39502e1bd51ab41103e84f1020000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1fd307080d0a0b0e5876657269666965724800000000000000000008000f001d00340055009c00a300ab00b600bb013e01410164016b01740000000000000201000000000000000f0000000000000000000000000000017d"
+
+const testXML = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>SALTED-SHA512-PBKDF2</key>
+	<dict>
+		<key>entropy</key>
+		<data>
+		3h8jO9ZGMeq4XAZJY77hbJRfiANx0kUiG1MkwwiHZ4QCkuXIhbmnH40vUW7q
+		SgW6drRX3QVXTp4j1gncKC9j1SOxkIkT0M3ytwK32s+m32DfZ51kJRzeyR7W
+		2if/cF+N1ZcHtNYDfpfIgSwvPEG1hLadxFDhGoJNbjlQLhvVGrQ=
+		</data>
+		<key>iterations</key>
+		<integer>1000</integer>
+		<key>salt</key>
+		<data>
+		AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=
+		</data>
+	</dict>
+</dict>
+</plist>`
+
+var testSalt = []byte{
+	0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+	0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+}
+
+func testBinaryData(t *testing.T) []byte {
+	b, err := hex.DecodeString(testBinary)
+	if err != nil {
+		t.Fatalf("hex.DecodeString() = _, %v; want nil", err)
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{"binary", testBinaryData(t)},
+		{"xml", []byte(testXML)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			salt, iterations, err := Params(test.b)
+			if err != nil {
+				t.Fatalf("Params() = _, _, %v; want nil", err)
+			}
+			if !bytes.Equal(salt, testSalt) || iterations != 1000 {
+				t.Errorf("Params() = %v, %d, _; want %v, 1000", salt, iterations, testSalt)
+			}
+			if err := Check(test.b, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(test.b, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestCheckLegacy(t *testing.T) {
+	d, err := Parse(testBinaryData(t))
+	if err != nil {
+		t.Fatalf("Parse() = _, %v; want nil", err)
+	}
+	d.PBKDF2 = nil
+	if err := d.Check("password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err := d.Check("test"); err != crypt.ErrPasswordMismatch {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+	d.SaltedSHA512 = nil
+	if err := d.Check("password"); err != ErrNoHash {
+		t.Errorf("Check() = %v; want %v", err, ErrNoHash)
+	}
+}
+
+func TestParseShouldFail(t *testing.T) {
+	b := testBinaryData(t)
+	tests := []struct {
+		name string
+		b    []byte
+		err  error
+	}{
+		{
+			name: "truncated binary",
+			b:    b[:len(b)-1],
+			err:  &parse.SyntaxError{Offset: len(b) - 33, Msg: "invalid binary property list trailer"},
+		},
+		{
+			name: "xml without plist",
+			b:    []byte("<dict></dict>"),
+			err:  &parse.SyntaxError{Offset: 6, Msg: "missing plist element"},
+		},
+		{
+			name: "invalid entry",
+			b:    []byte("<plist><dict><key>SALTED-SHA512-PBKDF2</key><string>x</string></dict></plist>"),
+			err:  InvalidEntryError(AlgorithmSaltedSHA512PBKDF2),
+		},
+		{
+			name: "invalid data",
+			b:    []byte("<plist><dict><key>SALTED-SHA512</key><data>@</data></dict></plist>"),
+			err:  &parse.SyntaxError{Offset: 51, Msg: "invalid base64 data"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Parse(test.b); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Parse() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParseSharedReferences(t *testing.T) {
+	// The "shared" entry is a chain of 29 arrays, each referencing the next one twice
+	objects := [][]byte{{0xd1, 1, 2}, append([]byte{0x56}, "shared"...)}
+	for i := 2; i < 31; i++ {
+		objects = append(objects, []byte{0xa2, byte(i + 1), byte(i + 1)})
+	}
+	objects = append(objects, []byte{0xa0})
+	b := []byte(binaryMagic)
+	var offsets []byte
+	for _, o := range objects {
+		offsets = append(offsets, byte(len(b)))
+		b = append(b, o...)
+	}
+	tableOffset := len(b)
+	b = append(b, offsets...)
+	b = append(b, 0, 0, 0, 0, 0, 0, 1, 1)
+	b = binary.BigEndian.AppendUint64(b, uint64(len(objects)))
+	b = binary.BigEndian.AppendUint64(b, 0)
+	b = binary.BigEndian.AppendUint64(b, uint64(tableOffset))
+	d, err := Parse(b)
+	if err != nil {
+		t.Fatalf("Parse() = _, %v; want nil", err)
+	}
+	v := d.other["shared"]
+	for i := 2; i < 31; i++ {
+		a, ok := v.([]any)
+		if !ok || len(a) != 2 {
+			t.Fatalf("Parse() = %v; want 2 references", v)
+		}
+		v = a[0]
+	}
+}
+
+func TestMarshalManyObjects(t *testing.T) {
+	var xml strings.Builder
+	xml.WriteString("<plist><dict><key>other</key><array>")
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&xml, "<integer>%d</integer>", i)
+	}
+	xml.WriteString("</array></dict></plist>")
+	d, err := Parse([]byte(xml.String()))
+	if err != nil {
+		t.Fatalf("Parse() = _, %v; want nil", err)
+	}
+	b := d.Marshal(FormatBinary)
+	d2, err := Parse(b)
+	if err != nil {
+		t.Fatalf("Parse(Marshal(%d)) = _, %v; want nil", FormatBinary, err)
+	}
+	if !slices.Equal(d2.other["other"].([]any), d.other["other"].([]any)) {
+		t.Errorf("Parse(Marshal(%d)) doesn't round-trip", FormatBinary)
+	}
+	if refSize := b[len(b)-trailerLength+7]; refSize != 2 {
+		t.Errorf("Marshal(%d) reference size = %d; want 2", FormatBinary, refSize)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	d, err := Parse(testBinaryData(t))
+	if err != nil {
+		t.Fatalf("Parse() = _, %v; want nil", err)
+	}
+	for _, format := range []Format{FormatBinary, FormatXML} {
+		d2, err := Parse(d.Marshal(format))
+		if err != nil {
+			t.Fatalf("Parse(Marshal(%d)) = _, %v; want nil", format, err)
+		}
+		if !bytes.Equal(d2.Marshal(FormatBinary), d.Marshal(FormatBinary)) {
+			t.Errorf("Parse(Marshal(%d)) doesn't round-trip", format)
+		}
+	}
+	d.SaltedSHA512 = nil
+	d.other = nil
+	if s := string(d.Marshal(FormatXML)); s != testXML+"\n" {
+		t.Errorf("Marshal(FormatXML) = %q; want %q", s, testXML+"\n")
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	b, err := NewHash("password", 1000, FormatBinary)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err := Check(b, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if _, err := NewHash("password", 0, FormatBinary); err != InvalidIterationsError(0) {
+		t.Errorf("NewHash() = _, %v; want %v", err, InvalidIterationsError(0))
+	}
+}
+
+func TestHook(t *testing.T) {
+	var events []crypt.Event
+	crypt.SetHook(crypt.HookFunc(func(e crypt.Event) {
+		events = append(events, e)
+	}))
+	defer crypt.SetHook(nil)
+	if err := Check(testBinaryData(t), "test"); err != crypt.ErrPasswordMismatch {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+	if err := Check([]byte("bplist00"), "password"); err == nil {
+		t.Error("Check() = nil; want error")
+	}
+	tests := []struct {
+		outcome crypt.Outcome
+		params  string
+	}{
+		{outcome: crypt.OutcomeMismatch, params: "[algorithm=SALTED-SHA512-PBKDF2 iterations=1000]"},
+		{outcome: crypt.OutcomeMalformed, params: "[]"},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("got %d events; want %d", len(events), len(tests))
+	}
+	for i, test := range tests {
+		e := events[i]
+		if e.Op != crypt.OpCheck || e.Algorithm != "macos" || e.Outcome != test.outcome {
+			t.Errorf("events[%d] = %v %q %v; want %v %q %v", i, e.Op, e.Algorithm, e.Outcome, crypt.OpCheck, "macos", test.outcome)
+		}
+		if params := fmt.Sprint(e.Params); params != test.params {
+			t.Errorf("events[%d].Params = %s; want %s", i, params, test.params)
+		}
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	d, err := Parse(testBinaryData(t))
+	if err != nil {
+		t.Fatalf("Parse() = _, %v; want nil", err)
+	}
+	if err := d.Check("password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	d.PBKDF2 = nil
+	if err := d.Check("password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
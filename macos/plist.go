@@ -0,0 +1,518 @@
+package macos
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+)
+
+// This file implements the subset of the property list formats used by ShadowHashData:
+// dictionaries, arrays, data, integers, strings and booleans.
+// Decoded values are of the map[string]any, []any, []byte, int64, string and bool types.
+
+const (
+	binaryMagic   = "bplist00"
+	trailerLength = 32
+)
+
+type binaryDecoder struct {
+	b          []byte
+	offsets    []uint64
+	refSize    int
+	depth      int
+	numObjects uint64
+
+	// objects are the decoded objects by reference, so objects referenced
+	// more than once, maliciously or not, are decoded only once
+	objects map[uint64]any
+}
+
+func errBinary(offset int, msg string) error {
+	return &parse.SyntaxError{Offset: offset, Msg: msg}
+}
+
+func readUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+func decodeBinary(b []byte) (any, error) {
+	if len(b) < len(binaryMagic)+trailerLength || string(b[:len(binaryMagic)]) != binaryMagic {
+		return nil, errBinary(0, "invalid binary property list header")
+	}
+	trailer := b[len(b)-trailerLength:]
+	offsetSize, refSize := int(trailer[6]), int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:])
+	top := binary.BigEndian.Uint64(trailer[16:])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:])
+	end := uint64(len(b) - trailerLength)
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 ||
+		top >= numObjects || tableOffset > end || numObjects > (end-tableOffset)/uint64(offsetSize) {
+		return nil, errBinary(len(b)-trailerLength, "invalid binary property list trailer")
+	}
+	d := &binaryDecoder{
+		b:          b[:end],
+		offsets:    make([]uint64, numObjects),
+		refSize:    refSize,
+		numObjects: numObjects,
+		objects:    make(map[uint64]any),
+	}
+	for i := range d.offsets {
+		o := int(tableOffset) + i*offsetSize
+		d.offsets[i] = readUint(b[o : o+offsetSize])
+	}
+	return d.object(top)
+}
+
+const maxDepth = 32
+
+func (d *binaryDecoder) length(offset int, marker byte) (n, start int, err error) {
+	if n = int(marker & 0x0f); n != 0x0f {
+		return n, offset + 1, nil
+	}
+	// The length is the following integer object
+	if offset+1 >= len(d.b) || d.b[offset+1]&0xf0 != 0x10 {
+		return 0, 0, errBinary(offset+1, "invalid length")
+	}
+	size := 1 << (d.b[offset+1] & 0x0f)
+	if size > 8 || offset+2+size > len(d.b) {
+		return 0, 0, errBinary(offset+1, "invalid length")
+	}
+	l := readUint(d.b[offset+2 : offset+2+size])
+	if l > uint64(len(d.b)) {
+		return 0, 0, errBinary(offset+1, "invalid length")
+	}
+	return int(l), offset + 2 + size, nil
+}
+
+func (d *binaryDecoder) refs(start, n int) ([]uint64, error) {
+	if start+n*d.refSize > len(d.b) {
+		return nil, errBinary(start, "unexpected end of object")
+	}
+	refs := make([]uint64, n)
+	for i := range refs {
+		o := start + i*d.refSize
+		refs[i] = readUint(d.b[o : o+d.refSize])
+	}
+	return refs, nil
+}
+
+func (d *binaryDecoder) object(ref uint64) (any, error) {
+	if v, ok := d.objects[ref]; ok {
+		return v, nil
+	}
+	v, err := d.decodeObject(ref)
+	if err != nil {
+		return nil, err
+	}
+	d.objects[ref] = v
+	return v, nil
+}
+
+func (d *binaryDecoder) decodeObject(ref uint64) (any, error) {
+	if ref >= d.numObjects {
+		return nil, errBinary(0, "invalid object reference")
+	}
+	offset := int(min(d.offsets[ref], uint64(len(d.b))))
+	if offset < len(binaryMagic) || offset >= len(d.b) {
+		return nil, errBinary(offset, "invalid object offset")
+	}
+	if d.depth++; d.depth > maxDepth {
+		return nil, errBinary(offset, "too deeply nested")
+	}
+	defer func() { d.depth-- }()
+	marker := d.b[offset]
+	switch marker >> 4 {
+	case 0x0:
+		switch marker {
+		case 0x08:
+			return false, nil
+		case 0x09:
+			return true, nil
+		}
+	case 0x1:
+		size := 1 << (marker & 0x0f)
+		if size > 8 || offset+1+size > len(d.b) {
+			break
+		}
+		return int64(readUint(d.b[offset+1 : offset+1+size])), nil
+	case 0x4, 0x5, 0x6:
+		n, start, err := d.length(offset, marker)
+		if err != nil {
+			return nil, err
+		}
+		if marker>>4 == 0x6 {
+			n *= 2
+		}
+		if start+n > len(d.b) {
+			return nil, errBinary(start, "unexpected end of object")
+		}
+		v := d.b[start : start+n]
+		switch marker >> 4 {
+		case 0x4:
+			return slices.Clone(v), nil
+		case 0x5:
+			return string(v), nil
+		default:
+			u := make([]uint16, n/2)
+			for i := range u {
+				u[i] = binary.BigEndian.Uint16(v[i*2:])
+			}
+			return string(utf16.Decode(u)), nil
+		}
+	case 0xa:
+		n, start, err := d.length(offset, marker)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := d.refs(start, n)
+		if err != nil {
+			return nil, err
+		}
+		a := make([]any, n)
+		for i, ref := range refs {
+			if a[i], err = d.object(ref); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case 0xd:
+		n, start, err := d.length(offset, marker)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := d.refs(start, 2*n)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]any, n)
+		for i := 0; i < n; i++ {
+			k, err := d.object(refs[i])
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, errBinary(offset, "invalid dictionary key")
+			}
+			if m[key], err = d.object(refs[n+i]); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return nil, errBinary(offset, "unsupported object 0x"+strconv.FormatUint(uint64(marker), 16))
+}
+
+// container is an array or dictionary object: its marker followed by the object references.
+type container struct {
+	marker []byte
+	refs   []uint64
+}
+
+type binaryEncoder struct {
+	objects    [][]byte
+	containers map[uint64]container
+}
+
+// uintSize returns the number of bytes needed to encode n.
+func uintSize(n uint64) int {
+	size := 1
+	for n>>(8*size) != 0 {
+		size++
+	}
+	return size
+}
+
+func appendUint(b []byte, n uint64, size int) []byte {
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(n>>(8*i)))
+	}
+	return b
+}
+
+func (e *binaryEncoder) add(b []byte) uint64 {
+	e.objects = append(e.objects, b)
+	return uint64(len(e.objects) - 1)
+}
+
+func appendMarker(b []byte, kind byte, n int) []byte {
+	if n < 0x0f {
+		return append(b, kind<<4|byte(n))
+	}
+	return appendInt(append(b, kind<<4|0x0f), int64(n))
+}
+
+func appendInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= math.MaxUint8:
+		return append(b, 0x10, byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0x11), uint16(n))
+	case n >= 0 && n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0x12), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0x13), uint64(n))
+	}
+}
+
+// encode adds v and its children to the object table and returns the reference of v.
+// Arrays and dictionaries are added as containers, as the size of the references
+// is only known when all objects are added.
+func (e *binaryEncoder) encode(v any) uint64 {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return e.add([]byte{0x09})
+		}
+		return e.add([]byte{0x08})
+	case int64:
+		return e.add(appendInt(nil, v))
+	case []byte:
+		return e.add(append(appendMarker(nil, 0x4, len(v)), v...))
+	case string:
+		return e.add(append(appendMarker(nil, 0x5, len(v)), v...))
+	case []any:
+		i := e.add(nil)
+		c := container{marker: appendMarker(nil, 0xa, len(v)), refs: make([]uint64, len(v))}
+		for j, item := range v {
+			c.refs[j] = e.encode(item)
+		}
+		e.containers[i] = c
+		return i
+	case map[string]any:
+		keys := sortedKeys(v)
+		i := e.add(nil)
+		c := container{marker: appendMarker(nil, 0xd, len(keys)), refs: make([]uint64, 2*len(keys))}
+		for j, k := range keys {
+			c.refs[j] = e.encode(k)
+			c.refs[len(keys)+j] = e.encode(v[k])
+		}
+		e.containers[i] = c
+		return i
+	default:
+		panic("macos: unsupported property list value")
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func encodeBinary(v any) []byte {
+	e := binaryEncoder{containers: make(map[uint64]container)}
+	e.encode(v)
+	refSize := uintSize(uint64(len(e.objects) - 1))
+	b := []byte(binaryMagic)
+	offsets := make([]uint64, len(e.objects))
+	for i, o := range e.objects {
+		offsets[i] = uint64(len(b))
+		if c, ok := e.containers[uint64(i)]; ok {
+			b = append(b, c.marker...)
+			for _, ref := range c.refs {
+				b = appendUint(b, ref, refSize)
+			}
+			continue
+		}
+		b = append(b, o...)
+	}
+	tableOffset := uint64(len(b))
+	offsetSize := uintSize(tableOffset)
+	for _, o := range offsets {
+		b = appendUint(b, o, offsetSize)
+	}
+	b = append(b, 0, 0, 0, 0, 0, 0, byte(offsetSize), byte(refSize))
+	b = binary.BigEndian.AppendUint64(b, uint64(len(e.objects)))
+	b = binary.BigEndian.AppendUint64(b, 0)
+	return binary.BigEndian.AppendUint64(b, tableOffset)
+}
+
+func decodeXML(b []byte) (any, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, errXML(d, "missing plist element")
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			if se.Name.Local != "plist" {
+				return nil, errXML(d, "missing plist element")
+			}
+			return decodeXMLValue(d, 0)
+		}
+	}
+}
+
+func errXML(d *xml.Decoder, msg string) error {
+	return &parse.SyntaxError{Offset: int(d.InputOffset()), Msg: msg}
+}
+
+func nextElement(d *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, errXML(d, "unexpected end of property list")
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			return &tok, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+func decodeXMLValue(d *xml.Decoder, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errXML(d, "too deeply nested")
+	}
+	se, err := nextElement(d)
+	if err != nil {
+		return nil, err
+	}
+	if se == nil {
+		return nil, errXML(d, "missing value")
+	}
+	return decodeXMLElement(d, se, depth)
+}
+
+func decodeXMLElement(d *xml.Decoder, se *xml.StartElement, depth int) (any, error) {
+	switch se.Name.Local {
+	case "dict":
+		m := make(map[string]any)
+		for {
+			ke, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if ke == nil {
+				return m, nil
+			}
+			var key string
+			if ke.Name.Local != "key" || d.DecodeElement(&key, ke) != nil {
+				return nil, errXML(d, "invalid dictionary key")
+			}
+			if m[key], err = decodeXMLValue(d, depth+1); err != nil {
+				return nil, err
+			}
+		}
+	case "array":
+		a := []any{}
+		for {
+			ie, err := nextElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if ie == nil {
+				return a, nil
+			}
+			v, err := decodeXMLElement(d, ie, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	case "true", "false":
+		if err := d.Skip(); err != nil {
+			return nil, errXML(d, "unexpected end of property list")
+		}
+		return se.Name.Local == "true", nil
+	}
+	var s string
+	if err := d.DecodeElement(&s, se); err != nil {
+		return nil, errXML(d, "unexpected end of property list")
+	}
+	switch se.Name.Local {
+	case "data":
+		b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+		if err != nil {
+			return nil, errXML(d, "invalid base64 data")
+		}
+		return b, nil
+	case "integer":
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, errXML(d, "invalid integer")
+		}
+		return n, nil
+	case "string":
+		return s, nil
+	default:
+		return nil, errXML(d, "unsupported element "+strconv.Quote(se.Name.Local))
+	}
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+
+func encodeXML(v any) []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	encodeXMLValue(&b, v, 0)
+	b.WriteString("</plist>\n")
+	return b.Bytes()
+}
+
+func encodeXMLValue(b *bytes.Buffer, v any, depth int) {
+	indent := strings.Repeat("\t", depth)
+	switch v := v.(type) {
+	case bool:
+		if v {
+			b.WriteString(indent + "<true/>\n")
+		} else {
+			b.WriteString(indent + "<false/>\n")
+		}
+	case int64:
+		b.WriteString(indent + "<integer>" + strconv.FormatInt(v, 10) + "</integer>\n")
+	case []byte:
+		b.WriteString(indent + "<data>\n")
+		s := base64.StdEncoding.EncodeToString(v)
+		// Like Apple's and Python's writers, wrap at 76 columns, counting tabs as 8 columns
+		width := max(76-8*depth, 16) / 4 * 4
+		for len(s) > 0 {
+			n := min(len(s), width)
+			b.WriteString(indent + s[:n] + "\n")
+			s = s[n:]
+		}
+		b.WriteString(indent + "</data>\n")
+	case string:
+		b.WriteString(indent + "<string>")
+		xml.EscapeText(b, []byte(v))
+		b.WriteString("</string>\n")
+	case []any:
+		b.WriteString(indent + "<array>\n")
+		for _, item := range v {
+			encodeXMLValue(b, item, depth+1)
+		}
+		b.WriteString(indent + "</array>\n")
+	case map[string]any:
+		b.WriteString(indent + "<dict>\n")
+		for _, k := range sortedKeys(v) {
+			b.WriteString(indent + "\t<key>")
+			xml.EscapeText(b, []byte(k))
+			b.WriteString("</key>\n")
+			encodeXMLValue(b, v[k], depth+1)
+		}
+		b.WriteString(indent + "</dict>\n")
+	default:
+		panic("macos: unsupported property list value")
+	}
+}
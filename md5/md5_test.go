@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"github.com/sergeymakinen/go-crypt/internal/testutil"
 )
 
@@ -163,3 +165,98 @@ func TestNewHash(t *testing.T) {
 		t.Errorf("crypthash.Unmarshal() mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestGenerate(t *testing.T) {
+	hash, err := Generate("password")
+	if err != nil {
+		t.Fatalf("Generate() = _, %v; want nil", err)
+	}
+	if err := Check(hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	var schema scheme
+	if err := crypthash.Unmarshal(hash, &schema); err != nil {
+		t.Fatalf("crypthash.Unmarshal() = %v; want nil", err)
+	}
+	if diff := cmp.Diff(scheme{HashPrefix: Prefix}, schema, cmp.Comparer(func(x, y scheme) bool {
+		return x.HashPrefix == y.HashPrefix
+	})); diff != "" {
+		t.Errorf("crypthash.Unmarshal() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCrypt(t *testing.T) {
+	tests := []struct {
+		setting string
+		hash    string
+	}{
+		{
+			setting: "$1$k2XAnEHB",
+			hash:    "$1$k2XAnEHB$Dg0QXq3PU0O1FK/yKU9D00",
+		},
+		{
+			setting: "$1$k2XAnEHB$",
+			hash:    "$1$k2XAnEHB$Dg0QXq3PU0O1FK/yKU9D00",
+		},
+		{
+			setting: "$1$k2XAnEHB$Dg0QXq3PU0O1FK/yKU9D00",
+			hash:    "$1$k2XAnEHB$Dg0QXq3PU0O1FK/yKU9D00",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			hash, err := crypt.Crypt("password", test.setting)
+			if err != nil {
+				t.Fatalf("Crypt() = _, %v; want nil", err)
+			}
+			if hash != test.hash {
+				t.Errorf("Crypt() = %q, _; want %q", hash, test.hash)
+			}
+		})
+	}
+}
+
+func TestGenSalt(t *testing.T) {
+	tests := []struct {
+		count   uint64
+		setting string
+	}{
+		{
+			count:   0,
+			setting: "$1$k2XAnEHB",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			setting, err := crypt.GenSalt(Prefix, test.count, []byte("0123456789abcdef"))
+			if err != nil {
+				t.Fatalf("GenSalt() = _, %v; want nil", err)
+			}
+			if setting != test.setting {
+				t.Errorf("GenSalt() = %q, _; want %q", setting, test.setting)
+			}
+			if status := crypt.CheckSalt(setting); status != crypt.SaltMethodLegacy {
+				t.Errorf("CheckSalt() = %v; want %v", status, crypt.SaltMethodLegacy)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	if err := Check("$1$saltsalt$qjXMvbEw8oaL.CzflDtaK/", "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := crypt.Crypt("password", "$1$saltsalt$qjXMvbEw8oaL.CzflDtaK/"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Crypt() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := Generate("password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Generate() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := Salt(NewHash("password")); err != nil {
+		t.Errorf("Salt() = _, %v; want nil", err)
+	}
+}
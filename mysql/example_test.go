@@ -0,0 +1,52 @@
+package mysql_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/mysql"
+)
+
+func ExampleParams() {
+	salt, rounds, _ := mysql.Params("$A$005$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3")
+	fmt.Println(string(salt))
+	fmt.Println(rounds)
+	// Output:
+	// aaaaaaaaaaaaaaaaaaaa
+	// 5000
+}
+
+func ExampleKey() {
+	salt, rounds, _ := mysql.Params("$A$005$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3")
+	fmt.Println(string(salt))
+	fmt.Println(rounds)
+
+	key, _ := mysql.Key([]byte("password"), salt, rounds)
+	fmt.Println(hash.LittleEndianEncoding.EncodeToString(key))
+	// Output:
+	// aaaaaaaaaaaaaaaaaaaa
+	// 5000
+	// AMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3
+}
+
+func ExampleNewNativeHash() {
+	hash, _ := mysql.NewNativeHash("password")
+	fmt.Println(hash)
+	// Output:
+	// *2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19
+}
+
+func ExampleCheck() {
+	for _, hash := range []string{
+		"*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19",
+		"$A$005$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3",
+	} {
+		fmt.Println(mysql.Check(hash, "password"))
+		fmt.Println(mysql.Check(hash, "test"))
+	}
+	// Output:
+	// <nil>
+	// hash and password mismatch
+	// <nil>
+	// hash and password mismatch
+}
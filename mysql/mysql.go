@@ -0,0 +1,285 @@
+// Package mysql implements the MySQL mysql_native_password and caching_sha2_password
+// hashing algorithms.
+package mysql
+
+import (
+	"crypto"
+	"crypto/sha1"
+	_ "crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/hashutil"
+	"github.com/sergeymakinen/go-crypt/sha256/sha2crypt"
+)
+
+const MaxPasswordLength = 256
+
+// InvalidPasswordLengthError values describe errors resulting from an invalid length of a password.
+type InvalidPasswordLengthError int
+
+func (e InvalidPasswordLengthError) Error() string {
+	return "invalid password length " + strconv.FormatInt(int64(e), 10)
+}
+
+const SaltLength = 20
+
+// InvalidSaltLengthError values describe errors resulting from an invalid length of a salt.
+type InvalidSaltLengthError int
+
+func (e InvalidSaltLengthError) Error() string {
+	return "invalid salt length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidSaltError values describe errors resulting from an invalid character in a hash string.
+type InvalidSaltError byte
+
+func (e InvalidSaltError) Error() string {
+	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in salt"
+}
+
+const (
+	MinRounds     = 5000
+	MaxRounds     = 0xFFF * roundsMultiplier
+	DefaultRounds = MinRounds
+)
+
+const roundsMultiplier = 1000
+
+// InvalidRoundsError values describe errors resulting from an invalid round count.
+type InvalidRoundsError uint32
+
+func (e InvalidRoundsError) Error() string {
+	return "invalid round count " + strconv.FormatUint(uint64(e), 10)
+}
+
+const (
+	PrefixNative      = "*"   // mysql_native_password
+	PrefixCachingSHA2 = "$A$" // caching_sha2_password
+)
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+// InvalidHashLengthError values describe errors resulting from an invalid length of a hash string.
+type InvalidHashLengthError int
+
+func (e InvalidHashLengthError) Error() string {
+	return "invalid hash length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidHashError values describe errors resulting from an invalid character in a hash string.
+type InvalidHashError byte
+
+func (e InvalidHashError) Error() string {
+	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in hash"
+}
+
+// NativeKey returns a mysql_native_password key derived from the password.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func NativeKey(password []byte) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	b := sha1.Sum(password)
+	b = sha1.Sum(b[:])
+	return b[:], nil
+}
+
+// Key returns a caching_sha2_password key derived from the password, salt and rounds.
+//
+// Unlike the crypt(3) SHA-256 salt, the salt may contain any 7-bit character except NUL and '$'.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	if n := len(password); n > MaxPasswordLength {
+		return nil, InvalidPasswordLengthError(n)
+	}
+	if n := len(salt); n != SaltLength {
+		return nil, InvalidSaltLengthError(n)
+	}
+	for _, c := range salt {
+		if c == 0 || c == '$' || c >= 0x80 {
+			return nil, InvalidSaltError(c)
+		}
+	}
+	if rounds < MinRounds || rounds > MaxRounds || rounds%roundsMultiplier != 0 {
+		return nil, InvalidRoundsError(rounds)
+	}
+	return sha2crypt.Encrypt(crypto.SHA256, password, salt, rounds, sha2crypt.PermFinalSHA256[:])
+}
+
+const (
+	nativeSumLength = 40
+	sumLength       = 43
+	roundsLength    = 3
+)
+
+type scheme struct {
+	Rounds uint32
+	Salt   []byte
+	Sum    []byte
+}
+
+// The salt of a caching_sha2_password hash may contain any character
+// the crypt(3) hash parser treats as a delimiter, so it's parsed by hand.
+func parse(hash string) (*scheme, error) {
+	if !strings.HasPrefix(hash, PrefixCachingSHA2) {
+		return nil, UnsupportedPrefixError(hash[:min(len(hash), len(PrefixCachingSHA2))])
+	}
+	if n, expected := len(hash), len(PrefixCachingSHA2)+roundsLength+1+SaltLength+sumLength; n != expected {
+		return nil, InvalidHashLengthError(n)
+	}
+	s := hash[len(PrefixCachingSHA2):]
+	for i := 0; i < roundsLength; i++ {
+		if c := s[i]; !isHex(c) {
+			return nil, InvalidHashError(c)
+		}
+	}
+	rounds, _ := strconv.ParseUint(s[:roundsLength], 16, 32)
+	s = s[roundsLength:]
+	if s[0] != '$' {
+		return nil, InvalidHashError(s[0])
+	}
+	s = s[1:]
+	if i := hashutil.HashEncoding.IndexAnyInvalid([]byte(s[SaltLength:])); i >= 0 {
+		return nil, InvalidHashError(s[SaltLength+i])
+	}
+	return &scheme{
+		Rounds: uint32(rounds) * roundsMultiplier,
+		Salt:   []byte(s[:SaltLength]),
+		Sum:    []byte(s[SaltLength:]),
+	}, nil
+}
+
+func parseNative(hash string) ([]byte, error) {
+	if !strings.HasPrefix(hash, PrefixNative) {
+		return nil, UnsupportedPrefixError(hash[:min(len(hash), len(PrefixNative))])
+	}
+	if n := len(hash); n != len(PrefixNative)+nativeSumLength {
+		return nil, InvalidHashLengthError(n)
+	}
+	for i := len(PrefixNative); i < len(hash); i++ {
+		if c := hash[i]; !isHex(c) {
+			return nil, InvalidHashError(c)
+		}
+	}
+	b, _ := hex.DecodeString(hash[len(PrefixNative):])
+	return b, nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// NewNativeHash returns the mysql_native_password hash of the password.
+func NewNativeHash(password string) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newNativeHash(password)
+	})
+}
+
+func newNativeHash(password string) (string, error) {
+	key, err := NativeKey([]byte(password))
+	if err != nil {
+		return "", err
+	}
+	return PrefixNative + strings.ToUpper(hex.EncodeToString(key)), nil
+}
+
+// NewHash returns the caching_sha2_password hash of the password with the given rounds.
+func NewHash(password string, rounds uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, rounds)
+	})
+}
+
+func newHash(password string, rounds uint32) (string, error) {
+	salt := hashutil.HashEncoding.Rand(SaltLength)
+	key, err := Key([]byte(password), salt, rounds)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%03X$%s%s", PrefixCachingSHA2, rounds/roundsMultiplier, salt, crypthash.LittleEndianEncoding.EncodeToString(key)), nil
+}
+
+// Params returns the hashing salt and rounds used to create
+// the given caching_sha2_password hash.
+func Params(hash string) (salt []byte, rounds uint32, err error) {
+	scheme, err := parse(hash)
+	if err != nil {
+		return
+	}
+	return scheme.Salt, scheme.Rounds, nil
+}
+
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "mysql",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the rounds of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, rounds, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.Uint64("rounds", uint64(rounds))}
+}
+
+// Check compares the given mysql_native_password or caching_sha2_password hash
+// with a new hash derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
+	if strings.HasPrefix(hash, PrefixNative) {
+		sum, err := parseNative(hash)
+		if err != nil {
+			return err
+		}
+		key, err := NativeKey([]byte(password))
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare(key, sum) == 0 {
+			return crypt.ErrPasswordMismatch
+		}
+		return nil
+	}
+	scheme, err := parse(hash)
+	if err != nil {
+		return err
+	}
+	key, err := Key([]byte(password), scheme.Salt, scheme.Rounds)
+	if err != nil {
+		return err
+	}
+	var b [sumLength]byte
+	crypthash.LittleEndianEncoding.Encode(b[:], key)
+	if subtle.ConstantTimeCompare(b[:], scheme.Sum) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+func init() {
+	crypt.Register(PrefixNative, hashAlgorithm)
+	crypt.Register(PrefixCachingSHA2, hashAlgorithm)
+}
@@ -0,0 +1,258 @@
+package mysql
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestCheckNative(t *testing.T) {
+	tests := []string{
+		"*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19",
+		"*2470c0c06dee42fd1618bb99005adca2ec9d1e19",
+	}
+	for _, hash := range tests {
+		t.Run(hash, func(t *testing.T) {
+			if err := Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		hash     string
+		password string
+		salt     []byte
+		rounds   uint32
+	}{
+		{
+			hash:     "$A$005$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3",
+			password: "password",
+			salt:     []byte("aaaaaaaaaaaaaaaaaaaa"),
+			rounds:   5000,
+		},
+		{
+			hash:     "$A$005$\x1fX,4b]&W\x7fz.%<9vT5=r?flEOnOKo9cDazSgqsSymC3kUE0ZrUtvI2HkcikACc61",
+			password: "password",
+			salt:     []byte("\x1fX,4b]&W\x7fz.%<9vT5=r?"),
+			rounds:   5000,
+		},
+		{
+			hash:     "$A$00A$aaaaaaaaaaaaaaaaaaaa48zegGfpfH.7jzkC.963WvgWd3Q/m/mkUmz3UN2Ykd7",
+			password: "password",
+			salt:     []byte("aaaaaaaaaaaaaaaaaaaa"),
+			rounds:   10000,
+		},
+		{
+			hash:     "$A$005$bbbbbbbbbbbbbbbbbbbbhUzAsYstJIUtHIRQkMw3VPUuopa7YVwL6QssOFZOEe/",
+			password: strings.Repeat("0123456789", 4),
+			salt:     []byte("bbbbbbbbbbbbbbbbbbbb"),
+			rounds:   5000,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, test.password); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			salt, rounds, err := Params(test.hash)
+			if err != nil {
+				t.Fatalf("Params() = _, _, %v; want nil", err)
+			}
+			if !bytes.Equal(salt, test.salt) {
+				t.Errorf("Params() = %v, _, _; want %v", salt, test.salt)
+			}
+			if rounds != test.rounds {
+				t.Errorf("Params() = _, %d, _; want %d", rounds, test.rounds)
+			}
+		})
+	}
+}
+
+func TestParseShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  UnsupportedPrefixError(""),
+		},
+		{
+			hash: "$B$005$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3",
+			err:  UnsupportedPrefixError("$B$"),
+		},
+		{
+			hash: "$A$005$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns",
+			err:  InvalidHashLengthError(69),
+		},
+		{
+			hash: "$A$00@$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3",
+			err:  InvalidHashError('@'),
+		},
+		{
+			hash: "$A$0050aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3",
+			err:  InvalidHashError('0'),
+		},
+		{
+			hash: "$A$005$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns@",
+			err:  InvalidHashError('@'),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+			if _, _, err := Params(test.hash); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Params() = _, _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckNativeShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E1",
+			err:  InvalidHashLengthError(40),
+		},
+		{
+			hash: "*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E1@",
+			err:  InvalidHashError('@'),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestKeyShouldFail(t *testing.T) {
+	tests := []struct {
+		password, salt []byte
+		rounds         uint32
+		err            error
+	}{
+		{
+			password: bytes.Repeat([]byte{'p'}, MaxPasswordLength+1),
+			salt:     bytes.Repeat([]byte{'a'}, SaltLength),
+			rounds:   DefaultRounds,
+			err:      InvalidPasswordLengthError(MaxPasswordLength + 1),
+		},
+		{
+			password: []byte("password"),
+			salt:     []byte("aaa"),
+			rounds:   DefaultRounds,
+			err:      InvalidSaltLengthError(3),
+		},
+		{
+			password: []byte("password"),
+			salt:     append(bytes.Repeat([]byte{'a'}, SaltLength-1), '$'),
+			rounds:   DefaultRounds,
+			err:      InvalidSaltError('$'),
+		},
+		{
+			password: []byte("password"),
+			salt:     bytes.Repeat([]byte{'a'}, SaltLength),
+			rounds:   MinRounds - 1000,
+			err:      InvalidRoundsError(MinRounds - 1000),
+		},
+		{
+			password: []byte("password"),
+			salt:     bytes.Repeat([]byte{'a'}, SaltLength),
+			rounds:   MaxRounds + 1000,
+			err:      InvalidRoundsError(MaxRounds + 1000),
+		},
+		{
+			password: []byte("password"),
+			salt:     bytes.Repeat([]byte{'a'}, SaltLength),
+			rounds:   5500,
+			err:      InvalidRoundsError(5500),
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("password=%s;salt=%s;rounds=%d", test.password, test.salt, test.rounds), func(t *testing.T) {
+			if _, err := Key(test.password, test.salt, test.rounds); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Key() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	tests := []uint32{DefaultRounds, 16000}
+	for _, rounds := range tests {
+		t.Run(fmt.Sprintf("rounds=%d", rounds), func(t *testing.T) {
+			hash, err := NewHash("password", rounds)
+			if err != nil {
+				t.Fatalf("NewHash() = _, %v; want nil", err)
+			}
+			if err := Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			_, r, err := Params(hash)
+			if err != nil {
+				t.Fatalf("Params() = _, _, %v; want nil", err)
+			}
+			if r != rounds {
+				t.Errorf("Params() = _, %d, _; want %d", r, rounds)
+			}
+		})
+	}
+}
+
+func TestNewNativeHash(t *testing.T) {
+	hash, err := NewNativeHash("password")
+	if err != nil {
+		t.Fatalf("NewNativeHash() = _, %v; want nil", err)
+	}
+	if expected := "*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19"; hash != expected {
+		t.Errorf("NewNativeHash() = %q, _; want %q", hash, expected)
+	}
+}
+
+func TestKey(t *testing.T) {
+	key, err := Key([]byte("password"), []byte("aaaaaaaaaaaaaaaaaaaa"), 10000)
+	if err != nil {
+		t.Fatalf("Key() = _, %v; want nil", err)
+	}
+	if encKey, expected := crypthash.LittleEndianEncoding.EncodeToString(key), "48zegGfpfH.7jzkC.963WvgWd3Q/m/mkUmz3UN2Ykd7"; encKey != expected {
+		t.Errorf("Key() = %q, _; want %q", encKey, expected)
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	for _, hash := range []string{
+		"*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19",
+		"$A$005$aaaaaaaaaaaaaaaaaaaaAMmri05lRxk9uWikU7.XMxBGPCH0pSGhMD7bmVscns3",
+	} {
+		if err := Check(hash, "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+			t.Errorf("Check(%q) = %v; want %v", hash, err, crypt.ErrNotApproved)
+		}
+	}
+	if _, err := NewNativeHash("password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewNativeHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := NewHash("password", 5000); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
@@ -3,14 +3,13 @@ package nthash
 
 import (
 	"crypto/subtle"
-	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"strconv"
-	"unicode/utf16"
 
 	"github.com/sergeymakinen/go-crypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"golang.org/x/crypto/md4"
 )
 
@@ -25,6 +24,9 @@ func (e InvalidPasswordLengthError) Error() string {
 
 // Key returns a NT Hash key derived from the password and salt.
 func Key(password []byte) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
 	if n := len(password); n%2 != 0 || n > MaxPasswordLength {
 		return nil, InvalidPasswordLengthError(n)
 	}
@@ -60,17 +62,35 @@ type scheme struct {
 	Sum        [sumLength]byte
 }
 
-func encodePassword(s string) []byte {
-	a := utf16.Encode([]rune(s))
-	b := make([]byte, len(a)*2)
-	for i, r := range a {
-		binary.LittleEndian.PutUint16(b[i*2:], r)
+// setting is a hash string without the sum.
+type setting struct {
+	HashPrefix hashPrefix
+}
+
+func parseSetting(s string) (*setting, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &setting{HashPrefix: scheme.HashPrefix}, nil
 	}
-	return b
+	var setting setting
+	if err := crypthash.Unmarshal(s, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func encodePassword(s string) []byte {
+	return cryptoutil.EncodeUTF16LE(s)
 }
 
 // NewHash returns the crypt(3) NT Hash hash of the password.
 func NewHash(password string) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password)
+	})
+}
+
+func newHash(password string) (string, error) {
 	b, err := Key(encodePassword(password))
 	if err != nil {
 		return "", err
@@ -80,9 +100,18 @@ func NewHash(password string) (string, error) {
 	return crypthash.Marshal(scheme)
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "nthash",
+	Check:     check,
+}
+
 // Check compares the given crypt(3) NT Hash hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -99,6 +128,29 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	if _, err := parseSetting(s); err != nil {
+		return "", err
+	}
+	return NewHash(password)
+}
+
+// genSalt returns the prefix as NT Hash has no salt, the count is ignored.
+func genSalt(_ uint64, _ []byte) (string, error) {
+	return Prefix, nil
+}
+
+func checkSetting(s string) error {
+	_, err := parseSetting(s)
+	return err
+}
+
 func init() {
-	crypt.RegisterHash(Prefix, Check)
+	crypt.Register(Prefix, hashAlgorithm)
+	crypt.RegisterMethod(Prefix, &crypt.Method{
+		Crypt:        cryptSetting,
+		GenSalt:      genSalt,
+		CheckSetting: checkSetting,
+		Legacy:       true,
+	})
 }
@@ -0,0 +1,37 @@
+package ntlm_test
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/ntlm"
+)
+
+func ExampleLMHash() {
+	key, _ := ntlm.LMHash("password")
+	fmt.Println(hex.EncodeToString(key))
+	// Output:
+	// e52cac67419a9a224a3b108f3fa6cb6d
+}
+
+func ExampleCheckNTLMv1() {
+	ntHash, _ := ntlm.NTHash("Password")
+	serverChallenge, _ := hex.DecodeString("0123456789abcdef")
+	response, _ := hex.DecodeString("67c43011f30298a2ad35ece64f16331c44bdbed927841f94")
+	fmt.Println(ntlm.CheckNTLMv1(response, ntHash, serverChallenge))
+
+	ntHash, _ = ntlm.NTHash("test")
+	fmt.Println(ntlm.CheckNTLMv1(response, ntHash, serverChallenge))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
+
+func ExampleCheckLMv2() {
+	ntHash, _ := ntlm.NTHash("Password")
+	serverChallenge, _ := hex.DecodeString("0123456789abcdef")
+	response, _ := hex.DecodeString("86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa")
+	fmt.Println(ntlm.CheckLMv2(response, ntHash, "User", "Domain", serverChallenge))
+	// Output:
+	// <nil>
+}
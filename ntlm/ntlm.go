@@ -0,0 +1,279 @@
+// Package ntlm implements the LM hash and the NTLMv1, NTLMv2 and LMv2
+// challenge-response computation and verification on top of the NT Hash.
+package ntlm
+
+import (
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/nthash"
+)
+
+const MaxLMPasswordLength = 14
+
+// InvalidPasswordLengthError values describe errors resulting from an invalid length of a password.
+type InvalidPasswordLengthError int
+
+func (e InvalidPasswordLengthError) Error() string {
+	return "invalid password length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidPasswordError values describe errors resulting from a character
+// that can't be represented in the OEM code page.
+type InvalidPasswordError rune
+
+func (e InvalidPasswordError) Error() string {
+	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in password"
+}
+
+const (
+	HashLength      = 16
+	ChallengeLength = 8
+)
+
+// InvalidHashLengthError values describe errors resulting from an invalid length of a LM or NT hash.
+type InvalidHashLengthError int
+
+func (e InvalidHashLengthError) Error() string {
+	return "invalid hash length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidChallengeLengthError values describe errors resulting from an invalid length of a challenge.
+type InvalidChallengeLengthError int
+
+func (e InvalidChallengeLengthError) Error() string {
+	return "invalid challenge length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidResponseLengthError values describe errors resulting from an invalid length of a response.
+type InvalidResponseLengthError int
+
+func (e InvalidResponseLengthError) Error() string {
+	return "invalid response length " + strconv.FormatInt(int64(e), 10)
+}
+
+var lmMagic = []byte("KGS!@#$%")
+
+// LMKey returns a LM hash derived from the password
+// which is expected to be upper-cased and encoded with the OEM code page.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func LMKey(password []byte) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	if n := len(password); n > MaxLMPasswordLength {
+		return nil, InvalidPasswordLengthError(n)
+	}
+	var b [MaxLMPasswordLength]byte
+	copy(b[:], password)
+	key := make([]byte, HashLength)
+	desEncrypt(key[:8], b[:7], lmMagic)
+	desEncrypt(key[8:], b[7:], lmMagic)
+	return key, nil
+}
+
+// LMHash returns the LM hash of the password.
+// The password is upper-cased and encoded with the OEM code page (437).
+func LMHash(password string) ([]byte, error) {
+	b, err := encodeOEMUpper(password)
+	if err != nil {
+		return nil, err
+	}
+	return LMKey(b)
+}
+
+// NTHash returns the NT hash of the password.
+func NTHash(password string) ([]byte, error) {
+	return nthash.Key(cryptoutil.EncodeUTF16LE(password))
+}
+
+// desEncrypt encrypts src with a DES key expanded from a 7-byte key.
+func desEncrypt(dst, key, src []byte) {
+	k := [8]byte{
+		key[0],
+		key[0]<<7 | key[1]>>1,
+		key[1]<<6 | key[2]>>2,
+		key[2]<<5 | key[3]>>3,
+		key[3]<<4 | key[4]>>4,
+		key[4]<<3 | key[5]>>5,
+		key[5]<<2 | key[6]>>6,
+		key[6] << 1,
+	}
+	c, _ := des.NewCipher(k[:])
+	c.Encrypt(dst, src)
+}
+
+func validate(hash, challenge []byte) error {
+	if n := len(hash); n != HashLength {
+		return InvalidHashLengthError(n)
+	}
+	if n := len(challenge); n != ChallengeLength {
+		return InvalidChallengeLengthError(n)
+	}
+	return nil
+}
+
+const v1ResponseLength = 24
+
+// NTLMv1Response returns the NTLMv1 (or LMv1 when given a LM hash) response
+// to the server challenge computed from the NT or LM hash.
+func NTLMv1Response(hash, serverChallenge []byte) ([]byte, error) {
+	if err := validate(hash, serverChallenge); err != nil {
+		return nil, err
+	}
+	var key [21]byte
+	copy(key[:], hash)
+	b := make([]byte, v1ResponseLength)
+	desEncrypt(b[:8], key[:7], serverChallenge)
+	desEncrypt(b[8:16], key[7:14], serverChallenge)
+	desEncrypt(b[16:], key[14:], serverChallenge)
+	return b, nil
+}
+
+// NTLMv1ESSResponse returns the NTLMv1 response with extended session security
+// (NTLM2 session response) to the server challenge computed from the NT hash and client challenge.
+func NTLMv1ESSResponse(ntHash, serverChallenge, clientChallenge []byte) ([]byte, error) {
+	if n := len(clientChallenge); n != ChallengeLength {
+		return nil, InvalidChallengeLengthError(n)
+	}
+	if n := len(serverChallenge); n != ChallengeLength {
+		return nil, InvalidChallengeLengthError(n)
+	}
+	h := md5.New()
+	h.Write(serverChallenge)
+	h.Write(clientChallenge)
+	return NTLMv1Response(ntHash, h.Sum(nil)[:ChallengeLength])
+}
+
+// NTOWFv2 returns the NTLMv2 one-way function of the NT hash, username and domain.
+func NTOWFv2(ntHash []byte, username, domain string) []byte {
+	return hmacMD5(ntHash, cryptoutil.EncodeUTF16LE(strings.ToUpper(username)+domain))
+}
+
+func hmacMD5(key []byte, bytes ...[]byte) []byte {
+	h := hmac.New(md5.New, key)
+	for _, b := range bytes {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// Windows file time of the Unix epoch.
+const fileTimeEpoch = 116444736000000000
+
+// NewBlob returns a NTLMv2 client blob for the timestamp, client challenge and
+// target information (AV pairs) received from the server.
+func NewBlob(timestamp time.Time, clientChallenge, targetInfo []byte) ([]byte, error) {
+	if n := len(clientChallenge); n != ChallengeLength {
+		return nil, InvalidChallengeLengthError(n)
+	}
+	b := make([]byte, 0, 28+len(targetInfo)+4)
+	b = append(b, 1, 1, 0, 0, 0, 0, 0, 0)
+	b = binary.LittleEndian.AppendUint64(b, uint64(timestamp.Unix()*1e7+int64(timestamp.Nanosecond()/100)+fileTimeEpoch))
+	b = append(b, clientChallenge...)
+	b = append(b, 0, 0, 0, 0)
+	b = append(b, targetInfo...)
+	return append(b, 0, 0, 0, 0), nil
+}
+
+const minBlobLength = 28
+
+// NTLMv2Response returns the NTLMv2 response to the server challenge computed
+// from the NT hash, username, domain and client blob.
+// The response is the NTProofStr followed by the blob.
+func NTLMv2Response(ntHash []byte, username, domain string, serverChallenge, blob []byte) ([]byte, error) {
+	if err := validate(ntHash, serverChallenge); err != nil {
+		return nil, err
+	}
+	if n := len(blob); n < minBlobLength {
+		return nil, InvalidResponseLengthError(n)
+	}
+	proof := hmacMD5(NTOWFv2(ntHash, username, domain), serverChallenge, blob)
+	return append(proof, blob...), nil
+}
+
+// LMv2Response returns the LMv2 response to the server challenge computed
+// from the NT hash, username, domain and client challenge.
+func LMv2Response(ntHash []byte, username, domain string, serverChallenge, clientChallenge []byte) ([]byte, error) {
+	if err := validate(ntHash, serverChallenge); err != nil {
+		return nil, err
+	}
+	if n := len(clientChallenge); n != ChallengeLength {
+		return nil, InvalidChallengeLengthError(n)
+	}
+	proof := hmacMD5(NTOWFv2(ntHash, username, domain), serverChallenge, clientChallenge)
+	return append(proof, clientChallenge...), nil
+}
+
+func compare(x, y []byte) error {
+	if subtle.ConstantTimeCompare(x, y) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+// CheckNTLMv1 compares the given NTLMv1 (or LMv1) response with a new response
+// derived from the NT (or LM) hash and server challenge.
+// Returns nil on success, or an error on failure.
+func CheckNTLMv1(response, hash, serverChallenge []byte) error {
+	if n := len(response); n != v1ResponseLength {
+		return InvalidResponseLengthError(n)
+	}
+	b, err := NTLMv1Response(hash, serverChallenge)
+	if err != nil {
+		return err
+	}
+	return compare(b, response)
+}
+
+// CheckNTLMv1ESS compares the given NTLMv1 response with extended session security
+// with a new response derived from the NT hash and challenges.
+// Returns nil on success, or an error on failure.
+func CheckNTLMv1ESS(response, ntHash, serverChallenge, clientChallenge []byte) error {
+	if n := len(response); n != v1ResponseLength {
+		return InvalidResponseLengthError(n)
+	}
+	b, err := NTLMv1ESSResponse(ntHash, serverChallenge, clientChallenge)
+	if err != nil {
+		return err
+	}
+	return compare(b, response)
+}
+
+// CheckNTLMv2 compares the given NTLMv2 response with a new response
+// derived from the NT hash, username, domain, server challenge
+// and the client blob contained in the response.
+// Returns nil on success, or an error on failure.
+func CheckNTLMv2(response, ntHash []byte, username, domain string, serverChallenge []byte) error {
+	if n := len(response); n < md5.Size+minBlobLength {
+		return InvalidResponseLengthError(n)
+	}
+	b, err := NTLMv2Response(ntHash, username, domain, serverChallenge, response[md5.Size:])
+	if err != nil {
+		return err
+	}
+	return compare(b[:md5.Size], response[:md5.Size])
+}
+
+// CheckLMv2 compares the given LMv2 response with a new response
+// derived from the NT hash, username, domain, server challenge
+// and the client challenge contained in the response.
+// Returns nil on success, or an error on failure.
+func CheckLMv2(response, ntHash []byte, username, domain string, serverChallenge []byte) error {
+	if n := len(response); n != md5.Size+ChallengeLength {
+		return InvalidResponseLengthError(n)
+	}
+	b, err := LMv2Response(ntHash, username, domain, serverChallenge, response[md5.Size:])
+	if err != nil {
+		return err
+	}
+	return compare(b[:md5.Size], response[:md5.Size])
+}
@@ -0,0 +1,247 @@
+package ntlm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+// Test vectors from [MS-NLMP] section 4.2.
+var (
+	serverChallenge = decodeHex("0123456789abcdef")
+	clientChallenge = decodeHex("aaaaaaaaaaaaaaaa")
+	ntHash          = decodeHex("a4f49c406510bdcab6824ee7c30fd852")
+	lmHash          = decodeHex("e52cac67419a9a224a3b108f3fa6cb6d")
+	targetInfo      = decodeHex("02000c0044006f006d00610069006e0001000c0053006500720076006500720000000000")
+)
+
+func decodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestLMHash(t *testing.T) {
+	tests := []struct {
+		password string
+		key      string
+	}{
+		{
+			password: "Password",
+			key:      "e52cac67419a9a224a3b108f3fa6cb6d",
+		},
+		{
+			password: "",
+			key:      "aad3b435b51404eeaad3b435b51404ee",
+		},
+		{
+			password: "päßwörd",
+			key:      hex.EncodeToString(mustLMKey([]byte("P\x8e\xe1W\x99RD"))),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.password, func(t *testing.T) {
+			key, err := LMHash(test.password)
+			if err != nil {
+				t.Fatalf("LMHash() = _, %v; want nil", err)
+			}
+			if encKey := hex.EncodeToString(key); encKey != test.key {
+				t.Errorf("LMHash() = %q, _; want %q", encKey, test.key)
+			}
+		})
+	}
+}
+
+func mustLMKey(password []byte) []byte {
+	key, err := LMKey(password)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func TestLMHashShouldFail(t *testing.T) {
+	tests := []struct {
+		password string
+		err      error
+	}{
+		{
+			password: strings.Repeat("p", MaxLMPasswordLength+1),
+			err:      InvalidPasswordLengthError(MaxLMPasswordLength + 1),
+		},
+		{
+			password: "пароль",
+			err:      InvalidPasswordError('п'),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.password, func(t *testing.T) {
+			if _, err := LMHash(test.password); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("LMHash() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNTHash(t *testing.T) {
+	key, err := NTHash("Password")
+	if err != nil {
+		t.Fatalf("NTHash() = _, %v; want nil", err)
+	}
+	if !bytes.Equal(key, ntHash) {
+		t.Errorf("NTHash() = %x, _; want %x", key, ntHash)
+	}
+}
+
+func TestNTLMv1(t *testing.T) {
+	tests := []struct {
+		name     string
+		hash     []byte
+		response string
+	}{
+		{
+			name:     "NTLMv1",
+			hash:     ntHash,
+			response: "67c43011f30298a2ad35ece64f16331c44bdbed927841f94",
+		},
+		{
+			name:     "LMv1",
+			hash:     lmHash,
+			response: "98def7b87f88aa5dafe2df779688a172def11c7d5ccdef13",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := NTLMv1Response(test.hash, serverChallenge)
+			if err != nil {
+				t.Fatalf("NTLMv1Response() = _, %v; want nil", err)
+			}
+			if encResponse := hex.EncodeToString(response); encResponse != test.response {
+				t.Errorf("NTLMv1Response() = %q, _; want %q", encResponse, test.response)
+			}
+			if err := CheckNTLMv1(decodeHex(test.response), test.hash, serverChallenge); err != nil {
+				t.Errorf("CheckNTLMv1() = %v; want nil", err)
+			}
+			if err := CheckNTLMv1(decodeHex(test.response), test.hash, clientChallenge); err != crypt.ErrPasswordMismatch {
+				t.Errorf("CheckNTLMv1() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestNTLMv1ESS(t *testing.T) {
+	response, err := NTLMv1ESSResponse(ntHash, serverChallenge, clientChallenge)
+	if err != nil {
+		t.Fatalf("NTLMv1ESSResponse() = _, %v; want nil", err)
+	}
+	if encResponse, expected := hex.EncodeToString(response), "7537f803ae367128ca458204bde7caf81e97ed2683267232"; encResponse != expected {
+		t.Errorf("NTLMv1ESSResponse() = %q, _; want %q", encResponse, expected)
+	}
+	if err := CheckNTLMv1ESS(response, ntHash, serverChallenge, clientChallenge); err != nil {
+		t.Errorf("CheckNTLMv1ESS() = %v; want nil", err)
+	}
+}
+
+func TestNTLMv2(t *testing.T) {
+	if key, expected := hex.EncodeToString(NTOWFv2(ntHash, "User", "Domain")), "0c868a403bfd7a93a3001ef22ef02e3f"; key != expected {
+		t.Errorf("NTOWFv2() = %q; want %q", key, expected)
+	}
+	blob, err := NewBlob(time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC), clientChallenge, targetInfo)
+	if err != nil {
+		t.Fatalf("NewBlob() = _, %v; want nil", err)
+	}
+	response, err := NTLMv2Response(ntHash, "User", "Domain", serverChallenge, blob)
+	if err != nil {
+		t.Fatalf("NTLMv2Response() = _, %v; want nil", err)
+	}
+	if proof, expected := hex.EncodeToString(response[:16]), "68cd0ab851e51c96aabc927bebef6a1c"; proof != expected {
+		t.Errorf("NTLMv2Response() = %q..., _; want %q...", proof, expected)
+	}
+	if !bytes.Equal(response[16:], blob) {
+		t.Errorf("NTLMv2Response() = ...%x, _; want ...%x", response[16:], blob)
+	}
+	if err := CheckNTLMv2(response, ntHash, "user", "Domain", serverChallenge); err != nil {
+		t.Errorf("CheckNTLMv2() = %v; want nil", err)
+	}
+	if err := CheckNTLMv2(response, ntHash, "User", "Other", serverChallenge); err != crypt.ErrPasswordMismatch {
+		t.Errorf("CheckNTLMv2() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
+
+func TestLMv2(t *testing.T) {
+	response, err := LMv2Response(ntHash, "User", "Domain", serverChallenge, clientChallenge)
+	if err != nil {
+		t.Fatalf("LMv2Response() = _, %v; want nil", err)
+	}
+	if encResponse, expected := hex.EncodeToString(response), "86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa"; encResponse != expected {
+		t.Errorf("LMv2Response() = %q, _; want %q", encResponse, expected)
+	}
+	if err := CheckLMv2(response, ntHash, "User", "Domain", serverChallenge); err != nil {
+		t.Errorf("CheckLMv2() = %v; want nil", err)
+	}
+	if err := CheckLMv2(response, lmHash, "User", "Domain", serverChallenge); err != crypt.ErrPasswordMismatch {
+		t.Errorf("CheckLMv2() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() error
+		err  error
+	}{
+		{
+			name: "NTLMv1 response length",
+			fn:   func() error { return CheckNTLMv1(make([]byte, 23), ntHash, serverChallenge) },
+			err:  InvalidResponseLengthError(23),
+		},
+		{
+			name: "NTLMv1 hash length",
+			fn:   func() error { return CheckNTLMv1(make([]byte, 24), ntHash[:15], serverChallenge) },
+			err:  InvalidHashLengthError(15),
+		},
+		{
+			name: "NTLMv1 challenge length",
+			fn:   func() error { return CheckNTLMv1(make([]byte, 24), ntHash, serverChallenge[:7]) },
+			err:  InvalidChallengeLengthError(7),
+		},
+		{
+			name: "NTLMv2 response length",
+			fn:   func() error { return CheckNTLMv2(make([]byte, 43), ntHash, "User", "Domain", serverChallenge) },
+			err:  InvalidResponseLengthError(43),
+		},
+		{
+			name: "LMv2 response length",
+			fn:   func() error { return CheckLMv2(make([]byte, 25), ntHash, "User", "Domain", serverChallenge) },
+			err:  InvalidResponseLengthError(25),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.fn(); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("%s = %v; want %v", test.name, err, test.err)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	if _, err := LMHash("password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("LMHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := NTHash("password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NTHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
@@ -0,0 +1,49 @@
+package ntlm
+
+import "unicode"
+
+// Upper half of code page 437, the default OEM code page of Windows.
+var cp437 = [128]rune{
+	'Ç', 'ü', 'é', 'â', 'ä', 'à', 'å', 'ç', 'ê', 'ë', 'è', 'ï', 'î', 'ì', 'Ä', 'Å',
+	'É', 'æ', 'Æ', 'ô', 'ö', 'ò', 'û', 'ù', 'ÿ', 'Ö', 'Ü', '¢', '£', '¥', '₧', 'ƒ',
+	'á', 'í', 'ó', 'ú', 'ñ', 'Ñ', 'ª', 'º', '¿', '⌐', '¬', '½', '¼', '¡', '«', '»',
+	'░', '▒', '▓', '│', '┤', '╡', '╢', '╖', '╕', '╣', '║', '╗', '╝', '╜', '╛', '┐',
+	'└', '┴', '┬', '├', '─', '┼', '╞', '╟', '╚', '╔', '╩', '╦', '╠', '═', '╬', '╧',
+	'╨', '╤', '╥', '╙', '╘', '╒', '╓', '╫', '╪', '┘', '┌', '█', '▄', '▌', '▐', '▀',
+	'α', 'ß', 'Γ', 'π', 'Σ', 'σ', 'µ', 'τ', 'Φ', 'Θ', 'Ω', 'δ', '∞', 'φ', 'ε', '∩',
+	'≡', '±', '≥', '≤', '⌠', '⌡', '÷', '≈', '°', '∙', '·', '√', 'ⁿ', '²', '■', ' ',
+}
+
+var cp437Encoder = func() map[rune]byte {
+	m := make(map[rune]byte, len(cp437))
+	for i, r := range cp437 {
+		m[r] = byte(0x80 + i)
+	}
+	return m
+}()
+
+func encodeOEM(r rune) (byte, bool) {
+	if r < 0x80 {
+		return byte(r), true
+	}
+	c, ok := cp437Encoder[r]
+	return c, ok
+}
+
+// encodeOEMUpper returns s upper-cased and encoded with the OEM code page.
+// Characters whose upper case is not present in the code page are kept as is.
+func encodeOEMUpper(s string) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if c, ok := encodeOEM(unicode.ToUpper(r)); ok {
+			b = append(b, c)
+			continue
+		}
+		c, ok := encodeOEM(r)
+		if !ok {
+			return nil, InvalidPasswordError(r)
+		}
+		b = append(b, c)
+	}
+	return b, nil
+}
@@ -0,0 +1,34 @@
+package onion_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt"
+	_ "github.com/sergeymakinen/go-crypt/md5"
+	"github.com/sergeymakinen/go-crypt/onion"
+	"github.com/sergeymakinen/go-crypt/sha512"
+)
+
+func ExampleWrap() {
+	hash, _ := onion.Wrap("$1$saltsalt$qjXMvbEw8oaL.CzflDtaK/", 512, 1)
+	fmt.Println(crypt.Check(hash, "password"))
+	fmt.Println(crypt.Check(hash, "test"))
+	_, inner, _ := onion.Params(hash)
+	fmt.Println(inner)
+	// Output:
+	// <nil>
+	// hash and password mismatch
+	// $1$saltsalt
+}
+
+func ExampleUnwrap() {
+	hash := "$onion$v=19$m=512,t=1,p=1$imv+1BRpPLI$55YlYd3q/a9cFVgR8FDWzhgFEjfQlKGsg16Dtzy9hX4$$1$saltsalt"
+	hash, err := onion.Unwrap(hash, "password", func(password string) (string, error) {
+		return sha512.NewHash(password, sha512.ImplicitRounds)
+	})
+	fmt.Println(err)
+	fmt.Println(sha512.Check(hash, "password"))
+	// Output:
+	// <nil>
+	// <nil>
+}
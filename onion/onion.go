@@ -0,0 +1,185 @@
+// Package onion implements onion hashing: the upgrade of legacy hashes
+// to Argon2 without knowing the passwords.
+//
+// A legacy hash of any registered algorithm supported by crypt.Crypt is used as the password
+// of an outer Argon2id hash, and only the setting of the legacy hash
+// (its parameters without the sum) is kept:
+//
+//	$onion$v=19$m=<memory>,t=<time>,p=<threads>$<outer salt>$<outer sum>$<inner setting>
+//
+// Checking recomputes the inner hash from the password and the inner setting,
+// then the outer hash from the inner hash. As the legacy hash is still
+// the weakest link, the onion hash should be replaced with a plain modern hash
+// on the next successful check with Unwrap.
+package onion
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/argon2"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/hashutil"
+)
+
+const Prefix = "$onion$"
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+// bcryptSumLength is the length of the bcrypt sum that follows the salt without a separator.
+const bcryptSumLength = 31
+
+// innerSetting returns the setting of the given hash: the hash without the sum.
+// The candidate settings are checked with crypt.CheckSalt and crypt.Crypt,
+// so the algorithm of the hash must be registered.
+func innerSetting(hash string) (string, error) {
+	var candidates []string
+	if i := strings.LastIndexByte(hash, '$'); i > 0 {
+		// MCF hashes: the sum is the last fragment
+		candidates = append(candidates, hash[:i])
+	}
+	// bcrypt, DES Extended (BSDi) and DES hashes: the sum follows the setting without a separator
+	for _, n := range []int{len(hash) - bcryptSumLength, 9, 2} {
+		if n > 0 && n < len(hash) {
+			candidates = append(candidates, hash[:n])
+		}
+	}
+	for _, setting := range candidates {
+		if status := crypt.CheckSalt(setting); status != crypt.SaltOK && status != crypt.SaltMethodLegacy {
+			continue
+		}
+		if s, err := crypt.Crypt("", setting); err == nil && len(s) == len(hash) && strings.HasPrefix(s, setting) {
+			return setting, nil
+		}
+	}
+	return "", crypt.ErrHash
+}
+
+type scheme struct {
+	Outer string // the Argon2id hash of the inner hash
+	Inner string // the inner setting
+}
+
+func (s *scheme) String() string {
+	return Prefix + strings.TrimPrefix(s.Outer, argon2.Prefix2id) + "$" + s.Inner
+}
+
+// $onion$[v=<version>$]m=<memory>,t=<time>,p=<threads>$<outer salt>$<outer sum>$<inner setting>
+func parseHash(hash string) (*scheme, error) {
+	if !strings.HasPrefix(hash, Prefix) {
+		return nil, UnsupportedPrefixError(hashutil.Prefix(hash, len(Prefix)))
+	}
+	s := hash[len(Prefix):]
+	n := 3
+	if strings.HasPrefix(s, "v=") {
+		n++
+	}
+	fragments := strings.SplitN(s, "$", n+1)
+	if len(fragments) <= n || fragments[n] == "" {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "missing inner setting"}
+	}
+	scheme := scheme{
+		Outer: argon2.Prefix2id + strings.Join(fragments[:n], "$"),
+		Inner: fragments[n],
+	}
+	if _, _, _, _, _, err := argon2.Params(scheme.Outer); err != nil {
+		return nil, err
+	}
+	return &scheme, nil
+}
+
+// Wrap returns the onion hash of the given hash with the outer Argon2id memory and time costs.
+func Wrap(inner string, memory, time uint32) (string, error) {
+	setting, err := innerSetting(inner)
+	if err != nil {
+		return "", err
+	}
+	outer, err := argon2.NewHash(inner, memory, time)
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{
+		Outer: outer,
+		Inner: setting,
+	}
+	return scheme.String(), nil
+}
+
+// Params returns the outer Argon2id hash without the inner setting
+// and the inner setting of the given onion hash.
+// The parameters of the hashes are returned by the Params functions of their packages.
+func Params(hash string) (outer, inner string, err error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return
+	}
+	return scheme.Outer, scheme.Inner, nil
+}
+
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "onion",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the costs of the outer hash and the prefix of the inner hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	outer, inner, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	_, memory, time, threads, _, err := argon2.Params(outer)
+	if err != nil {
+		return nil
+	}
+	attrs := []slog.Attr{
+		slog.Uint64("memory", uint64(memory)),
+		slog.Uint64("time", uint64(time)),
+		slog.Uint64("threads", uint64(threads)),
+	}
+	if strings.HasPrefix(inner, "$") {
+		if i := strings.IndexAny(inner[1:], "$,"); i > 0 {
+			attrs = append(attrs, slog.String("inner", inner[:i+2]))
+		}
+	}
+	return attrs
+}
+
+// Check compares the given onion hash with a new hash derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return err
+	}
+	inner, err := crypt.Crypt(password, scheme.Inner)
+	if err != nil {
+		return err
+	}
+	return argon2.Check(scheme.Outer, inner)
+}
+
+// Unwrap compares the given onion hash with a new hash derived from the password
+// and, on success, returns the hash of the password returned by newHash,
+// like the NewHash function of a hash package with the parameters bound.
+func Unwrap(hash, password string, newHash func(password string) (string, error)) (string, error) {
+	if err := Check(hash, password); err != nil {
+		return "", err
+	}
+	return newHash(password)
+}
+
+func init() {
+	crypt.Register(Prefix, hashAlgorithm)
+}
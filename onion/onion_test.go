@@ -0,0 +1,214 @@
+package onion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/argon2"
+	_ "github.com/sergeymakinen/go-crypt/bcrypt"
+	"github.com/sergeymakinen/go-crypt/des"
+	_ "github.com/sergeymakinen/go-crypt/desext"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+	"github.com/sergeymakinen/go-crypt/md5"
+	"github.com/sergeymakinen/go-crypt/nthash"
+	"github.com/sergeymakinen/go-crypt/sha512"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		hash  string
+		outer string
+		inner string
+	}{
+		{
+			hash:  "$onion$v=19$m=512,t=1,p=1$sAbiiSkOpgA$WIa7APgragwwZuwdO/HvhuMnAr24aQ+NAsut9VM/j5E$sa",
+			outer: "$argon2id$v=19$m=512,t=1,p=1$sAbiiSkOpgA$WIa7APgragwwZuwdO/HvhuMnAr24aQ+NAsut9VM/j5E",
+			inner: "sa",
+		},
+		{
+			hash:  "$onion$v=19$m=512,t=1,p=1$G19eF9hjhCA$avpsMy1mQWAWofiHVQSPIgWl1H8eIwFiFUQ84HiYw+c$_J9..salt",
+			outer: "$argon2id$v=19$m=512,t=1,p=1$G19eF9hjhCA$avpsMy1mQWAWofiHVQSPIgWl1H8eIwFiFUQ84HiYw+c",
+			inner: "_J9..salt",
+		},
+		{
+			hash:  "$onion$v=19$m=512,t=1,p=1$imv+1BRpPLI$55YlYd3q/a9cFVgR8FDWzhgFEjfQlKGsg16Dtzy9hX4$$1$saltsalt",
+			outer: "$argon2id$v=19$m=512,t=1,p=1$imv+1BRpPLI$55YlYd3q/a9cFVgR8FDWzhgFEjfQlKGsg16Dtzy9hX4",
+			inner: "$1$saltsalt",
+		},
+		{
+			hash:  "$onion$v=19$m=512,t=1,p=1$0TMFKodmuC8$71bO2UB1Ysob4NxbLlOSqmY/NZP4BrYAz7XDy+mj3gU$$6$rounds=5000$saltsalt",
+			outer: "$argon2id$v=19$m=512,t=1,p=1$0TMFKodmuC8$71bO2UB1Ysob4NxbLlOSqmY/NZP4BrYAz7XDy+mj3gU",
+			inner: "$6$rounds=5000$saltsalt",
+		},
+		{
+			hash:  "$onion$v=19$m=512,t=1,p=1$MYpyeRa7X40$kAUy3Igda86+FBHQv8V6S7pxOKpg9UofLkAdHXhMgXw$$2b$04$abcdefghijklmnopqrstuu",
+			outer: "$argon2id$v=19$m=512,t=1,p=1$MYpyeRa7X40$kAUy3Igda86+FBHQv8V6S7pxOKpg9UofLkAdHXhMgXw",
+			inner: "$2b$04$abcdefghijklmnopqrstuu",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(test.hash, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+			if err := crypt.Check(test.hash, "password"); err != nil {
+				t.Errorf("crypt.Check() = %v; want nil", err)
+			}
+			outer, inner, err := Params(test.hash)
+			if err != nil {
+				t.Fatalf("Params() = _, _, %v; want nil", err)
+			}
+			if outer != test.outer {
+				t.Errorf("Params() = %q, _, _; want %q", outer, test.outer)
+			}
+			if inner != test.inner {
+				t.Errorf("Params() = _, %q, _; want %q", inner, test.inner)
+			}
+		})
+	}
+}
+
+func argon2ParamsError(hash string) error {
+	_, _, _, _, _, err := argon2.Params(hash)
+	return err
+}
+
+func TestParseShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  UnsupportedPrefixError(""),
+		},
+		{
+			hash: "$argon2id$v=19$m=512,t=1,p=1$sAbiiSkOpgA$WIa7APgragwwZuwdO/HvhuMnAr24aQ+NAsut9VM/j5E",
+			err:  UnsupportedPrefixError("$argon2id$"),
+		},
+		{
+			hash: "$onion$v=19$m=512,t=1,p=1$sAbiiSkOpgA$WIa7APgragwwZuwdO/HvhuMnAr24aQ+NAsut9VM/j5E",
+			err:  &parse.SyntaxError{Offset: 81, Msg: "missing inner setting"},
+		},
+		{
+			hash: "$onion$v=19$m=512,t=1,p=1$sAbiiSkOpgA$WIa7APgragwwZuwdO/HvhuMnAr24aQ+NAsut9VM/j5E$",
+			err:  &parse.SyntaxError{Offset: 82, Msg: "missing inner setting"},
+		},
+		{
+			hash: "$onion$v=19$m=512,t=1,p=1$sAbiiSkOpgA$$sa",
+			err:  argon2ParamsError("$argon2id$v=19$m=512,t=1,p=1$sAbiiSkOpgA$"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+			if _, _, err := Params(test.hash); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Params() = _, _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	sha512Hash, _ := sha512.NewHash("password", sha512.ImplicitRounds)
+	nthashHash, _ := nthash.NewHash("password")
+	desHash, _ := des.Generate("password")
+	md5Hash, _ := md5.Generate("password")
+	tests := []struct {
+		name  string
+		inner string
+	}{
+		{
+			name:  "des",
+			inner: desHash,
+		},
+		{
+			name:  "md5",
+			inner: md5Hash,
+		},
+		{
+			name:  "nthash",
+			inner: nthashHash,
+		},
+		{
+			name:  "sha512",
+			inner: sha512Hash,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hash, err := Wrap(test.inner, argon2.MinMemory, argon2.MinTime)
+			if err != nil {
+				t.Fatalf("Wrap() = _, %v; want nil", err)
+			}
+			if err = Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err = Check(hash, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+			if strings.Contains(hash, test.inner) {
+				t.Errorf("Wrap() = %q, _; want no inner hash sum", hash)
+			}
+		})
+	}
+}
+
+func TestWrapShouldFail(t *testing.T) {
+	tests := []struct {
+		name   string
+		inner  string
+		memory uint32
+		err    error
+	}{
+		{
+			name:   "unknown hash",
+			inner:  "$foo$saltsalt$qjXMvbEw8oaL.CzflDtaK/",
+			memory: argon2.MinMemory,
+			err:    crypt.ErrHash,
+		},
+		{
+			name:   "malformed hash",
+			inner:  "$1$saltsalt$qjXMvbEw8oaL.CzflDtaK",
+			memory: argon2.MinMemory,
+			err:    crypt.ErrHash,
+		},
+		{
+			name:   "invalid memory",
+			inner:  "$1$saltsalt$qjXMvbEw8oaL.CzflDtaK/",
+			memory: argon2.MinMemory - 1,
+			err:    argon2.InvalidMemoryError(argon2.MinMemory - 1),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Wrap(test.inner, test.memory, argon2.MinTime); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Wrap() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	hash := "$onion$v=19$m=512,t=1,p=1$imv+1BRpPLI$55YlYd3q/a9cFVgR8FDWzhgFEjfQlKGsg16Dtzy9hX4$$1$saltsalt"
+	newHash := func(password string) (string, error) {
+		return sha512.NewHash(password, sha512.ImplicitRounds)
+	}
+	s, err := Unwrap(hash, "password", newHash)
+	if err != nil {
+		t.Fatalf("Unwrap() = _, %v; want nil", err)
+	}
+	if err = sha512.Check(s, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if _, err = Unwrap(hash, "test", newHash); err != crypt.ErrPasswordMismatch {
+		t.Errorf("Unwrap() = _, %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
@@ -0,0 +1,28 @@
+package pepper_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/pepper"
+	_ "github.com/sergeymakinen/go-crypt/sha512"
+)
+
+func ExampleRegister() {
+	keyring := &pepper.StaticKeyring{
+		Current: "2",
+		Keys: map[string][]byte{
+			"1": []byte("secret1"),
+			"2": []byte("secret2"),
+		},
+	}
+	pepper.Register(keyring, nil)
+	hash := "$pepper$kid=1$$6$saltsalt$pOc8NDfo6lhkGzrx7WO6QtOrM7Ui/3IYfa3gc2P8xPqeUiLu3VDciMNd3aVMnLiQ1h.bNsWxzhIc7OCdWwH66."
+	fmt.Println(crypt.Check(hash, "password"))
+	fmt.Println(crypt.Check(hash, "test"))
+	fmt.Println(pepper.NeedsRotation(hash, keyring))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+	// true <nil>
+}
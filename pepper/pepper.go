@@ -0,0 +1,191 @@
+// Package pepper implements peppering of hashes of any registered algorithm.
+//
+// The password is HMAC-ed with a server-held secret key before it is hashed
+// by the wrapped algorithm, and the ID of the key is recorded in the hash string:
+//
+//	$pepper$kid=<key ID>$<inner hash>
+//
+// so a leaked hash can't be attacked without the key, and keys can be rotated.
+// The inner hash is created and checked by the algorithm packages as is,
+// so their hash formats stay intact.
+package pepper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/hashutil"
+	"github.com/sergeymakinen/go-crypt/internal/keyid"
+)
+
+const Prefix = "$pepper$"
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+// UnknownKeyError values describe errors resulting from a key ID not found in a keyring.
+type UnknownKeyError = keyid.UnknownKeyError
+
+// InvalidKeyIDError values describe errors resulting from a key ID
+// that can't be represented in a hash string.
+type InvalidKeyIDError = keyid.InvalidKeyIDError
+
+// Keyring provides the secret keys used to pepper passwords.
+type Keyring interface {
+	// CurrentKeyID returns the ID of the key used to pepper new hashes.
+	// Keys with other IDs are considered retired.
+	CurrentKeyID() string
+
+	// Key returns the key with the given ID,
+	// or UnknownKeyError if there is no such key.
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeyring is a Keyring with a fixed set of keys.
+type StaticKeyring = keyid.Static
+
+// Key returns the peppered password: the base64-encoded HMAC-SHA-256
+// of the password with the given key. It is the password of the inner hash.
+func Key(password, key []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write(password)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+type scheme struct {
+	KeyID string
+	Inner string
+}
+
+func (s *scheme) String() string {
+	return keyid.String(Prefix, s.KeyID) + s.Inner
+}
+
+// $pepper$kid=<key ID>$<inner hash>
+func parseHash(hash string) (*scheme, error) {
+	if !strings.HasPrefix(hash, Prefix) {
+		return nil, UnsupportedPrefixError(hashutil.Prefix(hash, len(Prefix)))
+	}
+	id, inner, err := keyid.Parse(hash, Prefix, "inner hash")
+	if err != nil {
+		return nil, err
+	}
+	if inner == "" {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "missing inner hash"}
+	}
+	return &scheme{
+		KeyID: id,
+		Inner: inner,
+	}, nil
+}
+
+// NewHash returns the peppered hash of the password
+// with the current key of the keyring.
+// NewHash is the function that returns the inner hash of the peppered password,
+// like the NewHash function of a hash package with the parameters bound.
+func NewHash(password string, keyring Keyring, newHash func(password string) (string, error)) (string, error) {
+	return crypt.ObserveNewHash("pepper", func() (string, error) {
+		return newHashWith(password, keyring, newHash)
+	}, hookParams)
+}
+
+func newHashWith(password string, keyring Keyring, newHash func(password string) (string, error)) (string, error) {
+	scheme := scheme{KeyID: keyring.CurrentKeyID()}
+	if err := keyid.Validate(scheme.KeyID); err != nil {
+		return "", err
+	}
+	key, err := keyring.Key(scheme.KeyID)
+	if err != nil {
+		return "", err
+	}
+	if scheme.Inner, err = newHash(Key([]byte(password), key)); err != nil {
+		return "", err
+	}
+	return scheme.String(), nil
+}
+
+// Params returns the key ID and the inner hash of the given peppered hash.
+func Params(hash string) (keyID, inner string, err error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return
+	}
+	return scheme.KeyID, scheme.Inner, nil
+}
+
+// hookParams returns the key ID of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	keyID, _, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.String("kid", keyID)}
+}
+
+// Check compares the given peppered hash with a new hash derived from the password
+// and the key from the keyring.
+// The inner hash is checked with the registry, so its algorithm must be registered there
+// and allowed by its allow and deny lists.
+//
+// The r parameter is optional. If nil, crypt.DefaultRegistry is used.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string, keyring Keyring, r *crypt.Registry) error {
+	if r == nil {
+		r = crypt.DefaultRegistry
+	}
+	return crypt.ObserveCheck("pepper", hash, password, func(hash, password string) error {
+		return check(hash, password, keyring, r)
+	}, hookParams)
+}
+
+func check(hash, password string, keyring Keyring, r *crypt.Registry) error {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return err
+	}
+	key, err := keyring.Key(scheme.KeyID)
+	if err != nil {
+		return err
+	}
+	return r.Check(scheme.Inner, Key([]byte(password), key))
+}
+
+// NeedsRotation reports whether the given peppered hash uses a retired key of the keyring,
+// so it should be replaced with a new hash on the next successful check,
+// when the password is known.
+func NeedsRotation(hash string, keyring Keyring) (bool, error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return false, err
+	}
+	return scheme.KeyID != keyring.CurrentKeyID(), nil
+}
+
+// Register registers peppered hashes in the registry with the given keyring.
+// The inner hashes are checked with the same registry, so they can't bypass its deny list.
+//
+// The r parameter is optional. If nil, crypt.DefaultRegistry is used.
+func Register(keyring Keyring, r *crypt.Registry) {
+	if r == nil {
+		r = crypt.DefaultRegistry
+	}
+	r.Register(Prefix, &crypt.Hash{
+		Algorithm: "pepper",
+		Check: func(hash, password string) error {
+			return check(hash, password, keyring, r)
+		},
+		Params: hookParams,
+		// The inner hash is refused by the registry if not approved in FIPS 140 mode
+		Approved: true,
+	})
+}
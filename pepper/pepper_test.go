@@ -0,0 +1,234 @@
+package pepper
+
+import (
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+	"github.com/sergeymakinen/go-crypt/md5"
+	"github.com/sergeymakinen/go-crypt/sha512"
+)
+
+var testKeyring = &StaticKeyring{
+	Current: "2",
+	Keys: map[string][]byte{
+		"1": []byte("secret1"),
+		"2": []byte("secret2"),
+	},
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		key      []byte
+		expected string
+	}{
+		{
+			key:      []byte("secret1"),
+			expected: "DiLpmUHShNh9pt2dr9PWIMtHIut27bvoMq5obIavl1U=",
+		},
+		{
+			key:      []byte("secret2"),
+			expected: "5KW/HkyDTuAkTMT15E3Y4CfL/PjfFK5kKlYWaq5i76U=",
+		},
+	}
+	for _, test := range tests {
+		t.Run(string(test.key), func(t *testing.T) {
+			if s := Key([]byte("password"), test.key); s != test.expected {
+				t.Errorf("Key() = %q; want %q", s, test.expected)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		hash          string
+		keyID         string
+		inner         string
+		needsRotation bool
+	}{
+		{
+			hash:          "$pepper$kid=1$$6$saltsalt$pOc8NDfo6lhkGzrx7WO6QtOrM7Ui/3IYfa3gc2P8xPqeUiLu3VDciMNd3aVMnLiQ1h.bNsWxzhIc7OCdWwH66.",
+			keyID:         "1",
+			inner:         "$6$saltsalt$pOc8NDfo6lhkGzrx7WO6QtOrM7Ui/3IYfa3gc2P8xPqeUiLu3VDciMNd3aVMnLiQ1h.bNsWxzhIc7OCdWwH66.",
+			needsRotation: true,
+		},
+		{
+			hash:  "$pepper$kid=2$$6$saltsalt$UiBN4Jdqgs8Pc7JLLyv6BpOGnZFwdioJwKMoWp15KD1aG1lHViyYlb50ce03PrVer2iqwow9QPFP820qnBpbs/",
+			keyID: "2",
+			inner: "$6$saltsalt$UiBN4Jdqgs8Pc7JLLyv6BpOGnZFwdioJwKMoWp15KD1aG1lHViyYlb50ce03PrVer2iqwow9QPFP820qnBpbs/",
+		},
+		{
+			hash:  "$pepper$kid=2$$1$saltsalt$PrZbOYWsZcC.vhyqduaAy/",
+			keyID: "2",
+			inner: "$1$saltsalt$PrZbOYWsZcC.vhyqduaAy/",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password", testKeyring, nil); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(test.hash, "test", testKeyring, nil); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+			keyID, inner, err := Params(test.hash)
+			if err != nil {
+				t.Fatalf("Params() = _, _, %v; want nil", err)
+			}
+			if keyID != test.keyID {
+				t.Errorf("Params() = %q, _, _; want %q", keyID, test.keyID)
+			}
+			if inner != test.inner {
+				t.Errorf("Params() = _, %q, _; want %q", inner, test.inner)
+			}
+			needsRotation, err := NeedsRotation(test.hash, testKeyring)
+			if err != nil {
+				t.Fatalf("NeedsRotation() = _, %v; want nil", err)
+			}
+			if needsRotation != test.needsRotation {
+				t.Errorf("NeedsRotation() = %v, _; want %v", needsRotation, test.needsRotation)
+			}
+		})
+	}
+}
+
+func TestParseShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  UnsupportedPrefixError(""),
+		},
+		{
+			hash: "$6$saltsalt$UiBN4Jdqgs8Pc7JLLyv6BpOGnZFwdioJwKMoWp15KD1aG1lHViyYlb50ce03PrVer2iqwow9QPFP820qnBpbs/",
+			err:  UnsupportedPrefixError("$6$"),
+		},
+		{
+			hash: "$pepper$2$$1$saltsalt$PrZbOYWsZcC.vhyqduaAy/",
+			err:  &parse.SyntaxError{Offset: 8, Msg: "missing key ID"},
+		},
+		{
+			hash: "$pepper$kid=2",
+			err:  &parse.SyntaxError{Offset: 13, Msg: "missing inner hash"},
+		},
+		{
+			hash: "$pepper$kid=2$",
+			err:  &parse.SyntaxError{Offset: 14, Msg: "missing inner hash"},
+		},
+		{
+			hash: "$pepper$kid=$$1$saltsalt$PrZbOYWsZcC.vhyqduaAy/",
+			err:  &parse.SyntaxError{Offset: 12, Msg: "invalid key ID"},
+		},
+		{
+			hash: "$pepper$kid=2,3$$1$saltsalt$PrZbOYWsZcC.vhyqduaAy/",
+			err:  &parse.SyntaxError{Offset: 15, Msg: "invalid key ID"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password", testKeyring, nil); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+			if _, _, err := Params(test.hash); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Params() = _, _, %v; want %v", err, test.err)
+			}
+			if _, err := NeedsRotation(test.hash, testKeyring); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("NeedsRotation() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	hash := "$pepper$kid=3$$1$saltsalt$PrZbOYWsZcC.vhyqduaAy/"
+	if err, expected := Check(hash, "password", testKeyring, nil), UnknownKeyError("3"); !testutil.IsEqualError(err, expected) {
+		t.Errorf("Check() = %v; want %v", err, expected)
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	hash, err := NewHash("password", testKeyring, func(password string) (string, error) {
+		return sha512.NewHash(password, sha512.ImplicitRounds)
+	})
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err = Check(hash, "password", testKeyring, nil); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	keyID, inner, err := Params(hash)
+	if err != nil {
+		t.Fatalf("Params() = _, _, %v; want nil", err)
+	}
+	if keyID != testKeyring.Current {
+		t.Errorf("Params() = %q, _, _; want %q", keyID, testKeyring.Current)
+	}
+	if _, rounds, err := sha512.Params(inner); err != nil || rounds != sha512.ImplicitRounds {
+		t.Errorf("Params() = _, %d, %v; want %d, nil", rounds, err, sha512.ImplicitRounds)
+	}
+}
+
+func TestNewHashShouldFail(t *testing.T) {
+	newHash := func(password string) (string, error) {
+		return md5.Generate(password)
+	}
+	tests := []struct {
+		name    string
+		keyring Keyring
+		err     error
+	}{
+		{
+			name:    "invalid key ID",
+			keyring: &StaticKeyring{Current: "1$"},
+			err:     InvalidKeyIDError("1$"),
+		},
+		{
+			name:    "unknown key",
+			keyring: &StaticKeyring{Current: "1"},
+			err:     UnknownKeyError("1"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewHash("password", test.keyring, newHash); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("NewHash() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(testKeyring, nil)
+	hash := "$pepper$kid=1$$6$saltsalt$pOc8NDfo6lhkGzrx7WO6QtOrM7Ui/3IYfa3gc2P8xPqeUiLu3VDciMNd3aVMnLiQ1h.bNsWxzhIc7OCdWwH66."
+	if err := crypt.Check(hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err := crypt.Check(hash, "test"); err != crypt.ErrPasswordMismatch {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
+
+func TestRegisterDeny(t *testing.T) {
+	r := crypt.NewRegistry(&crypt.RegistryOptions{
+		Parent: crypt.DefaultRegistry,
+		Deny:   []string{md5.Prefix},
+	})
+	Register(testKeyring, r)
+	hash, err := NewHash("password", testKeyring, md5.Generate)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err := r.Check(hash, "password"); err != crypt.ErrHashNotAllowed {
+		t.Errorf("Registry.Check() = %v; want %v", err, crypt.ErrHashNotAllowed)
+	}
+	if err := Check(hash, "password", testKeyring, r); err != crypt.ErrHashNotAllowed {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrHashNotAllowed)
+	}
+	if err := Check(hash, "password", testKeyring, nil); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+}
@@ -0,0 +1,39 @@
+package phpass_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/phpass"
+)
+
+func ExampleParams() {
+	salt, cost, _, _ := phpass.Params("$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0")
+	fmt.Println(string(salt))
+	fmt.Println(cost)
+	// Output:
+	// IQRaTwmf
+	// 11
+}
+
+func ExampleKey() {
+	salt, cost, opts, _ := phpass.Params("$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0")
+	fmt.Println(string(salt))
+	fmt.Println(cost)
+
+	key, _ := phpass.Key([]byte("test12345"), salt, cost, opts)
+	fmt.Println(hash.LittleEndianEncoding.EncodeToString(key))
+	// Output:
+	// IQRaTwmf
+	// 11
+	// eRo7ud9Fh4E2PdI0S3r.L0
+}
+
+func ExampleCheck() {
+	hash := "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0"
+	fmt.Println(phpass.Check(hash, "test12345"))
+	fmt.Println(phpass.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
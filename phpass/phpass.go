@@ -0,0 +1,227 @@
+// Package phpass implements the PHPass portable hashing algorithm for crypt(3).
+//
+// It covers the iterated MD5 hashes used by WordPress and phpBB
+// as well as the iterated SHA-512 hashes used by Drupal 7.
+package phpass
+
+import (
+	"crypto/md5"
+	"crypto/sha512"
+	"crypto/subtle"
+	"hash"
+	"log/slog"
+	"strconv"
+
+	"github.com/sergeymakinen/go-crypt"
+	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/hashutil"
+)
+
+const SaltLength = 8
+
+// InvalidSaltLengthError values describe errors resulting from an invalid length of a salt.
+type InvalidSaltLengthError int
+
+func (e InvalidSaltLengthError) Error() string {
+	return "invalid salt length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidSaltError values describe errors resulting from an invalid character in a hash string.
+type InvalidSaltError byte
+
+func (e InvalidSaltError) Error() string {
+	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in salt"
+}
+
+const (
+	MinCost     = 7
+	MaxCost     = 30
+	DefaultCost = 8
+)
+
+// InvalidCostError values describe errors resulting from an invalid cost.
+type InvalidCostError uint8
+
+func (e InvalidCostError) Error() string {
+	return "invalid cost " + strconv.FormatUint(uint64(e), 10)
+}
+
+const (
+	PrefixP = "$P$" // the PHPass portable hash used by WordPress
+	PrefixH = "$H$" // the PHPass portable hash used by phpBB
+	PrefixS = "$S$" // the SHA-512 variant used by Drupal 7
+)
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+// CompatibilityOptions are the key derivation parameters required to produce keys from old/non-standard hashes.
+type CompatibilityOptions struct {
+	Prefix string
+}
+
+// Key returns a PHPass key derived from the password, salt, cost and compatibility options.
+//
+// The opts parameter is optional. If nil, default options are used.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func Key(password, salt []byte, cost uint8, opts *CompatibilityOptions) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	if opts == nil {
+		opts = &CompatibilityOptions{Prefix: PrefixP}
+	}
+	var h hash.Hash
+	switch opts.Prefix {
+	case PrefixP, PrefixH:
+		h = md5.New()
+	case PrefixS:
+		h = sha512.New()
+	default:
+		return nil, UnsupportedPrefixError(opts.Prefix)
+	}
+	if n := len(salt); n != SaltLength {
+		return nil, InvalidSaltLengthError(n)
+	}
+	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
+		return nil, InvalidSaltError(salt[i])
+	}
+	if cost < MinCost || cost > MaxCost {
+		return nil, InvalidCostError(cost)
+	}
+	h.Write(salt)
+	h.Write(password)
+	b := h.Sum(nil)
+	for i, n := 0, 1<<cost; i < n; i++ {
+		h.Reset()
+		h.Write(b)
+		h.Write(password)
+		b = h.Sum(b[:0])
+	}
+	return b, nil
+}
+
+type hashPrefix string
+
+func (h *hashPrefix) UnmarshalText(text []byte) error {
+	switch s := hashPrefix(text); s {
+	case PrefixP, PrefixH, PrefixS:
+		*h = s
+		return nil
+	default:
+		return UnsupportedPrefixError(s)
+	}
+}
+
+type hashCost uint8
+
+func (h hashCost) MarshalText() ([]byte, error) {
+	return []byte{hashutil.HashEncoding.Encode(byte(h))}, nil
+}
+
+func (h *hashCost) UnmarshalText(text []byte) error {
+	*h = hashCost(hashutil.HashEncoding.Decode(text[0]))
+	return nil
+}
+
+const (
+	md5SumLength    = 22
+	sha512SumLength = 43 // Drupal truncates the whole hash to 55 characters
+)
+
+type scheme struct {
+	HashPrefix hashPrefix
+	Cost       hashCost `hash:"length:1,inline"`
+	Salt       []byte   `hash:"length:8,inline"`
+	Sum        []byte
+}
+
+func encodeKey(key []byte, prefix hashPrefix) []byte {
+	b := make([]byte, crypthash.LittleEndianEncoding.EncodedLen(len(key)))
+	crypthash.LittleEndianEncoding.Encode(b, key)
+	if prefix == PrefixS {
+		return b[:sha512SumLength]
+	}
+	return b[:md5SumLength]
+}
+
+// NewHash returns the crypt(3) PHPass portable hash of the password at the given cost.
+func NewHash(password string, cost uint8) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, cost)
+	})
+}
+
+func newHash(password string, cost uint8) (string, error) {
+	scheme := scheme{
+		HashPrefix: PrefixP,
+		Cost:       hashCost(cost),
+		Salt:       hashutil.HashEncoding.Rand(SaltLength),
+	}
+	key, err := Key([]byte(password), scheme.Salt, uint8(scheme.Cost), &CompatibilityOptions{Prefix: string(scheme.HashPrefix)})
+	if err != nil {
+		return "", err
+	}
+	scheme.Sum = encodeKey(key, scheme.HashPrefix)
+	return crypthash.Marshal(scheme)
+}
+
+// Params returns the hashing salt, cost and compatibility options used to create
+// the given crypt(3) PHPass hash.
+func Params(hash string) (salt []byte, cost uint8, opts *CompatibilityOptions, err error) {
+	var scheme scheme
+	if err = crypthash.Unmarshal(hash, &scheme); err != nil {
+		return
+	}
+	return scheme.Salt, uint8(scheme.Cost), &CompatibilityOptions{Prefix: string(scheme.HashPrefix)}, nil
+}
+
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "phpass",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the prefix and cost of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, cost, opts, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{
+		slog.String("prefix", opts.Prefix),
+		slog.Uint64("cost", uint64(cost)),
+	}
+}
+
+// Check compares the given crypt(3) PHPass hash with a new hash derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
+	var scheme scheme
+	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
+		return err
+	}
+	key, err := Key([]byte(password), scheme.Salt, uint8(scheme.Cost), &CompatibilityOptions{Prefix: string(scheme.HashPrefix)})
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(encodeKey(key, scheme.HashPrefix), scheme.Sum) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+func init() {
+	crypt.Register(PrefixP, hashAlgorithm)
+	crypt.Register(PrefixH, hashAlgorithm)
+	crypt.Register(PrefixS, hashAlgorithm)
+}
@@ -0,0 +1,277 @@
+package phpass
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
+	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		hash     string
+		password string
+		salt     []byte
+		cost     uint8
+		opts     *CompatibilityOptions
+	}{
+		// PHPass
+		{
+			hash:     "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0",
+			password: "test12345",
+			salt:     []byte("IQRaTwmf"),
+			cost:     11,
+			opts:     &CompatibilityOptions{Prefix: PrefixP},
+		},
+
+		// Other
+		{
+			hash:     "$P$BaaaaaaaAYjga9TZPwGrnHWe6swani/",
+			password: "password",
+			salt:     []byte("aaaaaaaA"),
+			cost:     13,
+			opts:     &CompatibilityOptions{Prefix: PrefixP},
+		},
+		{
+			hash:     "$H$9aaaaaaaAYjk7mI8Gz4EXhJUJcZhLA0",
+			password: "password",
+			salt:     []byte("aaaaaaaA"),
+			cost:     11,
+			opts:     &CompatibilityOptions{Prefix: PrefixH},
+		},
+		{
+			hash:     "$S$DaaaaaaaAN4ojSOWxMXayO9/EvteR4r9lGgeOmvogP.EWh6ryPck",
+			password: "password",
+			salt:     []byte("aaaaaaaA"),
+			cost:     15,
+			opts:     &CompatibilityOptions{Prefix: PrefixS},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, test.password); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			salt, cost, opts, err := Params(test.hash)
+			if err != nil {
+				t.Fatalf("Params() = _, _, _, %v; want nil", err)
+			}
+			if !bytes.Equal(salt, test.salt) {
+				t.Errorf("Params() = %v, _, _, _; want %v", salt, test.salt)
+			}
+			if cost != test.cost {
+				t.Errorf("Params() = _, %d, _, _; want %d", cost, test.cost)
+			}
+			if diff := cmp.Diff(test.opts, opts); diff != "" {
+				t.Errorf("Params() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err: &crypthash.UnmarshalTypeError{
+				Value:  "EOF",
+				Type:   testutil.FieldType(scheme{}, "HashPrefix"),
+				Struct: "*phpass.scheme",
+				Field:  "HashPrefix",
+				Msg:    "prefix not found",
+			},
+		},
+		{
+			hash: "$P@$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0",
+			err: &crypthash.UnmarshalTypeError{
+				Value:  "prefix",
+				Type:   testutil.FieldType(scheme{}, "HashPrefix"),
+				Offset: 4,
+				Struct: "*phpass.scheme",
+				Field:  "HashPrefix",
+				Msg:    `unsupported prefix "$P@$"`,
+			},
+		},
+		{
+			hash: "$P$@IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0",
+			err: &crypthash.UnmarshalTypeError{
+				Value:  "value",
+				Type:   testutil.FieldType(scheme{}, "Cost"),
+				Offset: 34,
+				Struct: "*phpass.scheme",
+				Field:  "Cost",
+				Msg:    "invalid character '@'",
+			},
+		},
+		{
+			hash: "$P$9IQRaTw@feRo7ud9Fh4E2PdI0S3r.L0",
+			err: &crypthash.UnmarshalTypeError{
+				Value:  "value",
+				Type:   testutil.FieldType(scheme{}, "Salt"),
+				Offset: 34,
+				Struct: "*phpass.scheme",
+				Field:  "Salt",
+				Msg:    "invalid character '@'",
+			},
+		},
+		{
+			hash: "$P$9IQR",
+			err: &crypthash.UnmarshalTypeError{
+				Value:  "value",
+				Type:   testutil.FieldType(scheme{}, "Salt"),
+				Offset: 7,
+				Struct: "*phpass.scheme",
+				Field:  "Salt",
+				Msg:    "length mismatch",
+			},
+		},
+		{
+			hash: "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L@",
+			err: &crypthash.UnmarshalTypeError{
+				Value:  "value",
+				Type:   testutil.FieldType(scheme{}, "Sum"),
+				Offset: 34,
+				Struct: "*phpass.scheme",
+				Field:  "Sum",
+				Msg:    "invalid character '@'",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+			if _, _, _, err := Params(test.hash); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Params() = _, _, _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		salt []byte
+		cost uint8
+		opts *CompatibilityOptions
+		key  string
+	}{
+		{
+			salt: []byte("aaaaaaab"),
+			cost: 13,
+			opts: nil,
+			key:  "Sl800wV4xOg67aPxF9C2.0",
+		},
+		{
+			salt: []byte("aaaaaaaa"),
+			cost: 14,
+			opts: &CompatibilityOptions{Prefix: PrefixP},
+			key:  "PKK10xAgGSURlY3BE1g.e1",
+		},
+		{
+			salt: []byte("aaaaaaaA"),
+			cost: 14,
+			opts: &CompatibilityOptions{Prefix: PrefixS},
+			key:  "AdSR3aBQdnuPBJ9pN962swBAy3Whg5GjgcG840NwZCK",
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("salt=%s;cost=%d;opts=%v", test.salt, test.cost, test.opts), func(t *testing.T) {
+			key, err := Key([]byte("password"), test.salt, test.cost, test.opts)
+			if err != nil {
+				t.Fatalf("Key() = _, %v; want nil", err)
+			}
+			if encKey := crypthash.LittleEndianEncoding.EncodeToString(key); encKey[:len(test.key)] != test.key {
+				t.Errorf("Key() = %q, _; want %q", encKey, test.key)
+			}
+		})
+	}
+}
+
+func TestKeyShouldFail(t *testing.T) {
+	tests := []struct {
+		password, salt []byte
+		cost           uint8
+		opts           *CompatibilityOptions
+		err            error
+	}{
+		{
+			password: []byte("password"),
+			salt:     []byte("aaaaaaaa"),
+			cost:     DefaultCost,
+			opts:     &CompatibilityOptions{Prefix: "$Q$"},
+			err:      UnsupportedPrefixError("$Q$"),
+		},
+		{
+			password: []byte("password"),
+			salt:     []byte("aaa"),
+			cost:     DefaultCost,
+			err:      InvalidSaltLengthError(3),
+		},
+		{
+			password: []byte("password"),
+			salt:     []byte("aaaaaaa@"),
+			cost:     DefaultCost,
+			err:      InvalidSaltError('@'),
+		},
+		{
+			password: []byte("password"),
+			salt:     []byte("aaaaaaaa"),
+			cost:     MinCost - 1,
+			err:      InvalidCostError(MinCost - 1),
+		},
+		{
+			password: []byte("password"),
+			salt:     []byte("aaaaaaaa"),
+			cost:     MaxCost + 1,
+			err:      InvalidCostError(MaxCost + 1),
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("password=%s;salt=%s;cost=%d;opts=%v", test.password, test.salt, test.cost, test.opts), func(t *testing.T) {
+			if _, err := Key(test.password, test.salt, test.cost, test.opts); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Key() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	hash, err := NewHash("password", DefaultCost)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err := Check(hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	var schema scheme
+	if err := crypthash.Unmarshal(hash, &schema); err != nil {
+		t.Fatalf("crypthash.Unmarshal() = %v; want nil", err)
+	}
+	if diff := cmp.Diff(scheme{HashPrefix: PrefixP, Cost: DefaultCost}, schema, cmp.Comparer(func(x, y scheme) bool {
+		return x.HashPrefix == y.HashPrefix && x.Cost == y.Cost
+	})); diff != "" {
+		t.Errorf("crypthash.Unmarshal() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	if err := Check("$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0", "test12345"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("Check() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err := NewHash("password", DefaultCost); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewHash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
@@ -0,0 +1,45 @@
+package postgres_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/postgres"
+)
+
+func ExampleParams() {
+	salt, iterations, _ := postgres.Params("SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU=")
+	fmt.Println(string(salt))
+	fmt.Println(iterations)
+	// Output:
+	// W22ZaJ0SNY7soEsUEjb6gQ==
+	// 4096
+}
+
+func ExampleCheck() {
+	hash := "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU="
+	fmt.Println(postgres.Check(hash, "pencil"))
+	fmt.Println(postgres.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
+
+func ExampleCheckMD5() {
+	hash := "md532e12f215ba27cb750c9e093ce4b5127"
+	fmt.Println(postgres.CheckMD5(hash, "postgres", "password"))
+	fmt.Println(postgres.CheckMD5(hash, "postgres", "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
+
+func ExampleServerConversation() {
+	hash := "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU="
+	c, _ := postgres.NewServerConversation(hash, nil)
+	serverFirst, err := c.ClientFirst("n,,n=,r=rOprNGfwEbeRWgbNEkqO")
+	fmt.Println(err)
+	fmt.Println(serverFirst[:22])
+	// Output:
+	// <nil>
+	// r=rOprNGfwEbeRWgbNEkqO
+}
@@ -0,0 +1,291 @@
+// Package postgres implements the PostgreSQL SCRAM-SHA-256 and MD5 password verifiers
+// and the server side of a SCRAM-SHA-256 SASL exchange.
+//
+// The password is used as is: unlike PostgreSQL, SASLprep is not applied to it,
+// so non-ASCII passwords must be normalized by the caller.
+package postgres
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+const (
+	MinSaltLength     = 1
+	DefaultSaltLength = 16
+)
+
+// InvalidSaltLengthError values describe errors resulting from an invalid length of a salt.
+type InvalidSaltLengthError int
+
+func (e InvalidSaltLengthError) Error() string {
+	return "invalid salt length " + strconv.FormatInt(int64(e), 10)
+}
+
+// InvalidSaltError values describe errors resulting from an invalid character in a hash string.
+type InvalidSaltError byte
+
+func (e InvalidSaltError) Error() string {
+	return "invalid character " + strconv.QuoteRuneToASCII(rune(e)) + " in salt"
+}
+
+const (
+	MinIterations     = 1
+	DefaultIterations = 4096
+)
+
+// InvalidIterationsError values describe errors resulting from an invalid iteration count.
+type InvalidIterationsError uint32
+
+func (e InvalidIterationsError) Error() string {
+	return "invalid iteration count " + strconv.FormatUint(uint64(e), 10)
+}
+
+const (
+	Prefix    = "SCRAM-SHA-256"
+	PrefixMD5 = "md5"
+)
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+// Key returns a SCRAM-SHA-256 salted password derived from the password, salt and iterations.
+// The salt is expected to be base64-encoded.
+func Key(password, salt []byte, iterations uint32) ([]byte, error) {
+	decSalt, err := decodeSalt(salt)
+	if err != nil {
+		return nil, err
+	}
+	if iterations < MinIterations {
+		return nil, InvalidIterationsError(iterations)
+	}
+	return pbkdf2.Key(sha256.New, string(password), decSalt, int(iterations), sha256.Size)
+}
+
+func decodeSalt(salt []byte) ([]byte, error) {
+	b := make([]byte, base64.StdEncoding.DecodedLen(len(salt)))
+	n, err := base64.StdEncoding.Decode(b, salt)
+	if err != nil {
+		if i := int(err.(base64.CorruptInputError)); i < len(salt) && salt[i] != '=' {
+			return nil, InvalidSaltError(salt[i])
+		}
+		return nil, InvalidSaltLengthError(len(salt))
+	}
+	if n < MinSaltLength {
+		return nil, InvalidSaltLengthError(len(salt))
+	}
+	return b[:n], nil
+}
+
+func clientKey(saltedPassword []byte) []byte {
+	return hmacSum(saltedPassword, []byte("Client Key"))
+}
+
+func serverKey(saltedPassword []byte) []byte {
+	return hmacSum(saltedPassword, []byte("Server Key"))
+}
+
+func hmacSum(key, b []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+type scheme struct {
+	Iterations uint32
+	Salt       []byte
+	StoredKey  []byte
+	ServerKey  []byte
+}
+
+func (s *scheme) String() string {
+	return Prefix + "$" + strconv.FormatUint(uint64(s.Iterations), 10) + ":" + string(s.Salt) + "$" +
+		base64.StdEncoding.EncodeToString(s.StoredKey) + ":" + base64.StdEncoding.EncodeToString(s.ServerKey)
+}
+
+// SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>
+func parseHash(hash string) (*scheme, error) {
+	if !strings.HasPrefix(hash, Prefix+"$") {
+		if i := strings.IndexByte(hash, '$'); i >= 0 {
+			return nil, UnsupportedPrefixError(hash[:i])
+		}
+		return nil, UnsupportedPrefixError(hash[:min(len(hash), len(Prefix))])
+	}
+	s := hash[len(Prefix)+1:]
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "missing salt"}
+	}
+	iterations, err := strconv.ParseUint(s[:i], 10, 32)
+	if err != nil {
+		return nil, &parse.SyntaxError{Offset: len(hash) - len(s) + i, Msg: "invalid iteration count"}
+	}
+	s = s[i+1:]
+	i = strings.IndexByte(s, '$')
+	if i < 0 {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "missing keys"}
+	}
+	salt := s[:i]
+	s = s[i+1:]
+	i = strings.IndexByte(s, ':')
+	if i < 0 {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "missing server key"}
+	}
+	storedKey, err := base64.StdEncoding.DecodeString(s[:i])
+	if err != nil || len(storedKey) != sha256.Size {
+		return nil, &parse.SyntaxError{Offset: len(hash) - len(s) + i, Msg: "invalid stored key"}
+	}
+	serverKey, err := base64.StdEncoding.DecodeString(s[i+1:])
+	if err != nil || len(serverKey) != sha256.Size {
+		return nil, &parse.SyntaxError{Offset: len(hash), Msg: "invalid server key"}
+	}
+	return &scheme{
+		Iterations: uint32(iterations),
+		Salt:       []byte(salt),
+		StoredKey:  storedKey,
+		ServerKey:  serverKey,
+	}, nil
+}
+
+// NewHash returns the SCRAM-SHA-256 verifier of the password with the given iterations.
+func NewHash(password string, iterations uint32) (string, error) {
+	return crypt.ObserveNewHash("postgres", func() (string, error) {
+		return newHash(password, iterations)
+	}, hookParams)
+}
+
+func newHash(password string, iterations uint32) (string, error) {
+	scheme := scheme{
+		Iterations: iterations,
+		Salt:       []byte(base64.StdEncoding.EncodeToString(cryptoutil.Rand(DefaultSaltLength))),
+	}
+	key, err := Key([]byte(password), scheme.Salt, scheme.Iterations)
+	if err != nil {
+		return "", err
+	}
+	scheme.StoredKey = sha256Sum(clientKey(key))
+	scheme.ServerKey = serverKey(key)
+	return scheme.String(), nil
+}
+
+func sha256Sum(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	return sum[:]
+}
+
+// Params returns the hashing salt and iterations used to create
+// the given SCRAM-SHA-256 verifier.
+func Params(hash string) (salt []byte, iterations uint32, err error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return
+	}
+	return scheme.Salt, scheme.Iterations, nil
+}
+
+// hookParams returns the iterations of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, iterations, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.Uint64("iterations", uint64(iterations))}
+}
+
+// Check compares the given SCRAM-SHA-256 verifier with a new verifier derived from the password.
+// Returns nil on success, or an error on failure.
+func Check(hash, password string) error {
+	return crypt.ObserveCheck("postgres", hash, password, check, hookParams)
+}
+
+func check(hash, password string) error {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return err
+	}
+	key, err := Key([]byte(password), scheme.Salt, scheme.Iterations)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(sha256Sum(clientKey(key)), scheme.StoredKey) == 0 ||
+		subtle.ConstantTimeCompare(serverKey(key), scheme.ServerKey) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
+
+// MD5Key returns a MD5 key derived from the password and username.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func MD5Key(password, username []byte) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	h := md5.New()
+	h.Write(password)
+	h.Write(username)
+	return h.Sum(nil), nil
+}
+
+const md5SumLength = 32
+
+// NewMD5Hash returns the MD5 verifier of the password for the given username.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func NewMD5Hash(password, username string) (string, error) {
+	return crypt.ObserveNewHash("postgres", func() (string, error) {
+		return newMD5Hash(password, username)
+	}, nil)
+}
+
+func newMD5Hash(password, username string) (string, error) {
+	key, err := MD5Key([]byte(password), []byte(username))
+	if err != nil {
+		return "", err
+	}
+	return PrefixMD5 + hex.EncodeToString(key), nil
+}
+
+// CheckMD5 compares the given MD5 verifier with a new verifier derived from the password and username.
+// Returns nil on success, or an error on failure.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func CheckMD5(hash, username, password string) error {
+	return crypt.ObserveCheck("postgres", hash, password, func(hash, password string) error {
+		return checkMD5(hash, username, password)
+	}, nil)
+}
+
+func checkMD5(hash, username, password string) error {
+	if !strings.HasPrefix(hash, PrefixMD5) {
+		return UnsupportedPrefixError(hash[:min(len(hash), len(PrefixMD5))])
+	}
+	if n := len(hash) - len(PrefixMD5); n != md5SumLength {
+		return &parse.SyntaxError{Offset: len(hash), Msg: "length mismatch"}
+	}
+	sum, err := hex.DecodeString(hash[len(PrefixMD5):])
+	if err != nil {
+		return &parse.SyntaxError{Offset: len(hash), Msg: err.Error()}
+	}
+	key, err := MD5Key([]byte(password), []byte(username))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, sum) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
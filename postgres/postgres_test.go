@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		hash       string
+		password   string
+		salt       []byte
+		iterations uint32
+	}{
+		// RFC 7677
+		{
+			hash:       "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU=",
+			password:   "pencil",
+			salt:       []byte("W22ZaJ0SNY7soEsUEjb6gQ=="),
+			iterations: 4096,
+		},
+
+		// Other
+		{
+			hash:       "SCRAM-SHA-256$4096:MDEyMzQ1Njc4OWFiY2RlZg==$wjGCKoCIcEWiPxSG7t/wnb/YICMEFr1JZNZSKNje12g=:6NG/vkzOjK2oyl11qeNEBeKuOY3QQ4atswXYbIBO79Q=",
+			password:   "password",
+			salt:       []byte("MDEyMzQ1Njc4OWFiY2RlZg=="),
+			iterations: 4096,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, test.password); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(test.hash, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+			salt, iterations, err := Params(test.hash)
+			if err != nil {
+				t.Fatalf("Params() = _, _, %v; want nil", err)
+			}
+			if !bytes.Equal(salt, test.salt) {
+				t.Errorf("Params() = %v, _, _; want %v", salt, test.salt)
+			}
+			if iterations != test.iterations {
+				t.Errorf("Params() = _, %d, _; want %d", iterations, test.iterations)
+			}
+		})
+	}
+}
+
+func TestParseShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  UnsupportedPrefixError(""),
+		},
+		{
+			hash: "SCRAM-SHA-1$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU=",
+			err:  UnsupportedPrefixError("SCRAM-SHA-1"),
+		},
+		{
+			hash: "SCRAM-SHA-256$4096",
+			err:  &parse.SyntaxError{Offset: 18, Msg: "missing salt"},
+		},
+		{
+			hash: "SCRAM-SHA-256$409@:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU=",
+			err:  &parse.SyntaxError{Offset: 18, Msg: "invalid iteration count"},
+		},
+		{
+			hash: "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==",
+			err:  &parse.SyntaxError{Offset: 43, Msg: "missing keys"},
+		},
+		{
+			hash: "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=",
+			err:  &parse.SyntaxError{Offset: 88, Msg: "missing server key"},
+		},
+		{
+			hash: "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4q=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU=",
+			err:  &parse.SyntaxError{Offset: 87, Msg: "invalid stored key"},
+		},
+		{
+			hash: "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2d@=",
+			err:  &parse.SyntaxError{Offset: 133, Msg: "invalid server key"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+			if _, _, err := Params(test.hash); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Params() = _, _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestKeyShouldFail(t *testing.T) {
+	tests := []struct {
+		password, salt []byte
+		iterations     uint32
+		err            error
+	}{
+		{
+			password:   []byte("password"),
+			salt:       []byte(""),
+			iterations: DefaultIterations,
+			err:        InvalidSaltLengthError(0),
+		},
+		{
+			password:   []byte("password"),
+			salt:       []byte("W22ZaJ0SNY7soEsUEjb6gQ="),
+			iterations: DefaultIterations,
+			err:        InvalidSaltLengthError(23),
+		},
+		{
+			password:   []byte("password"),
+			salt:       []byte("W22ZaJ0SNY7soEsUEjb6g@=="),
+			iterations: DefaultIterations,
+			err:        InvalidSaltError('@'),
+		},
+		{
+			password:   []byte("password"),
+			salt:       []byte("W22ZaJ0SNY7soEsUEjb6gQ=="),
+			iterations: MinIterations - 1,
+			err:        InvalidIterationsError(MinIterations - 1),
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("password=%s;salt=%s;iterations=%d", test.password, test.salt, test.iterations), func(t *testing.T) {
+			if _, err := Key(test.password, test.salt, test.iterations); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Key() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	hash, err := NewHash("password", DefaultIterations)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err := Check(hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	salt, iterations, err := Params(hash)
+	if err != nil {
+		t.Fatalf("Params() = _, _, %v; want nil", err)
+	}
+	if n := len(salt); n != 24 {
+		t.Errorf("len(salt) = %d; want 24", n)
+	}
+	if iterations != DefaultIterations {
+		t.Errorf("Params() = _, %d, _; want %d", iterations, DefaultIterations)
+	}
+}
+
+func TestCheckMD5(t *testing.T) {
+	if err := CheckMD5("md532e12f215ba27cb750c9e093ce4b5127", "postgres", "password"); err != nil {
+		t.Errorf("CheckMD5() = %v; want nil", err)
+	}
+	if err := CheckMD5("md532e12f215ba27cb750c9e093ce4b5127", "postgres", "test"); err != crypt.ErrPasswordMismatch {
+		t.Errorf("CheckMD5() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+	hash, err := NewMD5Hash("password", "postgres")
+	if err != nil {
+		t.Fatalf("NewMD5Hash() = _, %v; want nil", err)
+	}
+	if expected := "md532e12f215ba27cb750c9e093ce4b5127"; hash != expected {
+		t.Errorf("NewMD5Hash() = %q, _; want %q", hash, expected)
+	}
+}
+
+func TestCheckMD5ShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "",
+			err:  UnsupportedPrefixError(""),
+		},
+		{
+			hash: "sha32e12f215ba27cb750c9e093ce4b5127",
+			err:  UnsupportedPrefixError("sha"),
+		},
+		{
+			hash: "md532e12f215ba27cb750c9e093ce4b512",
+			err:  &parse.SyntaxError{Offset: 34, Msg: "length mismatch"},
+		},
+		{
+			hash: "md532e12f215ba27cb750c9e093ce4b512@",
+			err:  &parse.SyntaxError{Offset: 35, Msg: "encoding/hex: invalid byte: U+0040 '@'"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := CheckMD5(test.hash, "postgres", "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("CheckMD5() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	hash, err := NewHash("password", MinIterations)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err = Check(hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err = CheckMD5("md532e12f215ba27cb750c9e093ce4b5127", "postgres", "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("CheckMD5() = %v; want %v", err, crypt.ErrNotApproved)
+	}
+	if _, err = NewMD5Hash("password", "postgres"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+		t.Errorf("NewMD5Hash() = _, %v; want %v", err, crypt.ErrNotApproved)
+	}
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+// Mechanism names of the SCRAM-SHA-256 SASL exchange.
+const (
+	Mechanism     = "SCRAM-SHA-256"
+	MechanismPlus = "SCRAM-SHA-256-PLUS"
+)
+
+// ChannelBindingType is the only channel binding type supported by PostgreSQL.
+const ChannelBindingType = "tls-server-end-point"
+
+// ProtocolError values describe errors resulting from a malformed or unexpected SCRAM message.
+type ProtocolError string
+
+func (e ProtocolError) Error() string {
+	return "malformed SCRAM message: " + string(e)
+}
+
+// ErrChannelBinding is returned when the client and server disagree on channel binding.
+var ErrChannelBinding = errors.New("channel binding negotiation failed")
+
+const nonceLength = 18
+
+type conversationState int
+
+const (
+	stateClientFirst conversationState = iota
+	stateClientFinal
+	stateDone
+)
+
+// ServerConversation is the server side of a SCRAM-SHA-256 SASL exchange
+// performed against a stored SCRAM-SHA-256 verifier.
+//
+// A ServerConversation must not be reused for several exchanges.
+type ServerConversation struct {
+	// Username is the user name sent by the client. PostgreSQL clients usually
+	// leave it empty and rely on the user name from the startup message.
+	Username string
+
+	scheme         *scheme
+	channelBinding []byte
+	state          conversationState
+	randNonce      func() string
+
+	gs2Header, nonce             string
+	clientFirstBare, serverFirst string
+}
+
+// NewServerConversation returns a new ServerConversation for the given SCRAM-SHA-256 verifier.
+//
+// The channelBinding parameter is optional. If not nil, it's the tls-server-end-point
+// channel binding data of the connection and the client is allowed to use SCRAM-SHA-256-PLUS.
+func NewServerConversation(hash string, channelBinding []byte) (*ServerConversation, error) {
+	scheme, err := parseHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &ServerConversation{
+		scheme:         scheme,
+		channelBinding: channelBinding,
+		randNonce: func() string {
+			return base64.StdEncoding.EncodeToString(cryptoutil.Rand(nonceLength))
+		},
+	}, nil
+}
+
+// ClientFirst processes the client-first-message and returns the server-first-message.
+func (c *ServerConversation) ClientFirst(msg string) (string, error) {
+	if c.state != stateClientFirst {
+		return "", ProtocolError("unexpected client-first-message")
+	}
+	c.state = stateDone
+	// gs2-header = gs2-cbind-flag "," [ authzid ] ","
+	var cbindFlag string
+	if i := strings.IndexByte(msg, ','); i >= 0 {
+		cbindFlag, msg = msg[:i], msg[i+1:]
+	} else {
+		return "", ProtocolError("missing channel binding flag")
+	}
+	switch {
+	case cbindFlag == "n":
+	case cbindFlag == "y":
+		// The client supports channel binding but thinks the server doesn't
+		if c.channelBinding != nil {
+			return "", ErrChannelBinding
+		}
+	case cbindFlag == "p="+ChannelBindingType:
+		if c.channelBinding == nil {
+			return "", ErrChannelBinding
+		}
+	default:
+		return "", ProtocolError("unsupported channel binding flag " + strconv.Quote(cbindFlag))
+	}
+	i := strings.IndexByte(msg, ',')
+	if i < 0 {
+		return "", ProtocolError("missing authorization identity")
+	}
+	if msg[:i] != "" {
+		return "", ProtocolError("authorization identity is not supported")
+	}
+	c.gs2Header = cbindFlag + ",," // no authzid
+	c.clientFirstBare = msg[i+1:]
+	attrs, err := parseAttrs(c.clientFirstBare)
+	if err != nil {
+		return "", err
+	}
+	// client-first-message-bare = [reserved-mext ","] username "," nonce ["," extensions]
+	if attrs[0].Name == 'm' {
+		return "", ProtocolError("mandatory extensions are not supported")
+	}
+	if len(attrs) < 2 || attrs[0].Name != 'n' || attrs[1].Name != 'r' {
+		return "", ProtocolError("expected username and nonce")
+	}
+	if attrs[1].Value == "" {
+		return "", ProtocolError("empty nonce")
+	}
+	c.Username = decodeSASLName(attrs[0].Value)
+	c.nonce = attrs[1].Value + c.randNonce()
+	c.serverFirst = "r=" + c.nonce + ",s=" + string(c.scheme.Salt) + ",i=" + strconv.FormatUint(uint64(c.scheme.Iterations), 10)
+	c.state = stateClientFinal
+	return c.serverFirst, nil
+}
+
+// ClientFinal processes the client-final-message and returns the server-final-message.
+// If the client proof is invalid, ClientFinal returns crypt.ErrPasswordMismatch.
+func (c *ServerConversation) ClientFinal(msg string) (string, error) {
+	if c.state != stateClientFinal {
+		return "", ProtocolError("unexpected client-final-message")
+	}
+	c.state = stateDone
+	i := strings.LastIndex(msg, ",p=")
+	if i < 0 {
+		return "", ProtocolError("missing proof")
+	}
+	withoutProof := msg[:i]
+	proof, err := base64.StdEncoding.DecodeString(msg[i+len(",p="):])
+	if err != nil || len(proof) != len(c.scheme.StoredKey) {
+		return "", ProtocolError("invalid proof")
+	}
+	attrs, err := parseAttrs(withoutProof)
+	if err != nil {
+		return "", err
+	}
+	if len(attrs) < 2 || attrs[0].Name != 'c' || attrs[1].Name != 'r' {
+		return "", ProtocolError("expected channel binding and nonce")
+	}
+	cbind := []byte(c.gs2Header)
+	if strings.HasPrefix(c.gs2Header, "p=") {
+		cbind = append(cbind, c.channelBinding...)
+	}
+	if attrs[0].Value != base64.StdEncoding.EncodeToString(cbind) {
+		return "", ErrChannelBinding
+	}
+	if attrs[1].Value != c.nonce {
+		return "", ProtocolError("nonce mismatch")
+	}
+	authMessage := []byte(c.clientFirstBare + "," + c.serverFirst + "," + withoutProof)
+	key := hmacSum(c.scheme.StoredKey, authMessage)
+	for i := range key {
+		key[i] ^= proof[i]
+	}
+	if subtle.ConstantTimeCompare(sha256Sum(key), c.scheme.StoredKey) == 0 {
+		return "", crypt.ErrPasswordMismatch
+	}
+	return "v=" + base64.StdEncoding.EncodeToString(hmacSum(c.scheme.ServerKey, authMessage)), nil
+}
+
+type attr struct {
+	Name  byte
+	Value string
+}
+
+func parseAttrs(s string) ([]attr, error) {
+	var attrs []attr
+	for _, part := range strings.Split(s, ",") {
+		if len(part) < 2 || part[1] != '=' {
+			return nil, ProtocolError("invalid attribute " + strconv.Quote(part))
+		}
+		attrs = append(attrs, attr{Name: part[0], Value: part[2:]})
+	}
+	return attrs, nil
+}
+
+var saslNameReplacer = strings.NewReplacer("=2C", ",", "=3D", "=")
+
+func decodeSASLName(s string) string {
+	return saslNameReplacer.Replace(s)
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+const rfc7677Hash = "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU="
+
+func newTestConversation(t *testing.T, channelBinding []byte) *ServerConversation {
+	c, err := NewServerConversation(rfc7677Hash, channelBinding)
+	if err != nil {
+		t.Fatalf("NewServerConversation() = _, %v; want nil", err)
+	}
+	c.randNonce = func() string {
+		return "%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	}
+	return c
+}
+
+func TestServerConversation(t *testing.T) {
+	c := newTestConversation(t, nil)
+	serverFirst, err := c.ClientFirst("n,,n=user,r=rOprNGfwEbeRWgbNEkqO")
+	if err != nil {
+		t.Fatalf("ClientFirst() = _, %v; want nil", err)
+	}
+	if expected := "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"; serverFirst != expected {
+		t.Errorf("ClientFirst() = %q, _; want %q", serverFirst, expected)
+	}
+	if expected := "user"; c.Username != expected {
+		t.Errorf("Username = %q; want %q", c.Username, expected)
+	}
+	serverFinal, err := c.ClientFinal("c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=")
+	if err != nil {
+		t.Fatalf("ClientFinal() = _, %v; want nil", err)
+	}
+	if expected := "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="; serverFinal != expected {
+		t.Errorf("ClientFinal() = %q, _; want %q", serverFinal, expected)
+	}
+	if _, err := c.ClientFinal("c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="); err == nil {
+		t.Error("ClientFinal() = _, nil; want error")
+	}
+}
+
+func TestServerConversationExtensions(t *testing.T) {
+	c := newTestConversation(t, nil)
+	// Optional extensions follow the nonce and are ignored, even if named "m"
+	if _, err := c.ClientFirst("n,,n=user,r=rOprNGfwEbeRWgbNEkqO,m=foo,x=bar"); err != nil {
+		t.Fatalf("ClientFirst() = _, %v; want nil", err)
+	}
+	if expected := "user"; c.Username != expected {
+		t.Errorf("Username = %q; want %q", c.Username, expected)
+	}
+}
+
+func TestServerConversationChannelBinding(t *testing.T) {
+	cbindData := sha256.Sum256([]byte("certificate"))
+	c := newTestConversation(t, cbindData[:])
+	serverFirst, err := c.ClientFirst("p=tls-server-end-point,,n=,r=rOprNGfwEbeRWgbNEkqO")
+	if err != nil {
+		t.Fatalf("ClientFirst() = _, %v; want nil", err)
+	}
+	saltedPassword, err := Key([]byte("pencil"), []byte("W22ZaJ0SNY7soEsUEjb6gQ=="), 4096)
+	if err != nil {
+		t.Fatalf("Key() = _, %v; want nil", err)
+	}
+	cbind := base64.StdEncoding.EncodeToString(append([]byte("p=tls-server-end-point,,"), cbindData[:]...))
+	withoutProof := "c=" + cbind + ",r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	authMessage := []byte("n=,r=rOprNGfwEbeRWgbNEkqO," + serverFirst + "," + withoutProof)
+	proof := clientKey(saltedPassword)
+	signature := hmacSum(sha256Sum(proof), authMessage)
+	for i := range proof {
+		proof[i] ^= signature[i]
+	}
+	serverFinal, err := c.ClientFinal(withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof))
+	if err != nil {
+		t.Fatalf("ClientFinal() = _, %v; want nil", err)
+	}
+	if expected := "v=" + base64.StdEncoding.EncodeToString(hmacSum(serverKey(saltedPassword), authMessage)); serverFinal != expected {
+		t.Errorf("ClientFinal() = %q, _; want %q", serverFinal, expected)
+	}
+}
+
+func TestServerConversationShouldFail(t *testing.T) {
+	tests := []struct {
+		name                     string
+		channelBinding           []byte
+		clientFirst, clientFinal string
+		err                      error
+	}{
+		{
+			name:        "invalid proof",
+			clientFirst: "n,,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			clientFinal: "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=AHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=",
+			err:         crypt.ErrPasswordMismatch,
+		},
+		{
+			name:        "nonce mismatch",
+			clientFirst: "n,,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			clientFinal: "c=biws,r=rOprNGfwEbeRWgbNEkqO,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=",
+			err:         ProtocolError("nonce mismatch"),
+		},
+		{
+			name:        "channel binding mismatch",
+			clientFirst: "n,,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			clientFinal: "c=eSws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=",
+			err:         ErrChannelBinding,
+		},
+		{
+			name:        "missing proof",
+			clientFirst: "n,,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			clientFinal: "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0",
+			err:         ProtocolError("missing proof"),
+		},
+		{
+			name:        "unsupported channel binding",
+			clientFirst: "p=tls-server-end-point,,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			err:         ErrChannelBinding,
+		},
+		{
+			name:           "downgrade",
+			channelBinding: []byte("certificate"),
+			clientFirst:    "y,,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			err:            ErrChannelBinding,
+		},
+		{
+			name:        "unknown channel binding flag",
+			clientFirst: "x,,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			err:         ProtocolError(`unsupported channel binding flag "x"`),
+		},
+		{
+			name:        "authzid",
+			clientFirst: "n,a=admin,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			err:         ProtocolError("authorization identity is not supported"),
+		},
+		{
+			name:        "missing nonce",
+			clientFirst: "n,,n=user",
+			err:         ProtocolError("expected username and nonce"),
+		},
+		{
+			name:        "mandatory extension",
+			clientFirst: "n,,m=foo,n=user,r=rOprNGfwEbeRWgbNEkqO",
+			err:         ProtocolError("mandatory extensions are not supported"),
+		},
+		{
+			name:        "invalid attribute",
+			clientFirst: "n,,n=user,rOprNGfwEbeRWgbNEkqO",
+			err:         ProtocolError(`invalid attribute "rOprNGfwEbeRWgbNEkqO"`),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestConversation(t, test.channelBinding)
+			_, err := c.ClientFirst(test.clientFirst)
+			if test.clientFinal == "" {
+				if !testutil.IsEqualError(err, test.err) {
+					t.Errorf("ClientFirst() = _, %v; want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ClientFirst() = _, %v; want nil", err)
+			}
+			if _, err := c.ClientFinal(test.clientFinal); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("ClientFinal() = _, %v; want %v", err, test.err)
+			}
+		})
+	}
+}
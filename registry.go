@@ -0,0 +1,339 @@
+package crypt
+
+import (
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+// ErrHashNotAllowed is returned when the hash is registered
+// but not allowed by the allow or deny list of a registry.
+var ErrHashNotAllowed = errors.New("hash not allowed")
+
+// RegistryOptions are the options of a registry.
+type RegistryOptions struct {
+	// Parent is the registry consulted for the hashes and methods
+	// not registered in the registry, typically DefaultRegistry.
+	// The allow and deny lists of the parent apply too.
+	Parent *Registry
+
+	// Allow is the list of the prefixes of the allowed hashes, as passed to Register,
+	// or their algorithms, as registered with RegisterMatcher.
+	// If empty, all hashes not denied are allowed.
+	// The DES hash has the empty prefix.
+	Allow []string
+
+	// Deny is the list of the prefixes or algorithms of the denied hashes, like Allow.
+	Deny []string
+}
+
+type hashMatcher struct {
+	match func(hash string) bool
+	hash  *Hash
+}
+
+// Registry is a set of hashes for use by Check
+// and hashing methods for use by Crypt, GenSalt and CheckSalt,
+// optionally restricted with allow and deny lists.
+//
+// The zero value is an empty registry without a parent and restrictions.
+type Registry struct {
+	hashes  sync.Map // map[string]*Hash
+	methods sync.Map // map[string]*Method
+
+	matchersMu sync.RWMutex
+	matchers   []hashMatcher
+
+	parent *Registry
+	allow  map[string]bool
+	deny   map[string]bool
+
+	dummies sync.Map // map[Policy]string
+}
+
+// DefaultRegistry is the registry used by the package-level functions.
+// The hash packages register their hashes in it when initialized.
+var DefaultRegistry = &Registry{}
+
+// NewRegistry returns a new registry with the given options.
+//
+// The opts parameter is optional. If nil, an empty registry is returned.
+func NewRegistry(opts *RegistryOptions) *Registry {
+	r := &Registry{}
+	if opts == nil {
+		return r
+	}
+	r.parent = opts.Parent
+	if len(opts.Allow) > 0 {
+		r.allow = make(map[string]bool, len(opts.Allow))
+		for _, prefix := range opts.Allow {
+			r.allow[prefix] = true
+		}
+	}
+	if len(opts.Deny) > 0 {
+		r.deny = make(map[string]bool, len(opts.Deny))
+		for _, prefix := range opts.Deny {
+			r.deny[prefix] = true
+		}
+	}
+	return r
+}
+
+// RegisterHash is like the package-level RegisterHash but registers the hash in the registry.
+func (r *Registry) RegisterHash(prefix string, check func(hash, password string) error) {
+	r.Register(prefix, &Hash{
+		Algorithm: "crypt",
+		Check:     check,
+		Params: func(string) []slog.Attr {
+			return []slog.Attr{slog.String("prefix", prefix)}
+		},
+	})
+}
+
+// Register is like the package-level Register but registers the hash in the registry.
+func (r *Registry) Register(prefix string, h *Hash) {
+	r.hashes.Store(prefix, h)
+}
+
+// RegisterMatcher is like the package-level RegisterMatcher but registers the hash in the registry.
+// Matchers of the registry are consulted before the ones of its parent.
+func (r *Registry) RegisterMatcher(match func(hash string) bool, h *Hash) {
+	r.matchersMu.Lock()
+	defer r.matchersMu.Unlock()
+	r.matchers = append(r.matchers, hashMatcher{match: match, hash: h})
+}
+
+// RegisterMethod is like the package-level RegisterMethod but registers the method in the registry.
+func (r *Registry) RegisterMethod(prefix string, method *Method) {
+	r.methods.Store(prefix, method)
+}
+
+// allowed reports whether the hash with the prefix or matcher algorithm is allowed
+// by the registry and its parents.
+func (r *Registry) allowed(id string) bool {
+	for ; r != nil; r = r.parent {
+		if r.deny[id] || (r.allow != nil && !r.allow[id]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Registry) loadHash(prefix string) (*Hash, bool) {
+	for ; r != nil; r = r.parent {
+		if h, ok := r.hashes.Load(prefix); ok {
+			return h.(*Hash), true
+		}
+	}
+	return nil, false
+}
+
+func (r *Registry) loadMethod(prefix string) (*Method, bool) {
+	for ; r != nil; r = r.parent {
+		if m, ok := r.methods.Load(prefix); ok {
+			return m.(*Method), true
+		}
+	}
+	return nil, false
+}
+
+func (r *Registry) matchHash(hash string) (*Hash, bool) {
+	for ; r != nil; r = r.parent {
+		r.matchersMu.RLock()
+		for _, m := range r.matchers {
+			if m.match(hash) {
+				r.matchersMu.RUnlock()
+				return m.hash, true
+			}
+		}
+		r.matchersMu.RUnlock()
+	}
+	return nil, false
+}
+
+// hashPrefix returns the prefix of the hash as used by RegisterHash.
+// The "{id}" prefix is returned only if it is registered.
+// It returns false if the hash has an empty "$" prefix.
+func (r *Registry) hashPrefix(hash string) (prefix string, ok bool) {
+	switch {
+	case strings.HasPrefix(hash, "$"):
+		if i := strings.IndexAny(hash[1:], "$,"); i > 0 {
+			return hash[:i+2], true
+		}
+		return "", false
+	case strings.HasPrefix(hash, "_"), strings.HasPrefix(hash, "*"):
+		return hash[:1], true
+	case strings.HasPrefix(hash, "{"):
+		// LDAP-style "{id}" prefixes are only used if registered
+		if i := strings.IndexByte(hash, '}'); i > 1 {
+			if _, ok := r.loadHash(hash[:i+1]); ok {
+				return hash[:i+1], true
+			}
+		}
+	}
+	return "", true
+}
+
+// Check is like the package-level Check but uses the hashes of the registry.
+// Returns ErrHashNotAllowed if the hash is registered but not allowed,
+// or ErrNotApproved if the hash is not approved in FIPS 140 mode.
+//
+// The check is reported to the hook once, with the algorithm and parameters of the registered hash,
+// or as the "crypt" algorithm if the hash is unknown.
+func (r *Registry) Check(hash, password string) error {
+	id, h, ok := r.lookupHash(hash)
+	if !ok {
+		return ObserveCheck("crypt", hash, password, func(string, string) error {
+			return ErrHash
+		}, nil)
+	}
+	return ObserveCheck(h.Algorithm, hash, password, func(hash, password string) error {
+		return r.checkHash(id, h, hash, password)
+	}, h.Params)
+}
+
+// lookupHash returns the registered hash and its prefix,
+// or its algorithm if it's matched by a matcher.
+func (r *Registry) lookupHash(hash string) (id string, h *Hash, ok bool) {
+	prefix, ok := r.hashPrefix(hash)
+	if !ok {
+		return "", nil, false
+	}
+	if prefix == "" {
+		if h, ok := r.matchHash(hash); ok {
+			return h.Algorithm, h, true
+		}
+	}
+	h, ok = r.loadHash(prefix)
+	return prefix, h, ok
+}
+
+// check is like Check but doesn't report to the hook.
+func (r *Registry) check(hash, password string) error {
+	id, h, ok := r.lookupHash(hash)
+	if !ok {
+		return ErrHash
+	}
+	return r.checkHash(id, h, hash, password)
+}
+
+func (r *Registry) checkHash(id string, h *Hash, hash, password string) error {
+	if !r.allowed(id) {
+		return ErrHashNotAllowed
+	}
+	if !h.Approved && cryptoutil.FIPS140Enabled() {
+		return ErrNotApproved
+	}
+	return h.Check(hash, password)
+}
+
+// CheckOrDummy is like the package-level CheckOrDummy but uses the hashes and methods of the registry.
+func (r *Registry) CheckOrDummy(hash, password string, policy Policy) error {
+	err := r.Check(hash, password)
+	if err == nil || errors.Is(err, ErrPasswordMismatch) {
+		return err
+	}
+	if dummy, dummyErr := r.dummyHash(policy); dummyErr == nil {
+		// The dummy check isn't reported to the hook
+		r.check(dummy, password)
+	}
+	return err
+}
+
+// PrepareDummy is like the package-level PrepareDummy but uses the methods of the registry.
+func (r *Registry) PrepareDummy(policy Policy) error {
+	_, err := r.dummyHash(policy)
+	return err
+}
+
+// dummyHash returns the cached hash of a random password produced with the policy.
+func (r *Registry) dummyHash(policy Policy) (string, error) {
+	if hash, ok := r.dummies.Load(policy); ok {
+		return hash.(string), nil
+	}
+	setting, err := r.GenSalt(policy.Prefix, policy.Count, nil)
+	if err != nil {
+		return "", err
+	}
+	hash, err := r.Crypt(hex.EncodeToString(cryptoutil.Rand(16)), setting)
+	if err != nil {
+		return "", err
+	}
+	v, _ := r.dummies.LoadOrStore(policy, hash)
+	return v.(string), nil
+}
+
+// Crypt is like the package-level Crypt but uses the methods of the registry.
+// Returns ErrHashNotAllowed if the method is registered but not allowed,
+// or ErrNotApproved if the method is not approved in FIPS 140 mode.
+func (r *Registry) Crypt(password, setting string) (string, error) {
+	prefix, ok := r.hashPrefix(setting)
+	if !ok {
+		return "", ErrHash
+	}
+	m, ok := r.loadMethod(prefix)
+	if !ok {
+		return "", ErrHash
+	}
+	if !r.allowed(prefix) {
+		return "", ErrHashNotAllowed
+	}
+	if !m.Approved && cryptoutil.FIPS140Enabled() {
+		return "", ErrNotApproved
+	}
+	return m.Crypt(password, setting)
+}
+
+// GenSalt is like the package-level GenSalt but uses the methods of the registry.
+// Returns ErrHashNotAllowed if the method is registered but not allowed,
+// or ErrNotApproved if the method is not approved in FIPS 140 mode.
+func (r *Registry) GenSalt(prefix string, count uint64, rbytes []byte) (string, error) {
+	m, ok := r.loadMethod(prefix)
+	if !ok {
+		return "", ErrHash
+	}
+	if !r.allowed(prefix) {
+		return "", ErrHashNotAllowed
+	}
+	if !m.Approved && cryptoutil.FIPS140Enabled() {
+		return "", ErrNotApproved
+	}
+	if rbytes == nil {
+		rbytes = cryptoutil.Rand(MinRandomBytes)
+	}
+	if n := len(rbytes); n < MinRandomBytes {
+		return "", InvalidRandomBytesLengthError(n)
+	}
+	return m.GenSalt(count, rbytes)
+}
+
+// CheckSalt is like the package-level CheckSalt but uses the methods of the registry.
+// Settings of registered but not allowed methods,
+// and of not approved methods in FIPS 140 mode, are reported as SaltMethodDisabled.
+func (r *Registry) CheckSalt(setting string) SaltStatus {
+	prefix, ok := r.hashPrefix(setting)
+	if !ok || prefix == "*" {
+		return SaltInvalid
+	}
+	m, ok := r.loadMethod(prefix)
+	if !ok {
+		if _, ok := r.loadHash(prefix); ok && prefix != "" {
+			return SaltMethodDisabled
+		}
+		return SaltInvalid
+	}
+	if !r.allowed(prefix) || (!m.Approved && cryptoutil.FIPS140Enabled()) {
+		return SaltMethodDisabled
+	}
+	if err := m.CheckSetting(setting); err != nil {
+		return SaltInvalid
+	}
+	if m.Legacy {
+		return SaltMethodLegacy
+	}
+	return SaltOK
+}
@@ -0,0 +1,92 @@
+package crypt
+
+import "strconv"
+
+// Method is a hashing method that produces hashes from settings,
+// the hash strings without the sum, as crypt(3) does.
+type Method struct {
+	// Crypt returns the hash of the password using the prefix, parameters and salt
+	// of the setting, which is either a full hash or a hash without the sum.
+	Crypt func(password, setting string) (string, error)
+
+	// GenSalt returns a new setting with the method-specific count
+	// and the salt derived from the random bytes.
+	// If count is 0, the default value is used.
+	// Out of range counts are clamped to the method limits.
+	GenSalt func(count uint64, rbytes []byte) (string, error)
+
+	// CheckSetting returns an error if the setting is not valid.
+	CheckSetting func(setting string) error
+
+	// Legacy reports whether the method is considered weak
+	// and shouldn't be used for new hashes.
+	Legacy bool
+
+	// Approved reports whether the method is approved by FIPS 140,
+	// so it can be used in FIPS 140 mode.
+	Approved bool
+}
+
+// RegisterMethod registers a hashing method in DefaultRegistry for use by Crypt, GenSalt and CheckSalt.
+// Prefix is a prefix that identifies the hash, as passed to RegisterHash.
+func RegisterMethod(prefix string, method *Method) {
+	DefaultRegistry.RegisterMethod(prefix, method)
+}
+
+// Crypt returns the hash of the password using the setting,
+// which is either a full hash or a setting returned by GenSalt,
+// like the crypt(3) function.
+func Crypt(password, setting string) (string, error) {
+	return DefaultRegistry.Crypt(password, setting)
+}
+
+// MinRandomBytes is the minimum length of the random bytes passed to GenSalt.
+const MinRandomBytes = 16
+
+// InvalidRandomBytesLengthError values describe errors resulting from an invalid length of random bytes.
+type InvalidRandomBytesLengthError int
+
+func (e InvalidRandomBytesLengthError) Error() string {
+	return "invalid random bytes length " + strconv.FormatInt(int64(e), 10)
+}
+
+// GenSalt returns a new setting for the hashing method identified by the prefix,
+// like the crypt_gensalt(3) function.
+// The count is the method-specific cost parameter, like rounds,
+// or 0 to use the method default.
+// The salt is derived from rbytes, which must be at least MinRandomBytes long.
+// If rbytes is nil, cryptographically secure random bytes are used.
+func GenSalt(prefix string, count uint64, rbytes []byte) (string, error) {
+	return DefaultRegistry.GenSalt(prefix, count, rbytes)
+}
+
+// SaltStatus is the result of CheckSalt.
+type SaltStatus int
+
+const (
+	SaltOK             SaltStatus = iota // the setting is valid and its method is recommended
+	SaltInvalid                          // the setting is not valid or its method is unknown
+	SaltMethodDisabled                   // the method of the setting can only be checked with Check
+	SaltMethodLegacy                     // the setting is valid but its method is too weak
+)
+
+func (s SaltStatus) String() string {
+	switch s {
+	case SaltOK:
+		return "OK"
+	case SaltInvalid:
+		return "INVALID"
+	case SaltMethodDisabled:
+		return "METHOD_DISABLED"
+	case SaltMethodLegacy:
+		return "METHOD_LEGACY"
+	default:
+		return "SaltStatus(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// CheckSalt reports whether the setting can be used with Crypt,
+// like the crypt_checksalt(3) function.
+func CheckSalt(setting string) SaltStatus {
+	return DefaultRegistry.CheckSalt(setting)
+}
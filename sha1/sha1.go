@@ -7,6 +7,7 @@ import (
 	"crypto/sha1"
 	"crypto/subtle"
 	"encoding/binary"
+	"log/slog"
 	"strconv"
 
 	"github.com/sergeymakinen/go-crypt"
@@ -71,19 +72,30 @@ const Prefix = "$sha1$"
 
 var prefixBytes = []byte(Prefix)
 
-// Key returns a SHA-1 key derived from the password, salt and rounds.
-func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+func validateParams(salt []byte, rounds uint32) error {
 	if n := len(salt); n > MaxSaltLength {
-		return nil, InvalidSaltLengthError(n)
+		return InvalidSaltLengthError(n)
 	}
 	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
-		return nil, InvalidSaltError(salt[i])
+		return InvalidSaltError(salt[i])
+	}
+	if rounds < MinRounds {
+		return InvalidRoundsError(rounds)
+	}
+	return nil
+}
+
+// Key returns a SHA-1 key derived from the password, salt and rounds.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
 	}
 	if rounds == RandomRounds {
 		rounds = randRounds()
 	}
-	if rounds < MinRounds {
-		return nil, InvalidRoundsError(rounds)
+	if err := validateParams(salt, rounds); err != nil {
+		return nil, err
 	}
 	h := hmac.New(sha1.New, password)
 	h.Write(salt)
@@ -125,8 +137,37 @@ type scheme struct {
 	Sum        [sumLength]byte
 }
 
+// setting is a hash string without the sum.
+type setting struct {
+	HashPrefix hashPrefix
+	Rounds     uint32
+	Salt       []byte
+}
+
+func parseSetting(s string) (*setting, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &setting{
+			HashPrefix: scheme.HashPrefix,
+			Rounds:     scheme.Rounds,
+			Salt:       scheme.Salt,
+		}, nil
+	}
+	var setting setting
+	if err := crypthash.Unmarshal(s, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
 // NewHash returns the crypt(3) SHA-1 hash of the password with the given rounds.
 func NewHash(password string, rounds uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, rounds)
+	})
+}
+
+func newHash(password string, rounds uint32) (string, error) {
 	if rounds == RandomRounds {
 		rounds = randRounds()
 	}
@@ -153,9 +194,28 @@ func Params(hash string) (salt []byte, rounds uint32, err error) {
 	return scheme.Salt, scheme.Rounds, nil
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "sha1",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the rounds of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, rounds, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.Uint64("rounds", uint64(rounds))}
+}
+
 // Check compares the given crypt(3) SHA-1 hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -172,6 +232,52 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return "", err
+	}
+	key, err := Key([]byte(password), setting.Salt, setting.Rounds)
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{
+		HashPrefix: setting.HashPrefix,
+		Rounds:     setting.Rounds,
+		Salt:       setting.Salt,
+	}
+	crypthash.LittleEndianEncoding.Encode(scheme.Sum[:], key)
+	return crypthash.Marshal(scheme)
+}
+
+// genSalt returns a setting with the count rounds,
+// or rounds randomized around 24680 from rbytes if 0.
+func genSalt(count uint64, rbytes []byte) (string, error) {
+	setting := setting{
+		HashPrefix: Prefix,
+		Rounds:     uint32(min(max(count, MinRounds), RandomRounds-1)),
+		Salt:       []byte(crypthash.LittleEndianEncoding.EncodeToString(rbytes[:6])),
+	}
+	if count == 0 {
+		setting.Rounds = randomHint - (binary.BigEndian.Uint32(rbytes[6:10]) % (randomHint / 4))
+	}
+	return crypthash.Marshal(setting)
+}
+
+func checkSetting(s string) error {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return err
+	}
+	return validateParams(setting.Salt, setting.Rounds)
+}
+
 func init() {
-	crypt.RegisterHash(Prefix, Check)
+	crypt.Register(Prefix, hashAlgorithm)
+	crypt.RegisterMethod(Prefix, &crypt.Method{
+		Crypt:        cryptSetting,
+		GenSalt:      genSalt,
+		CheckSetting: checkSetting,
+		Legacy:       true,
+	})
 }
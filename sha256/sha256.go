@@ -5,6 +5,7 @@ import (
 	"crypto"
 	_ "crypto/sha256"
 	"crypto/subtle"
+	"log/slog"
 	"strconv"
 
 	"github.com/sergeymakinen/go-crypt"
@@ -46,30 +47,25 @@ func (e InvalidRoundsError) Error() string {
 	return "invalid round count " + strconv.FormatUint(uint64(e), 10)
 }
 
-// Permutation table for final digest.
-var permFinal = [32]byte{
-	20, 10, 0, 11,
-	1, 21, 2, 22,
-	12, 23, 13, 3,
-	14, 4, 24, 5,
-	25, 15, 26, 16,
-	6, 17, 7, 27,
-	8, 28, 18, 29,
-	19, 9, 30, 31,
-}
-
-// Key returns a SHA-256 key derived from the password, salt and rounds.
-func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+func validateParams(salt []byte, rounds uint32) error {
 	if n := len(salt); n > MaxSaltLength {
-		return nil, InvalidSaltLengthError(n)
+		return InvalidSaltLengthError(n)
 	}
 	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
-		return nil, InvalidSaltError(salt[i])
+		return InvalidSaltError(salt[i])
 	}
 	if rounds < MinRounds || rounds > MaxRounds {
-		return nil, InvalidRoundsError(rounds)
+		return InvalidRoundsError(rounds)
+	}
+	return nil
+}
+
+// Key returns a SHA-256 key derived from the password, salt and rounds.
+func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+	if err := validateParams(salt, rounds); err != nil {
+		return nil, err
 	}
-	return sha2crypt.Encrypt(crypto.SHA256, password, salt, rounds, permFinal[:])
+	return sha2crypt.Encrypt(crypto.SHA256, password, salt, rounds, sha2crypt.PermFinalSHA256[:])
 }
 
 const Prefix = "$5$"
@@ -100,8 +96,37 @@ type scheme struct {
 	Sum        [sumLength]byte
 }
 
+// setting is a hash string without the sum.
+type setting struct {
+	HashPrefix hashPrefix
+	Rounds     uint32 `hash:"param:rounds,omitempty"`
+	Salt       []byte
+}
+
+func parseSetting(s string) (*setting, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &setting{
+			HashPrefix: scheme.HashPrefix,
+			Rounds:     scheme.Rounds,
+			Salt:       scheme.Salt,
+		}, nil
+	}
+	var setting setting
+	if err := crypthash.Unmarshal(s, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
 // NewHash returns the crypt(3) SHA-256 hash of the password with the given rounds.
 func NewHash(password string, rounds uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, rounds)
+	})
+}
+
+func newHash(password string, rounds uint32) (string, error) {
 	scheme := scheme{
 		HashPrefix: Prefix,
 		Rounds:     rounds,
@@ -128,9 +153,29 @@ func Params(hash string) (salt []byte, rounds uint32, err error) {
 	return scheme.Salt, scheme.Rounds, nil
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "sha256",
+	Check:     check,
+	Params:    hookParams,
+	Approved:  true,
+}
+
+// hookParams returns the rounds of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, rounds, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.Uint64("rounds", uint64(rounds))}
+}
+
 // Check compares the given crypt(3) SHA-256 hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -150,6 +195,57 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return "", err
+	}
+	rounds := setting.Rounds
+	if rounds == 0 {
+		rounds = ImplicitRounds
+	}
+	key, err := Key([]byte(password), setting.Salt, rounds)
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{
+		HashPrefix: setting.HashPrefix,
+		Rounds:     setting.Rounds,
+		Salt:       setting.Salt,
+	}
+	crypthash.LittleEndianEncoding.Encode(scheme.Sum[:], key)
+	return crypthash.Marshal(scheme)
+}
+
+// genSalt returns a setting with the count rounds, or implicit rounds if 0.
+func genSalt(count uint64, rbytes []byte) (string, error) {
+	setting := setting{
+		HashPrefix: Prefix,
+		Salt:       []byte(crypthash.LittleEndianEncoding.EncodeToString(rbytes[:12])),
+	}
+	if count > 0 {
+		setting.Rounds = uint32(min(max(count, MinRounds), MaxRounds))
+	}
+	return crypthash.Marshal(setting)
+}
+
+func checkSetting(s string) error {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return err
+	}
+	if setting.Rounds == 0 {
+		setting.Rounds = ImplicitRounds
+	}
+	return validateParams(setting.Salt, setting.Rounds)
+}
+
 func init() {
-	crypt.RegisterHash(Prefix, Check)
+	crypt.Register(Prefix, hashAlgorithm)
+	crypt.RegisterMethod(Prefix, &crypt.Method{
+		Crypt:        cryptSetting,
+		GenSalt:      genSalt,
+		CheckSetting: checkSetting,
+		Approved:     true,
+	})
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
 	"github.com/sergeymakinen/go-crypt/internal/testutil"
 )
@@ -253,3 +254,78 @@ func TestNewHash(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyLongPassword(t *testing.T) {
+	key, err := Key([]byte("a very much longer text to encrypt.  This one even stretches over morethan one line."), []byte("anotherlongsalts"), 1400)
+	if err != nil {
+		t.Fatalf("Key() = _, %v; want nil", err)
+	}
+	if encKey, expected := crypthash.LittleEndianEncoding.EncodeToString(key), "Rx.j8H.h8HjEDGomFU8bDkXm3XIUnzyxf12oP84Bnq1"; encKey != expected {
+		t.Errorf("Key() = %q, _; want %q", encKey, expected)
+	}
+}
+
+func TestCrypt(t *testing.T) {
+	tests := []struct {
+		setting string
+		hash    string
+	}{
+		{
+			setting: "$5$rounds=1000$abc",
+			hash:    "$5$rounds=1000$abc$chB2229SaEAMndXolPyqp1RFge2UaeCAJVGEAvqr4M3",
+		},
+		{
+			setting: "$5$rounds=1000$abc$",
+			hash:    "$5$rounds=1000$abc$chB2229SaEAMndXolPyqp1RFge2UaeCAJVGEAvqr4M3",
+		},
+		{
+			setting: "$5$rounds=1000$abc$chB2229SaEAMndXolPyqp1RFge2UaeCAJVGEAvqr4M3",
+			hash:    "$5$rounds=1000$abc$chB2229SaEAMndXolPyqp1RFge2UaeCAJVGEAvqr4M3",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			hash, err := crypt.Crypt("password", test.setting)
+			if err != nil {
+				t.Fatalf("Crypt() = _, %v; want nil", err)
+			}
+			if hash != test.hash {
+				t.Errorf("Crypt() = %q, _; want %q", hash, test.hash)
+			}
+		})
+	}
+}
+
+func TestGenSalt(t *testing.T) {
+	tests := []struct {
+		count   uint64
+		setting string
+	}{
+		{
+			count:   0,
+			setting: "$5$k2XAnEHBqQ1Ct2aM",
+		},
+		{
+			count:   1,
+			setting: "$5$rounds=1000$k2XAnEHBqQ1Ct2aM",
+		},
+		{
+			count:   10000,
+			setting: "$5$rounds=10000$k2XAnEHBqQ1Ct2aM",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			setting, err := crypt.GenSalt(Prefix, test.count, []byte("0123456789abcdef"))
+			if err != nil {
+				t.Fatalf("GenSalt() = _, %v; want nil", err)
+			}
+			if setting != test.setting {
+				t.Errorf("GenSalt() = %q, _; want %q", setting, test.setting)
+			}
+			if status := crypt.CheckSalt(setting); status != crypt.SaltOK {
+				t.Errorf("CheckSalt() = %v; want %v", status, crypt.SaltOK)
+			}
+		})
+	}
+}
@@ -9,6 +9,38 @@ import (
 	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 )
 
+// Permutation tables for final digests of SHA-256 and SHA-512 crypt, passed to Encrypt.
+var (
+	PermFinalSHA256 = [32]byte{
+		20, 10, 0, 11,
+		1, 21, 2, 22,
+		12, 23, 13, 3,
+		14, 4, 24, 5,
+		25, 15, 26, 16,
+		6, 17, 7, 27,
+		8, 28, 18, 29,
+		19, 9, 30, 31,
+	}
+	PermFinalSHA512 = [64]byte{
+		42, 21, 0, 1,
+		43, 22, 23, 2,
+		44, 45, 24, 3,
+		4, 46, 25, 26,
+		5, 47, 48, 27,
+		6, 7, 49, 28,
+		29, 8, 50, 51,
+		30, 9, 10, 52,
+		31, 32, 11, 53,
+		54, 33, 12, 13,
+		55, 34, 35, 14,
+		56, 57, 36, 15,
+		16, 58, 37, 38,
+		17, 59, 60, 39,
+		18, 19, 61, 40,
+		41, 20, 62, 63,
+	}
+)
+
 // Encrypt performs raw SHA-2 family crypt calculation.
 func Encrypt(h crypto.Hash, password, salt []byte, rounds uint32, permutation []byte) ([]byte, error) {
 	switch h {
@@ -89,7 +121,7 @@ func sum(h crypto.Hash, bytes ...[]byte) []byte {
 func duplicate(h crypto.Hash, b []byte, n int) []byte {
 	r := make([]byte, 0, n)
 	var i int
-	for i = n; i >= h.Size(); i = -  h.Size() {
+	for i = n; i >= h.Size(); i -= h.Size() {
 		r = append(r, b[:h.Size()]...)
 	}
 	r = append(r, b[:i]...)
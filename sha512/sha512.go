@@ -5,6 +5,7 @@ import (
 	"crypto"
 	_ "crypto/sha512"
 	"crypto/subtle"
+	"log/slog"
 	"strconv"
 
 	"github.com/sergeymakinen/go-crypt"
@@ -46,38 +47,25 @@ func (e InvalidRoundsError) Error() string {
 	return "invalid round count " + strconv.FormatUint(uint64(e), 10)
 }
 
-// Permutation table for final digest.
-var permFinal = [64]byte{
-	42, 21, 0, 1,
-	43, 22, 23, 2,
-	44, 45, 24, 3,
-	4, 46, 25, 26,
-	5, 47, 48, 27,
-	6, 7, 49, 28,
-	29, 8, 50, 51,
-	30, 9, 10, 52,
-	31, 32, 11, 53,
-	54, 33, 12, 13,
-	55, 34, 35, 14,
-	56, 57, 36, 15,
-	16, 58, 37, 38,
-	17, 59, 60, 39,
-	18, 19, 61, 40,
-	41, 20, 62, 63,
-}
-
-// Key returns a SHA-512 key derived from the password, salt and rounds.
-func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+func validateParams(salt []byte, rounds uint32) error {
 	if n := len(salt); n > MaxSaltLength {
-		return nil, InvalidSaltLengthError(n)
+		return InvalidSaltLengthError(n)
 	}
 	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
-		return nil, InvalidSaltError(salt[i])
+		return InvalidSaltError(salt[i])
 	}
 	if rounds < MinRounds || rounds > MaxRounds {
-		return nil, InvalidRoundsError(rounds)
+		return InvalidRoundsError(rounds)
+	}
+	return nil
+}
+
+// Key returns a SHA-512 key derived from the password, salt and rounds.
+func Key(password, salt []byte, rounds uint32) ([]byte, error) {
+	if err := validateParams(salt, rounds); err != nil {
+		return nil, err
 	}
-	return sha2crypt.Encrypt(crypto.SHA512, password, salt, rounds, permFinal[:])
+	return sha2crypt.Encrypt(crypto.SHA512, password, salt, rounds, sha2crypt.PermFinalSHA512[:])
 }
 
 const Prefix = "$6$"
@@ -108,8 +96,37 @@ type scheme struct {
 	Sum        [sumLength]byte
 }
 
+// setting is a hash string without the sum.
+type setting struct {
+	HashPrefix hashPrefix
+	Rounds     uint32 `hash:"param:rounds,omitempty"`
+	Salt       []byte
+}
+
+func parseSetting(s string) (*setting, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &setting{
+			HashPrefix: scheme.HashPrefix,
+			Rounds:     scheme.Rounds,
+			Salt:       scheme.Salt,
+		}, nil
+	}
+	var setting setting
+	if err := crypthash.Unmarshal(s, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
 // NewHash returns the crypt(3) SHA-512 hash of the password with the given rounds.
 func NewHash(password string, rounds uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, rounds)
+	})
+}
+
+func newHash(password string, rounds uint32) (string, error) {
 	scheme := scheme{
 		HashPrefix: Prefix,
 		Rounds:     rounds,
@@ -136,9 +153,29 @@ func Params(hash string) (salt []byte, rounds uint32, err error) {
 	return scheme.Salt, scheme.Rounds, nil
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "sha512",
+	Check:     check,
+	Params:    hookParams,
+	Approved:  true,
+}
+
+// hookParams returns the rounds of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, rounds, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.Uint64("rounds", uint64(rounds))}
+}
+
 // Check compares the given crypt(3) SHA-512 hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -158,6 +195,57 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return "", err
+	}
+	rounds := setting.Rounds
+	if rounds == 0 {
+		rounds = ImplicitRounds
+	}
+	key, err := Key([]byte(password), setting.Salt, rounds)
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{
+		HashPrefix: setting.HashPrefix,
+		Rounds:     setting.Rounds,
+		Salt:       setting.Salt,
+	}
+	crypthash.LittleEndianEncoding.Encode(scheme.Sum[:], key)
+	return crypthash.Marshal(scheme)
+}
+
+// genSalt returns a setting with the count rounds, or implicit rounds if 0.
+func genSalt(count uint64, rbytes []byte) (string, error) {
+	setting := setting{
+		HashPrefix: Prefix,
+		Salt:       []byte(crypthash.LittleEndianEncoding.EncodeToString(rbytes[:12])),
+	}
+	if count > 0 {
+		setting.Rounds = uint32(min(max(count, MinRounds), MaxRounds))
+	}
+	return crypthash.Marshal(setting)
+}
+
+func checkSetting(s string) error {
+	setting, err := parseSetting(s)
+	if err != nil {
+		return err
+	}
+	if setting.Rounds == 0 {
+		setting.Rounds = ImplicitRounds
+	}
+	return validateParams(setting.Salt, setting.Rounds)
+}
+
 func init() {
-	crypt.RegisterHash(Prefix, Check)
+	crypt.Register(Prefix, hashAlgorithm)
+	crypt.RegisterMethod(Prefix, &crypt.Method{
+		Crypt:        cryptSetting,
+		GenSalt:      genSalt,
+		CheckSetting: checkSetting,
+		Approved:     true,
+	})
 }
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/sergeymakinen/go-crypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
 	"github.com/sergeymakinen/go-crypt/internal/testutil"
 )
 
@@ -253,3 +255,135 @@ func TestNewHash(t *testing.T) {
 		})
 	}
 }
+
+func TestCrypt(t *testing.T) {
+	tests := []struct {
+		setting string
+		hash    string
+	}{
+		{
+			setting: "$6$k2XAnEHBqQ1Ct2aM",
+			hash:    "$6$k2XAnEHBqQ1Ct2aM$ex5zZyYdY9DpUEGRk79A7sFHEG212AmKzMmu6esxm6V3MWglE9oe9uloWISKk5tSR.3dRXLqwFRwXn0HNmGHe.",
+		},
+		{
+			setting: "$6$k2XAnEHBqQ1Ct2aM$ex5zZyYdY9DpUEGRk79A7sFHEG212AmKzMmu6esxm6V3MWglE9oe9uloWISKk5tSR.3dRXLqwFRwXn0HNmGHe.",
+			hash:    "$6$k2XAnEHBqQ1Ct2aM$ex5zZyYdY9DpUEGRk79A7sFHEG212AmKzMmu6esxm6V3MWglE9oe9uloWISKk5tSR.3dRXLqwFRwXn0HNmGHe.",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			hash, err := crypt.Crypt("password", test.setting)
+			if err != nil {
+				t.Fatalf("Crypt() = _, %v; want nil", err)
+			}
+			if hash != test.hash {
+				t.Errorf("Crypt() = %q, _; want %q", hash, test.hash)
+			}
+		})
+	}
+}
+
+func TestGenSalt(t *testing.T) {
+	tests := []struct {
+		count   uint64
+		setting string
+	}{
+		{
+			count:   0,
+			setting: "$6$k2XAnEHBqQ1Ct2aM",
+		},
+		{
+			count:   1,
+			setting: "$6$rounds=1000$k2XAnEHBqQ1Ct2aM",
+		},
+		{
+			count:   10000,
+			setting: "$6$rounds=10000$k2XAnEHBqQ1Ct2aM",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			setting, err := crypt.GenSalt(Prefix, test.count, []byte("0123456789abcdef"))
+			if err != nil {
+				t.Fatalf("GenSalt() = _, %v; want nil", err)
+			}
+			if setting != test.setting {
+				t.Errorf("GenSalt() = %q, _; want %q", setting, test.setting)
+			}
+			if status := crypt.CheckSalt(setting); status != crypt.SaltOK {
+				t.Errorf("CheckSalt() = %v; want %v", status, crypt.SaltOK)
+			}
+		})
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	hash, err := NewHash("password", ImplicitRounds)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	if err = Check(hash, "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err = crypt.Check(hash, "password"); err != nil {
+		t.Errorf("crypt.Check() = %v; want nil", err)
+	}
+	if _, err = crypt.Crypt("password", hash); err != nil {
+		t.Errorf("Crypt() = _, %v; want nil", err)
+	}
+	if status := crypt.CheckSalt(hash); status != crypt.SaltOK {
+		t.Errorf("CheckSalt() = %v; want %v", status, crypt.SaltOK)
+	}
+}
+
+func TestHook(t *testing.T) {
+	var events []crypt.Event
+	crypt.SetHook(crypt.HookFunc(func(e crypt.Event) {
+		events = append(events, e)
+	}))
+	defer crypt.SetHook(nil)
+	hash, err := NewHash("password", MinRounds)
+	if err != nil {
+		t.Fatalf("NewHash() = _, %v; want nil", err)
+	}
+	Check(hash, "test")
+	Check("$6$rounds=1000$saltsalt$", "password")
+	tests := []struct {
+		op      crypt.Op
+		outcome crypt.Outcome
+		params  string
+	}{
+		{op: crypt.OpNewHash, outcome: crypt.OutcomeOK, params: "[rounds=1000]"},
+		{op: crypt.OpCheck, outcome: crypt.OutcomeMismatch, params: "[rounds=1000]"},
+		{op: crypt.OpCheck, outcome: crypt.OutcomeMalformed, params: "[]"},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("got %d events; want %d", len(events), len(tests))
+	}
+	for i, test := range tests {
+		e := events[i]
+		if e.Op != test.op || e.Algorithm != "sha512" {
+			t.Errorf("events[%d] = %v %q; want %v %q", i, e.Op, e.Algorithm, test.op, "sha512")
+		}
+		if e.Outcome != test.outcome {
+			t.Errorf("events[%d].Outcome = %v; want %v", i, e.Outcome, test.outcome)
+		}
+		if params := fmt.Sprint(e.Params); params != test.params {
+			t.Errorf("events[%d].Params = %s; want %s", i, params, test.params)
+		}
+	}
+}
+
+func TestKeyLongPassword(t *testing.T) {
+	key, err := Key(bytes.Repeat([]byte("a"), 70), []byte("saltsalt"), ImplicitRounds)
+	if err != nil {
+		t.Fatalf("Key() = _, %v; want nil", err)
+	}
+	if encKey, expected := crypthash.LittleEndianEncoding.EncodeToString(key), "5V6Phj3gZ8UvpCgziPIplxjenBzhC0EVCkTpjo4xE83r6/ymi41ClmoTnMIHlmU4mxZg31n3vSkmL05tDvpiG1"; encKey != expected {
+		t.Errorf("Key() = %q, _; want %q", encKey, expected)
+	}
+}
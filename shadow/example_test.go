@@ -0,0 +1,31 @@
+package shadow_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sergeymakinen/go-crypt/sha512"
+	"github.com/sergeymakinen/go-crypt/shadow"
+)
+
+func ExampleFile_SetPassword() {
+	dir, err := os.MkdirTemp("", "etc")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "shadow")
+	if err = os.WriteFile(name, []byte("root:*:19000:0:99999:7:::\n"), 0o640); err != nil {
+		panic(err)
+	}
+	f := &shadow.File{Path: name}
+	err = f.SetPassword("root", "password", func(password string) (string, error) {
+		return sha512.NewHash(password, sha512.ImplicitRounds)
+	})
+	fmt.Println(err)
+	fmt.Println(f.LockAccount("root"))
+	// Output:
+	// <nil>
+	// <nil>
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package shadow
+
+import (
+	"errors"
+	"os"
+)
+
+func lock(name string) (unlock func(), err error) {
+	return nil, errors.ErrUnsupported
+}
+
+func chown(f *os.File, fi os.FileInfo) error {
+	return nil
+}
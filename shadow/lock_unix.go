@@ -0,0 +1,60 @@
+//go:build unix
+
+package shadow
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+const lockRetryInterval = 100 * time.Millisecond
+
+// lock acquires an exclusive fcntl(2) record lock on the named file
+// the same way lckpwdf(3) does.
+func lock(name string) (unlock func(), err error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	lk := syscall.Flock_t{
+		Type:   syscall.F_WRLCK,
+		Whence: 0,
+	}
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		err = syscall.FcntlFlock(f.Fd(), syscall.F_SETLK, &lk)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EAGAIN) && !errors.Is(err, syscall.EACCES) && !errors.Is(err, syscall.EINTR) {
+			f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockRetryInterval)
+	}
+	return func() {
+		// Closing the file releases the lock
+		f.Close()
+	}, nil
+}
+
+func chown(f *os.File, fi os.FileInfo) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	tmp, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if tst, ok := tmp.Sys().(*syscall.Stat_t); ok && tst.Uid == st.Uid && tst.Gid == st.Gid {
+		return nil
+	}
+	return f.Chown(int(st.Uid), int(st.Gid))
+}
@@ -0,0 +1,237 @@
+// Package shadow implements the updating of password hashes in the shadow(5) file.
+//
+// Updates hold the lckpwdf(3) lock, preserve unrelated lines byte for byte
+// and replace the file atomically, so the package is suitable both for live systems
+// and for images built offline.
+package shadow
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPath = "/etc/shadow"
+	lockName    = ".pwd.lock"
+)
+
+// LockTimeout is the maximum time to wait for the lock, the same as lckpwdf(3) waits.
+const LockTimeout = 15 * time.Second
+
+// ErrLockTimeout is returned when the lock can't be acquired in LockTimeout.
+var ErrLockTimeout = errors.New("lock timeout")
+
+// ErrEmptyPassword is returned by UnlockAccount when unlocking would result
+// in an account without a password.
+var ErrEmptyPassword = errors.New("unlocking would result in an empty password")
+
+// UnknownUserError values describe errors resulting from a user not found in the shadow file.
+type UnknownUserError string
+
+func (e UnknownUserError) Error() string {
+	return "unknown user " + strconv.Quote(string(e))
+}
+
+// InvalidEntryError values describe errors resulting from a shadow entry
+// that can't be represented in the shadow file.
+type InvalidEntryError string
+
+func (e InvalidEntryError) Error() string {
+	return "invalid shadow entry: " + string(e)
+}
+
+// LineError records an error on a shadow file line.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Err.Error()
+}
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+const (
+	numFields = 9
+
+	fieldPassword   = 1
+	fieldLastChange = 2
+)
+
+// File is a shadow file.
+type File struct {
+	// Path is the shadow file path. If empty, DefaultPath is used.
+	Path string
+
+	// LockPath is the lock file path.
+	// If empty, the .pwd.lock file in the directory of the shadow file is used,
+	// which is /etc/.pwd.lock of lckpwdf(3) for DefaultPath.
+	LockPath string
+
+	// Now returns the time recorded as the last password change date.
+	// If nil, time.Now is used.
+	Now func() time.Time
+}
+
+func (f *File) path() string {
+	if f.Path == "" {
+		return DefaultPath
+	}
+	return f.Path
+}
+
+func (f *File) lockPath() string {
+	if f.LockPath == "" {
+		return filepath.Join(filepath.Dir(f.path()), lockName)
+	}
+	return f.LockPath
+}
+
+func (f *File) now() time.Time {
+	if f.Now == nil {
+		return time.Now()
+	}
+	return f.Now()
+}
+
+// SetHash sets the password hash of the user
+// and the last password change date to the current day.
+func (f *File) SetHash(user, hash string) error {
+	if hash == "" || strings.ContainsAny(hash, ":\r\n") {
+		return InvalidEntryError("hash is empty or contains a colon or a newline")
+	}
+	days := strconv.FormatInt(f.now().Unix()/(24*60*60), 10)
+	return f.update(user, func(fields []string) error {
+		fields[fieldPassword] = hash
+		fields[fieldLastChange] = days
+		return nil
+	})
+}
+
+// SetPassword sets the password hash of the user generated by newHash,
+// like the NewHash function of a hash package,
+// and the last password change date to the current day.
+func (f *File) SetPassword(user, password string, newHash func(password string) (string, error)) error {
+	hash, err := newHash(password)
+	if err != nil {
+		return err
+	}
+	return f.SetHash(user, hash)
+}
+
+// LockAccount locks the password of the user by prefixing the hash with "!", like usermod -L does.
+// Locking a locked account is a no-op.
+func (f *File) LockAccount(user string) error {
+	return f.update(user, func(fields []string) error {
+		if !strings.HasPrefix(fields[fieldPassword], "!") {
+			fields[fieldPassword] = "!" + fields[fieldPassword]
+		}
+		return nil
+	})
+}
+
+// UnlockAccount unlocks the password of the user by removing the "!" hash prefix, like usermod -U does.
+// Unlocking an unlocked account is a no-op.
+func (f *File) UnlockAccount(user string) error {
+	return f.update(user, func(fields []string) error {
+		if !strings.HasPrefix(fields[fieldPassword], "!") {
+			return nil
+		}
+		if fields[fieldPassword] == "!" {
+			return ErrEmptyPassword
+		}
+		fields[fieldPassword] = fields[fieldPassword][1:]
+		return nil
+	})
+}
+
+// mu serializes updates within the process as the lock is per process.
+var mu sync.Mutex
+
+// update locks the shadow file, applies fn to the fields of the user entry
+// and replaces the shadow file.
+func (f *File) update(user string, fn func(fields []string) error) error {
+	if user == "" || strings.ContainsAny(user, ":\r\n") {
+		return InvalidEntryError("user is empty or contains a colon or a newline")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	unlock, err := lock(f.lockPath())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	name := f.path()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	prefix := []byte(user + ":")
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, prefix) {
+			continue
+		}
+		content := bytes.TrimRight(line, "\r\n")
+		fields := strings.Split(string(content), ":")
+		if len(fields) != numFields {
+			return &LineError{Line: i + 1, Err: InvalidEntryError("expected " + strconv.Itoa(numFields) + " fields")}
+		}
+		if err := fn(fields); err != nil {
+			return err
+		}
+		lines[i] = append([]byte(strings.Join(fields, ":")), line[len(content):]...)
+		return replace(name, bytes.Join(lines, nil))
+	}
+	return UnknownUserError(user)
+}
+
+// replace atomically replaces the named file with b
+// keeping its permissions and ownership.
+func replace(name string, b []byte) error {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(name)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(name)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err = tmp.Chmod(fi.Mode().Perm()); err == nil {
+		err = chown(tmp, fi)
+	}
+	if err == nil {
+		_, err = tmp.Write(b)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func syncDir(name string) error {
+	d, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,216 @@
+package shadow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+	"github.com/sergeymakinen/go-crypt/sha512"
+)
+
+const testShadowFile = "root:*:19000:0:99999:7:::\n" +
+	"daemon:*:19000:0:99999:7:::\n" +
+	"user1:$6$aaa$I4qE52homEnm0Oc9OlL/XVQbfwhe2/m3vmS0y/a/hkTq01TU4NpqoPGWHKmDCHBpUO/htAXPrpsYE6v2zZon/.:19000:0:99999:7:::\r\n" +
+	"user10:!:19000::::::\n" +
+	"user2:!$6$aaa$I4qE52homEnm0Oc9OlL/XVQbfwhe2/m3vmS0y/a/hkTq01TU4NpqoPGWHKmDCHBpUO/htAXPrpsYE6v2zZon/.:19000:0:99999:7:::\n" +
+	"user3:!:19000:0:99999:7:::\n" +
+	"user4:broken\n" +
+	"user5::19000:0:99999:7:::"
+
+func newTestFile(t *testing.T) *File {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "shadow")
+	if err := os.WriteFile(name, []byte(testShadowFile), 0o640); err != nil {
+		t.Fatalf("WriteFile() = %v; want nil", err)
+	}
+	return &File{
+		Path: name,
+		Now: func() time.Time {
+			return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		},
+	}
+}
+
+func readTestFile(t *testing.T, f *File) string {
+	b, err := os.ReadFile(f.Path)
+	if err != nil {
+		t.Fatalf("ReadFile() = _, %v; want nil", err)
+	}
+	return string(b)
+}
+
+func TestSetHash(t *testing.T) {
+	tests := []struct {
+		user string
+		old  string
+		new  string
+	}{
+		{
+			user: "user1",
+			old:  "user1:$6$aaa$I4qE52homEnm0Oc9OlL/XVQbfwhe2/m3vmS0y/a/hkTq01TU4NpqoPGWHKmDCHBpUO/htAXPrpsYE6v2zZon/.:19000:0:99999:7:::\r\n",
+			new:  "user1:$1$aaa$sZbbxWYvlgYNZhB78yYjM0:19723:0:99999:7:::\r\n",
+		},
+		{
+			user: "user5",
+			old:  "user5::19000:0:99999:7:::",
+			new:  "user5:$1$aaa$sZbbxWYvlgYNZhB78yYjM0:19723:0:99999:7:::",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.user, func(t *testing.T) {
+			f := newTestFile(t)
+			if err := f.SetHash(test.user, "$1$aaa$sZbbxWYvlgYNZhB78yYjM0"); err != nil {
+				t.Fatalf("SetHash() = %v; want nil", err)
+			}
+			if s, expected := readTestFile(t, f), strings.Replace(testShadowFile, test.old, test.new, 1); s != expected {
+				t.Errorf("SetHash() = %q; want %q", s, expected)
+			}
+			fi, err := os.Stat(f.Path)
+			if err != nil {
+				t.Fatalf("Stat() = _, %v; want nil", err)
+			}
+			if mode := fi.Mode().Perm(); mode != 0o640 {
+				t.Errorf("Stat() = %v; want %v", mode, os.FileMode(0o640))
+			}
+			fi, err = os.Stat(filepath.Join(filepath.Dir(f.Path), ".pwd.lock"))
+			if err != nil {
+				t.Fatalf("Stat() = _, %v; want nil", err)
+			}
+			if mode := fi.Mode().Perm(); mode != 0o600 {
+				t.Errorf("Stat() = %v; want %v", mode, os.FileMode(0o600))
+			}
+		})
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	f := newTestFile(t)
+	err := f.SetPassword("user1", "test", func(password string) (string, error) {
+		return sha512.NewHash(password, sha512.ImplicitRounds)
+	})
+	if err != nil {
+		t.Fatalf("SetPassword() = %v; want nil", err)
+	}
+	for _, line := range strings.Split(readTestFile(t, f), "\n") {
+		if fields := strings.Split(line, ":"); fields[0] == "user1" {
+			if err = sha512.Check(fields[1], "test"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			return
+		}
+	}
+	t.Error("SetPassword() removed the entry")
+}
+
+func TestLockAccount(t *testing.T) {
+	tests := []struct {
+		user string
+		old  string
+		new  string
+	}{
+		{
+			user: "user1",
+			old:  "user1:$6$",
+			new:  "user1:!$6$",
+		},
+		{
+			user: "user2",
+			old:  "user2:!$6$",
+			new:  "user2:!$6$",
+		},
+		{
+			user: "user5",
+			old:  "user5::",
+			new:  "user5:!:",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.user, func(t *testing.T) {
+			f := newTestFile(t)
+			if err := f.LockAccount(test.user); err != nil {
+				t.Fatalf("LockAccount() = %v; want nil", err)
+			}
+			if s, expected := readTestFile(t, f), strings.Replace(testShadowFile, test.old, test.new, 1); s != expected {
+				t.Errorf("LockAccount() = %q; want %q", s, expected)
+			}
+		})
+	}
+}
+
+func TestUnlockAccount(t *testing.T) {
+	tests := []struct {
+		user string
+		old  string
+		new  string
+	}{
+		{
+			user: "user1",
+			old:  "user1:$6$",
+			new:  "user1:$6$",
+		},
+		{
+			user: "user2",
+			old:  "user2:!$6$",
+			new:  "user2:$6$",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.user, func(t *testing.T) {
+			f := newTestFile(t)
+			if err := f.UnlockAccount(test.user); err != nil {
+				t.Fatalf("UnlockAccount() = %v; want nil", err)
+			}
+			if s, expected := readTestFile(t, f), strings.Replace(testShadowFile, test.old, test.new, 1); s != expected {
+				t.Errorf("UnlockAccount() = %q; want %q", s, expected)
+			}
+		})
+	}
+}
+
+func TestShouldFail(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(f *File) error
+		err  error
+	}{
+		{
+			name: "unknown user",
+			fn:   func(f *File) error { return f.SetHash("user", "*") },
+			err:  UnknownUserError("user"),
+		},
+		{
+			name: "invalid user",
+			fn:   func(f *File) error { return f.LockAccount("user1:") },
+			err:  InvalidEntryError("user is empty or contains a colon or a newline"),
+		},
+		{
+			name: "invalid hash",
+			fn:   func(f *File) error { return f.SetHash("user1", "$1$aaa:") },
+			err:  InvalidEntryError("hash is empty or contains a colon or a newline"),
+		},
+		{
+			name: "invalid entry",
+			fn:   func(f *File) error { return f.SetHash("user4", "*") },
+			err:  &LineError{Line: 7, Err: InvalidEntryError("expected 9 fields")},
+		},
+		{
+			name: "empty password",
+			fn:   func(f *File) error { return f.UnlockAccount("user3") },
+			err:  ErrEmptyPassword,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newTestFile(t)
+			if err := test.fn(f); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("%s = %v; want %v", test.name, err, test.err)
+			}
+			if s := readTestFile(t, f); s != testShadowFile {
+				t.Errorf("file = %q; want %q", s, testShadowFile)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package spring_test
+
+import (
+	"fmt"
+
+	"github.com/sergeymakinen/go-crypt/spring"
+)
+
+func ExampleSplit() {
+	id, encoded, _ := spring.Split("{bcrypt}$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG")
+	fmt.Println(id)
+	fmt.Println(encoded)
+	// Output:
+	// bcrypt
+	// $2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG
+}
+
+func ExampleCheck() {
+	hash := "{pbkdf2}5d923b44a6d129f3ddf3e3c8d29412723dcbde72445e8ef6bf3b508fbf17fa4ed4d6b99ca763d8dc"
+	fmt.Println(spring.Check(hash, "password"))
+	fmt.Println(spring.Check(hash, "test"))
+	// Output:
+	// <nil>
+	// hash and password mismatch
+}
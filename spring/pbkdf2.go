@@ -0,0 +1,172 @@
+package spring
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"log/slog"
+	"strconv"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+// PBKDF2Algorithm is a SecretKeyFactory algorithm name of the Pbkdf2PasswordEncoder.
+type PBKDF2Algorithm string
+
+const (
+	PBKDF2WithHmacSHA1   PBKDF2Algorithm = "PBKDF2WithHmacSHA1"
+	PBKDF2WithHmacSHA256 PBKDF2Algorithm = "PBKDF2WithHmacSHA256"
+	PBKDF2WithHmacSHA512 PBKDF2Algorithm = "PBKDF2WithHmacSHA512"
+)
+
+// UnsupportedAlgorithmError values describe errors resulting from an unsupported algorithm.
+type UnsupportedAlgorithmError string
+
+func (e UnsupportedAlgorithmError) Error() string {
+	return "unsupported algorithm " + strconv.Quote(string(e))
+}
+
+// InvalidIterationsError values describe errors resulting from an invalid iteration count.
+type InvalidIterationsError int
+
+func (e InvalidIterationsError) Error() string {
+	return "invalid iteration count " + strconv.FormatInt(int64(e), 10)
+}
+
+// PBKDF2Options are the parameters of the Pbkdf2PasswordEncoder.
+type PBKDF2Options struct {
+	Algorithm  PBKDF2Algorithm
+	Secret     []byte
+	SaltLength int
+	Iterations int
+	HashWidth  int  // in bits
+	Base64     bool // encodeHashAsBase64
+}
+
+var (
+	// PBKDF2OptionsV55 are the parameters of Pbkdf2PasswordEncoder.defaultsForSpringSecurity_v5_5.
+	PBKDF2OptionsV55 = PBKDF2Options{
+		Algorithm:  PBKDF2WithHmacSHA1,
+		SaltLength: 8,
+		Iterations: 185000,
+		HashWidth:  256,
+	}
+
+	// PBKDF2OptionsV58 are the parameters of Pbkdf2PasswordEncoder.defaultsForSpringSecurity_v5_8.
+	PBKDF2OptionsV58 = PBKDF2Options{
+		Algorithm:  PBKDF2WithHmacSHA256,
+		SaltLength: 16,
+		Iterations: 310000,
+		HashWidth:  256,
+	}
+)
+
+// PBKDF2Key returns a Pbkdf2PasswordEncoder key derived from the password, raw salt and options.
+// The key doesn't include the salt.
+func PBKDF2Key(password, salt []byte, opts *PBKDF2Options) ([]byte, error) {
+	var h func() hash.Hash
+	switch opts.Algorithm {
+	case PBKDF2WithHmacSHA1:
+		h = sha1.New
+	case PBKDF2WithHmacSHA256:
+		h = sha256.New
+	case PBKDF2WithHmacSHA512:
+		h = sha512.New
+	default:
+		return nil, UnsupportedAlgorithmError(opts.Algorithm)
+	}
+	if opts.Iterations < 1 {
+		return nil, InvalidIterationsError(opts.Iterations)
+	}
+	return pbkdf2.Key(h, string(password), append(salt[:len(salt):len(salt)], opts.Secret...), opts.Iterations, opts.HashWidth/8)
+}
+
+func encode(b []byte, base64Encoded bool) string {
+	if base64Encoded {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return hex.EncodeToString(b)
+}
+
+func decode(s string, base64Encoded bool) ([]byte, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if base64Encoded {
+		b, err = base64.StdEncoding.DecodeString(s)
+	} else {
+		b, err = hex.DecodeString(s)
+	}
+	if err != nil {
+		return nil, &parse.SyntaxError{Offset: len(s), Msg: err.Error()}
+	}
+	return b, nil
+}
+
+// hookParams returns the algorithm and iteration count of the options for crypt.Hook.
+func (opts *PBKDF2Options) hookParams(string) []slog.Attr {
+	return []slog.Attr{
+		slog.String("algorithm", string(opts.Algorithm)),
+		slog.Int("iterations", opts.Iterations),
+	}
+}
+
+// NewPBKDF2 returns the Pbkdf2PasswordEncoder value of the password.
+//
+// The opts parameter is optional. If nil, PBKDF2OptionsV55 are used.
+func NewPBKDF2(password string, opts *PBKDF2Options) (string, error) {
+	if opts == nil {
+		opts = &PBKDF2OptionsV55
+	}
+	return crypt.ObserveNewHash("spring", func() (string, error) {
+		return newPBKDF2(password, opts)
+	}, opts.hookParams)
+}
+
+func newPBKDF2(password string, opts *PBKDF2Options) (string, error) {
+	salt := cryptoutil.Rand(opts.SaltLength)
+	key, err := PBKDF2Key([]byte(password), salt, opts)
+	if err != nil {
+		return "", err
+	}
+	return encode(append(salt, key...), opts.Base64), nil
+}
+
+// CheckPBKDF2 compares the given Pbkdf2PasswordEncoder value with a new value derived from the password.
+// Returns nil on success, or an error on failure.
+//
+// The opts parameter is optional. If nil, PBKDF2OptionsV55 are used.
+func CheckPBKDF2(encoded, password string, opts *PBKDF2Options) error {
+	if opts == nil {
+		opts = &PBKDF2OptionsV55
+	}
+	return crypt.ObserveCheck("spring", encoded, password, func(encoded, password string) error {
+		return checkPBKDF2(encoded, password, opts)
+	}, opts.hookParams)
+}
+
+func checkPBKDF2(encoded, password string, opts *PBKDF2Options) error {
+	b, err := decode(encoded, opts.Base64)
+	if err != nil {
+		return err
+	}
+	if len(b) != opts.SaltLength+opts.HashWidth/8 {
+		return &parse.SyntaxError{Offset: len(encoded), Msg: "length mismatch"}
+	}
+	key, err := PBKDF2Key([]byte(password), b[:opts.SaltLength], opts)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, b[opts.SaltLength:]) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
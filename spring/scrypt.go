@@ -0,0 +1,162 @@
+package spring
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"golang.org/x/crypto/scrypt"
+)
+
+// SCryptOptions are the parameters of the SCryptPasswordEncoder.
+type SCryptOptions struct {
+	CPUCost         int // N, a power of 2
+	MemoryCost      int // r
+	Parallelization int // p
+	KeyLength       int
+	SaltLength      int
+}
+
+var (
+	// SCryptOptionsV41 are the parameters of SCryptPasswordEncoder.defaultsForSpringSecurity_v4_1.
+	SCryptOptionsV41 = SCryptOptions{
+		CPUCost:         16384,
+		MemoryCost:      8,
+		Parallelization: 1,
+		KeyLength:       32,
+		SaltLength:      64,
+	}
+
+	// SCryptOptionsV58 are the parameters of SCryptPasswordEncoder.defaultsForSpringSecurity_v5_8.
+	SCryptOptionsV58 = SCryptOptions{
+		CPUCost:         65536,
+		MemoryCost:      8,
+		Parallelization: 1,
+		KeyLength:       32,
+		SaltLength:      16,
+	}
+)
+
+// InvalidSCryptParamsError values describe errors resulting from invalid SCrypt parameters.
+type InvalidSCryptParamsError string
+
+func (e InvalidSCryptParamsError) Error() string {
+	return "invalid scrypt parameters: " + string(e)
+}
+
+// hookParams returns the costs of the options for crypt.Hook.
+func (opts *SCryptOptions) hookParams(string) []slog.Attr {
+	return []slog.Attr{
+		slog.Int("cpu_cost", opts.CPUCost),
+		slog.Int("memory_cost", opts.MemoryCost),
+		slog.Int("parallelization", opts.Parallelization),
+	}
+}
+
+// NewSCrypt returns the SCryptPasswordEncoder value of the password.
+//
+// The opts parameter is optional. If nil, SCryptOptionsV41 are used.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func NewSCrypt(password string, opts *SCryptOptions) (string, error) {
+	if opts == nil {
+		opts = &SCryptOptionsV41
+	}
+	return crypt.ObserveNewHash("spring", func() (string, error) {
+		return newSCrypt(password, opts)
+	}, opts.hookParams)
+}
+
+func newSCrypt(password string, opts *SCryptOptions) (string, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return "", crypt.ErrNotApproved
+	}
+	if opts.CPUCost < 2 || opts.CPUCost&(opts.CPUCost-1) != 0 {
+		return "", InvalidSCryptParamsError("CPU cost must be a power of 2 greater than 1")
+	}
+	if opts.MemoryCost < 1 || opts.MemoryCost > 0xFF {
+		return "", InvalidSCryptParamsError("memory cost must be between 1 and 255")
+	}
+	if opts.Parallelization < 1 || opts.Parallelization > 0xFF {
+		return "", InvalidSCryptParamsError("parallelization must be between 1 and 255")
+	}
+	salt := cryptoutil.Rand(opts.SaltLength)
+	key, err := scrypt.Key([]byte(password), salt, opts.CPUCost, opts.MemoryCost, opts.Parallelization, opts.KeyLength)
+	if err != nil {
+		return "", InvalidSCryptParamsError(err.Error())
+	}
+	params := (bits.Len(uint(opts.CPUCost))-1)<<16 | opts.MemoryCost<<8 | opts.Parallelization
+	return "$" + strconv.FormatInt(int64(params), 16) +
+		"$" + base64.StdEncoding.EncodeToString(salt) +
+		"$" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// SCryptParams returns the options used to create the given SCryptPasswordEncoder value.
+func SCryptParams(encoded string) (*SCryptOptions, error) {
+	opts, _, _, err := parseSCrypt(encoded)
+	return opts, err
+}
+
+// $<params>$<salt>$<key>
+func parseSCrypt(encoded string) (opts *SCryptOptions, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "" {
+		return nil, nil, nil, &parse.SyntaxError{Offset: len(encoded), Msg: "invalid scrypt value"}
+	}
+	params, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil || params>>16 >= bits.UintSize-1 {
+		return nil, nil, nil, &parse.SyntaxError{Offset: 1 + len(parts[1]), Msg: "invalid scrypt parameters"}
+	}
+	if salt, err = base64.StdEncoding.DecodeString(parts[2]); err != nil {
+		return nil, nil, nil, &parse.SyntaxError{Offset: 2 + len(parts[1]) + len(parts[2]), Msg: "invalid salt"}
+	}
+	if key, err = base64.StdEncoding.DecodeString(parts[3]); err != nil || len(key) == 0 {
+		return nil, nil, nil, &parse.SyntaxError{Offset: len(encoded), Msg: "invalid key"}
+	}
+	return &SCryptOptions{
+		CPUCost:         1 << (params >> 16),
+		MemoryCost:      int(params >> 8 & 0xFF),
+		Parallelization: int(params & 0xFF),
+		KeyLength:       len(key),
+		SaltLength:      len(salt),
+	}, salt, key, nil
+}
+
+// scryptHookParams returns the costs of the value for crypt.Hook.
+func scryptHookParams(encoded string) []slog.Attr {
+	opts, err := SCryptParams(encoded)
+	if err != nil {
+		return nil
+	}
+	return opts.hookParams(encoded)
+}
+
+// CheckSCrypt compares the given SCryptPasswordEncoder value with a new value derived from the password.
+// Returns nil on success, or an error on failure.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func CheckSCrypt(encoded, password string) error {
+	return crypt.ObserveCheck("spring", encoded, password, checkSCrypt, scryptHookParams)
+}
+
+func checkSCrypt(encoded, password string) error {
+	if cryptoutil.FIPS140Enabled() {
+		return crypt.ErrNotApproved
+	}
+	opts, salt, key, err := parseSCrypt(encoded)
+	if err != nil {
+		return err
+	}
+	b, err := scrypt.Key([]byte(password), salt, opts.CPUCost, opts.MemoryCost, opts.Parallelization, opts.KeyLength)
+	if err != nil {
+		return InvalidSCryptParamsError(err.Error())
+	}
+	if subtle.ConstantTimeCompare(b, key) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
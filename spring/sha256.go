@@ -0,0 +1,81 @@
+package spring
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+const (
+	sha256SaltLength = 8
+	sha256Iterations = 1024
+)
+
+// SHA256Key returns a StandardPasswordEncoder key derived from the password, raw salt and secret.
+// The key doesn't include the salt.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func SHA256Key(password, salt, secret []byte) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
+	h := sha256.New()
+	h.Write(salt)
+	h.Write(secret)
+	h.Write(password)
+	sum := h.Sum(nil)
+	for i := 1; i < sha256Iterations; i++ {
+		h.Reset()
+		h.Write(sum)
+		sum = h.Sum(sum[:0])
+	}
+	return sum, nil
+}
+
+// NewSHA256 returns the StandardPasswordEncoder value of the password and secret.
+// The secret parameter is optional.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func NewSHA256(password string, secret []byte) (string, error) {
+	return crypt.ObserveNewHash("spring", func() (string, error) {
+		return newSHA256(password, secret)
+	}, nil)
+}
+
+func newSHA256(password string, secret []byte) (string, error) {
+	salt := cryptoutil.Rand(sha256SaltLength)
+	key, err := SHA256Key([]byte(password), salt, secret)
+	if err != nil {
+		return "", err
+	}
+	return encode(append(salt, key...), false), nil
+}
+
+// CheckSHA256 compares the given StandardPasswordEncoder value with a new value derived from the password and secret.
+// The secret parameter is optional.
+// Returns nil on success, or an error on failure.
+// Returns crypt.ErrNotApproved in FIPS 140 mode.
+func CheckSHA256(encoded, password string, secret []byte) error {
+	return crypt.ObserveCheck("spring", encoded, password, func(encoded, password string) error {
+		return checkSHA256(encoded, password, secret)
+	}, nil)
+}
+
+func checkSHA256(encoded, password string, secret []byte) error {
+	b, err := decode(encoded, false)
+	if err != nil {
+		return err
+	}
+	if len(b) != sha256SaltLength+sha256.Size {
+		return &parse.SyntaxError{Offset: len(encoded), Msg: "length mismatch"}
+	}
+	key, err := SHA256Key([]byte(password), b[:sha256SaltLength], secret)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, b[sha256SaltLength:]) == 0 {
+		return crypt.ErrPasswordMismatch
+	}
+	return nil
+}
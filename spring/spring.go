@@ -0,0 +1,202 @@
+// Package spring implements the password storage formats of the Spring Security
+// DelegatingPasswordEncoder: "{id}" prefixed values produced by the bcrypt, PBKDF2,
+// SCrypt, Argon2, StandardPasswordEncoder (sha256) and NoOpPasswordEncoder encoders.
+//
+// The bcrypt and Argon2 values are handled by the bcrypt and argon2 packages.
+package spring
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/argon2"
+	"github.com/sergeymakinen/go-crypt/bcrypt"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+)
+
+// Encoder ids of the default DelegatingPasswordEncoder.
+const (
+	IDBcrypt   = "bcrypt"
+	IDPBKDF2   = "pbkdf2"
+	IDPBKDF258 = "pbkdf2@SpringSecurity_v5_8"
+	IDSCrypt   = "scrypt"
+	IDSCrypt58 = "scrypt@SpringSecurity_v5_8"
+	IDArgon2   = "argon2"
+	IDArgon258 = "argon2@SpringSecurity_v5_8"
+	IDSHA256   = "sha256"
+	IDNoop     = "noop"
+)
+
+// UnsupportedPrefixError values describe errors resulting from an unsupported prefix string.
+type UnsupportedPrefixError string
+
+func (e UnsupportedPrefixError) Error() string {
+	return "unsupported prefix " + strconv.Quote(string(e))
+}
+
+// Split splits the given DelegatingPasswordEncoder value into the encoder id
+// and the encoded password.
+func Split(hash string) (id, encoded string, err error) {
+	if !strings.HasPrefix(hash, "{") {
+		return "", "", UnsupportedPrefixError("")
+	}
+	i := strings.IndexByte(hash, '}')
+	if i < 0 {
+		return "", "", UnsupportedPrefixError(hash[:min(len(hash), 1)])
+	}
+	return hash[1:i], hash[i+1:], nil
+}
+
+// Argon2Options are the parameters of the Argon2PasswordEncoder.
+type Argon2Options struct {
+	SaltLength int
+	Memory     uint32
+	Iterations uint32
+}
+
+var (
+	// Argon2OptionsV52 are the parameters of Argon2PasswordEncoder.defaultsForSpringSecurity_v5_2.
+	Argon2OptionsV52 = Argon2Options{
+		SaltLength: 16,
+		Memory:     1 << 12,
+		Iterations: 3,
+	}
+
+	// Argon2OptionsV58 are the parameters of Argon2PasswordEncoder.defaultsForSpringSecurity_v5_8.
+	Argon2OptionsV58 = Argon2Options{
+		SaltLength: 16,
+		Memory:     1 << 14,
+		Iterations: 2,
+	}
+)
+
+// hookParams returns the costs of the options for crypt.Hook.
+func (opts *Argon2Options) hookParams(string) []slog.Attr {
+	return []slog.Attr{
+		slog.Uint64("memory", uint64(opts.Memory)),
+		slog.Uint64("time", uint64(opts.Iterations)),
+	}
+}
+
+// NewArgon2 returns the Argon2PasswordEncoder value of the password.
+//
+// The opts parameter is optional. If nil, Argon2OptionsV52 are used.
+func NewArgon2(password string, opts *Argon2Options) (string, error) {
+	if opts == nil {
+		opts = &Argon2OptionsV52
+	}
+	return crypt.ObserveNewHash("spring", func() (string, error) {
+		return newArgon2(password, opts)
+	}, opts.hookParams)
+}
+
+func newArgon2(password string, opts *Argon2Options) (string, error) {
+	salt := []byte(base64.RawStdEncoding.EncodeToString(cryptoutil.Rand(opts.SaltLength)))
+	key, err := argon2.Key([]byte(password), salt, opts.Memory, opts.Iterations, argon2.DefaultThreads, nil)
+	if err != nil {
+		return "", err
+	}
+	return argon2.Prefix2id + "v=" + strconv.Itoa(argon2.Version13) +
+		"$m=" + strconv.FormatUint(uint64(opts.Memory), 10) +
+		",t=" + strconv.FormatUint(uint64(opts.Iterations), 10) +
+		",p=" + strconv.Itoa(argon2.DefaultThreads) +
+		"$" + string(salt) + "$" + base64.RawStdEncoding.EncodeToString(key), nil
+}
+
+// NewHash returns the DelegatingPasswordEncoder value of the password
+// produced by the encoder of the id with its default parameters.
+// The scrypt, SHA-256 and noop encoders return crypt.ErrNotApproved in FIPS 140 mode.
+func NewHash(id, password string) (string, error) {
+	return crypt.ObserveNewHash("spring", func() (string, error) {
+		return newHash(id, password)
+	}, hookParams)
+}
+
+func newHash(id, password string) (string, error) {
+	var (
+		s   string
+		err error
+	)
+	switch id {
+	case IDBcrypt:
+		s, err = bcrypt.NewHash(password, 10)
+	case IDPBKDF2:
+		s, err = newPBKDF2(password, &PBKDF2OptionsV55)
+	case IDPBKDF258:
+		s, err = newPBKDF2(password, &PBKDF2OptionsV58)
+	case IDSCrypt:
+		s, err = newSCrypt(password, &SCryptOptionsV41)
+	case IDSCrypt58:
+		s, err = newSCrypt(password, &SCryptOptionsV58)
+	case IDArgon2:
+		s, err = newArgon2(password, &Argon2OptionsV52)
+	case IDArgon258:
+		s, err = newArgon2(password, &Argon2OptionsV58)
+	case IDSHA256:
+		s, err = newSHA256(password, nil)
+	case IDNoop:
+		if cryptoutil.FIPS140Enabled() {
+			return "", crypt.ErrNotApproved
+		}
+		s = password
+	default:
+		return "", UnsupportedPrefixError("{" + id + "}")
+	}
+	if err != nil {
+		return "", err
+	}
+	return "{" + id + "}" + s, nil
+}
+
+// hookParams returns the encoder id of the value for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	id, _, err := Split(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.String("id", id)}
+}
+
+// Check compares the given DelegatingPasswordEncoder value with a new value derived from the password
+// using the default parameters of the encoder. Values produced with a secret
+// must be checked with CheckPBKDF2 or CheckSHA256.
+// Returns nil on success, or an error on failure.
+// The scrypt, SHA-256 and noop values return crypt.ErrNotApproved in FIPS 140 mode.
+func Check(hash, password string) error {
+	return crypt.ObserveCheck("spring", hash, password, check, hookParams)
+}
+
+func check(hash, password string) error {
+	id, encoded, err := Split(hash)
+	if err != nil {
+		return err
+	}
+	switch id {
+	case IDBcrypt:
+		return bcrypt.Check(encoded, password)
+	case IDPBKDF2:
+		return checkPBKDF2(encoded, password, &PBKDF2OptionsV55)
+	case IDPBKDF258:
+		return checkPBKDF2(encoded, password, &PBKDF2OptionsV58)
+	case IDSCrypt, IDSCrypt58:
+		return checkSCrypt(encoded, password)
+	case IDArgon2, IDArgon258:
+		return argon2.Check(encoded, password)
+	case IDSHA256:
+		return checkSHA256(encoded, password, nil)
+	case IDNoop:
+		if cryptoutil.FIPS140Enabled() {
+			return crypt.ErrNotApproved
+		}
+		if subtle.ConstantTimeCompare([]byte(encoded), []byte(password)) == 0 {
+			return crypt.ErrPasswordMismatch
+		}
+		return nil
+	default:
+		return UnsupportedPrefixError("{" + id + "}")
+	}
+}
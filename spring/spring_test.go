@@ -0,0 +1,216 @@
+package spring
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sergeymakinen/go-crypt"
+	"github.com/sergeymakinen/go-crypt/hash/parse"
+	"github.com/sergeymakinen/go-crypt/internal/cryptoutil"
+	"github.com/sergeymakinen/go-crypt/internal/testutil"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []string{
+		"{bcrypt}$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG",
+		"{pbkdf2}5d923b44a6d129f3ddf3e3c8d29412723dcbde72445e8ef6bf3b508fbf17fa4ed4d6b99ca763d8dc",
+		"{scrypt}$e0801$8bWJaSu2IKSn9Z9kM+TPXfOc/9bdYSrN1oD9qfVThWEwdRTnO7re7Ei+fUZRJ68k9lTyuTeUp4of4g24hHnazw==$OAOec05+bXxvuu/1qZ6NUR+xQYvYv7BeL1QxwRpY5Pc=",
+		"{argon2}$argon2id$v=19$m=4096,t=3,p=1$W0DGjjBizLNw/OmUaGbxTg$gdcFubw8oDqzBC5uc1BuMMRqfl3TPeZMQJHGIw8BxzQ",
+		"{sha256}97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc0",
+		"{noop}password",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			if err := Check(test, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+			if err := Check(test, "test"); err != crypt.ErrPasswordMismatch {
+				t.Errorf("Check() = %v; want %v", err, crypt.ErrPasswordMismatch)
+			}
+		})
+	}
+}
+
+func TestCheckShouldFail(t *testing.T) {
+	tests := []struct {
+		hash string
+		err  error
+	}{
+		{
+			hash: "password",
+			err:  UnsupportedPrefixError(""),
+		},
+		{
+			hash: "{bcryptpassword",
+			err:  UnsupportedPrefixError("{"),
+		},
+		{
+			hash: "{MD5}5f4dcc3b5aa765d61d8327deb882cf99",
+			err:  UnsupportedPrefixError("{MD5}"),
+		},
+		{
+			hash: "{pbkdf2}5d923b44a6d129f3ddf3e3c8d29412723dcbde72445e8ef6bf3b508fbf17fa4ed4d6b99ca763d8",
+			err:  &parse.SyntaxError{Offset: 78, Msg: "length mismatch"},
+		},
+		{
+			hash: "{sha256}97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc@",
+			err:  &parse.SyntaxError{Offset: 80, Msg: "encoding/hex: invalid byte: U+0040 '@'"},
+		},
+		{
+			hash: "{scrypt}$e0801$8bWJaSu2IKSn9Z9kM+TPXfOc",
+			err:  &parse.SyntaxError{Offset: 31, Msg: "invalid scrypt value"},
+		},
+		{
+			hash: "{scrypt}$e08@1$8bWJaSu2IKSn9Z9kM+TPXfOc$OAOec05+bXxvuu/1qZ6NUR+xQYvYv7BeL1QxwRpY5Pc=",
+			err:  &parse.SyntaxError{Offset: 6, Msg: "invalid scrypt parameters"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.err) {
+				t.Errorf("Check() = %v; want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckWithSecret(t *testing.T) {
+	opts := PBKDF2OptionsV58
+	opts.Secret = []byte("secret")
+	opts.Base64 = true
+	encoded, err := NewPBKDF2("password", &opts)
+	if err != nil {
+		t.Fatalf("NewPBKDF2() = _, %v; want nil", err)
+	}
+	if err := CheckPBKDF2(encoded, "password", &opts); err != nil {
+		t.Errorf("CheckPBKDF2() = %v; want nil", err)
+	}
+	opts.Secret = nil
+	if err := CheckPBKDF2(encoded, "password", &opts); err != crypt.ErrPasswordMismatch {
+		t.Errorf("CheckPBKDF2() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+
+	encoded, err = NewSHA256("password", []byte("secret"))
+	if err != nil {
+		t.Fatalf("NewSHA256() = _, %v; want nil", err)
+	}
+	if err := CheckSHA256(encoded, "password", []byte("secret")); err != nil {
+		t.Errorf("CheckSHA256() = %v; want nil", err)
+	}
+	if err := CheckSHA256(encoded, "password", nil); err != crypt.ErrPasswordMismatch {
+		t.Errorf("CheckSHA256() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+}
+
+func TestSCryptParams(t *testing.T) {
+	opts, err := SCryptParams("$e0801$8bWJaSu2IKSn9Z9kM+TPXfOc/9bdYSrN1oD9qfVThWEwdRTnO7re7Ei+fUZRJ68k9lTyuTeUp4of4g24hHnazw==$OAOec05+bXxvuu/1qZ6NUR+xQYvYv7BeL1QxwRpY5Pc=")
+	if err != nil {
+		t.Fatalf("SCryptParams() = _, %v; want nil", err)
+	}
+	if *opts != SCryptOptionsV41 {
+		t.Errorf("SCryptParams() = %+v, _; want %+v", *opts, SCryptOptionsV41)
+	}
+}
+
+func TestNewHash(t *testing.T) {
+	ids := []string{
+		IDBcrypt,
+		IDPBKDF2,
+		IDPBKDF258,
+		IDSCrypt,
+		IDSCrypt58,
+		IDArgon2,
+		IDArgon258,
+		IDSHA256,
+		IDNoop,
+	}
+	for _, id := range ids {
+		t.Run(id, func(t *testing.T) {
+			hash, err := NewHash(id, "password")
+			if err != nil {
+				t.Fatalf("NewHash() = _, %v; want nil", err)
+			}
+			if hashID, _, _ := Split(hash); hashID != id {
+				t.Errorf("Split() = %q, _, _; want %q", hashID, id)
+			}
+			if err := Check(hash, "password"); err != nil {
+				t.Errorf("Check() = %v; want nil", err)
+			}
+		})
+	}
+	if _, err := NewHash("MD5", "password"); !testutil.IsEqualError(err, UnsupportedPrefixError("{MD5}")) {
+		t.Errorf("NewHash() = _, %v; want %v", err, UnsupportedPrefixError("{MD5}"))
+	}
+}
+
+func TestHook(t *testing.T) {
+	var events []crypt.Event
+	crypt.SetHook(crypt.HookFunc(func(e crypt.Event) {
+		events = append(events, e)
+	}))
+	defer crypt.SetHook(nil)
+	if err := Check("{pbkdf2}5d923b44a6d129f3ddf3e3c8d29412723dcbde72445e8ef6bf3b508fbf17fa4ed4d6b99ca763d8dc", "password"); err != nil {
+		t.Errorf("Check() = %v; want nil", err)
+	}
+	if err := CheckPBKDF2("5d923b44a6d129f3ddf3e3c8d29412723dcbde72445e8ef6bf3b508fbf17fa4ed4d6b99ca763d8dc", "test", nil); err != crypt.ErrPasswordMismatch {
+		t.Errorf("CheckPBKDF2() = %v; want %v", err, crypt.ErrPasswordMismatch)
+	}
+	tests := []struct {
+		outcome crypt.Outcome
+		params  string
+	}{
+		{outcome: crypt.OutcomeMatch, params: "[id=pbkdf2]"},
+		{outcome: crypt.OutcomeMismatch, params: "[algorithm=PBKDF2WithHmacSHA1 iterations=185000]"},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("got %d events; want %d", len(events), len(tests))
+	}
+	for i, test := range tests {
+		e := events[i]
+		if e.Op != crypt.OpCheck || e.Algorithm != "spring" || e.Outcome != test.outcome {
+			t.Errorf("events[%d] = %v %q %v; want %v %q %v", i, e.Op, e.Algorithm, e.Outcome, crypt.OpCheck, "spring", test.outcome)
+		}
+		if params := fmt.Sprint(e.Params); params != test.params {
+			t.Errorf("events[%d].Params = %s; want %s", i, params, test.params)
+		}
+	}
+}
+
+func TestFIPS140(t *testing.T) {
+	defer func(enabled func() bool) {
+		cryptoutil.FIPS140Enabled = enabled
+	}(cryptoutil.FIPS140Enabled)
+	cryptoutil.FIPS140Enabled = func() bool { return true }
+	tests := []struct {
+		hash     string
+		expected error
+	}{
+		{
+			hash: "{pbkdf2}5d923b44a6d129f3ddf3e3c8d29412723dcbde72445e8ef6bf3b508fbf17fa4ed4d6b99ca763d8dc",
+		},
+		{
+			hash:     "{scrypt}$e0801$8bWJaSu2IKSn9Z9kM+TPXfOc/9bdYSrN1oD9qfVThWEwdRTnO7re7Ei+fUZRJ68k9lTyuTeUp4of4g24hHnazw==$OAOec05+bXxvuu/1qZ6NUR+xQYvYv7BeL1QxwRpY5Pc=",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "{sha256}97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc0",
+			expected: crypt.ErrNotApproved,
+		},
+		{
+			hash:     "{noop}password",
+			expected: crypt.ErrNotApproved,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.hash, func(t *testing.T) {
+			if err := Check(test.hash, "password"); !testutil.IsEqualError(err, test.expected) {
+				t.Errorf("Check() = %v; want %v", err, test.expected)
+			}
+		})
+	}
+	for _, id := range []string{IDSCrypt, IDSHA256, IDNoop} {
+		if _, err := NewHash(id, "password"); !testutil.IsEqualError(err, crypt.ErrNotApproved) {
+			t.Errorf("NewHash(%q) = _, %v; want %v", id, err, crypt.ErrNotApproved)
+		}
+	}
+}
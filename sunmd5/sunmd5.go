@@ -4,7 +4,9 @@ package sunmd5
 import (
 	"crypto/md5"
 	"crypto/subtle"
+	"log/slog"
 	"strconv"
+	"strings"
 
 	"github.com/sergeymakinen/go-crypt"
 	crypthash "github.com/sergeymakinen/go-crypt/hash"
@@ -138,21 +140,31 @@ var permFinal = [16]byte{
 
 var separator = ""
 
+func validateParams(salt []byte, rounds uint32) error {
+	if n := len(salt); n > MaxSaltLength {
+		return InvalidSaltLengthError(n)
+	}
+	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
+		return InvalidSaltError(salt[i])
+	}
+	if rounds > MaxRounds {
+		return InvalidRoundsError(rounds)
+	}
+	return nil
+}
+
 // Key returns a Sun MD5 key derived from the password, salt, rounds and compatibility options.
 //
 // The opts parameter is optional. If nil, default options are used.
 func Key(password, salt []byte, rounds uint32, opts *CompatibilityOptions) ([]byte, error) {
+	if cryptoutil.FIPS140Enabled() {
+		return nil, crypt.ErrNotApproved
+	}
 	if n := len(password); n > MaxPasswordLength {
 		return nil, InvalidPasswordLengthError(n)
 	}
-	if n := len(salt); n > MaxSaltLength {
-		return nil, InvalidSaltLengthError(n)
-	}
-	if i := hashutil.HashEncoding.IndexAnyInvalid(salt); i >= 0 {
-		return nil, InvalidSaltError(salt[i])
-	}
-	if rounds > MaxRounds {
-		return nil, InvalidRoundsError(rounds)
+	if err := validateParams(salt, rounds); err != nil {
+		return nil, err
 	}
 	if opts == nil {
 		opts = &CompatibilityOptions{}
@@ -222,8 +234,31 @@ type scheme struct {
 	Sum [sumLength]byte
 }
 
+// parseSetting parses a hash string or a salt string
+// used by Key with the optional trailing separator.
+func parseSetting(s string) (*saltScheme, error) {
+	var scheme scheme
+	if crypthash.Unmarshal(s, &scheme) == nil {
+		return &scheme.saltScheme, nil
+	}
+	var saltScheme saltScheme
+	if err := crypthash.Unmarshal(s, &saltScheme); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(s, "$") {
+		saltScheme.Separator = &separator
+	}
+	return &saltScheme, nil
+}
+
 // NewHash returns the crypt(3) Sun MD5 hash of the password with the given rounds.
 func NewHash(password string, rounds uint32) (string, error) {
+	return hashAlgorithm.ObserveNewHash(func() (string, error) {
+		return newHash(password, rounds)
+	})
+}
+
+func newHash(password string, rounds uint32) (string, error) {
 	scheme := scheme{saltScheme: saltScheme{
 		Rounds: rounds,
 		Salt:   hashutil.HashEncoding.Rand(DefaultSaltLength),
@@ -258,9 +293,28 @@ func Params(hash string) (salt []byte, rounds uint32, opts *CompatibilityOptions
 	}, nil
 }
 
+var hashAlgorithm = &crypt.Hash{
+	Algorithm: "sunmd5",
+	Check:     check,
+	Params:    hookParams,
+}
+
+// hookParams returns the rounds of the hash for crypt.Hook.
+func hookParams(hash string) []slog.Attr {
+	_, rounds, _, err := Params(hash)
+	if err != nil {
+		return nil
+	}
+	return []slog.Attr{slog.Uint64("rounds", uint64(rounds))}
+}
+
 // Check compares the given crypt(3) Sun MD5 hash with a new hash derived from the password.
 // Returns nil on success, or an error on failure.
 func Check(hash, password string) error {
+	return hashAlgorithm.ObserveCheck(hash, password)
+}
+
+func check(hash, password string) error {
 	var scheme scheme
 	if err := crypthash.Unmarshal(hash, &scheme); err != nil {
 		return err
@@ -280,7 +334,53 @@ func Check(hash, password string) error {
 	return nil
 }
 
+func cryptSetting(password, s string) (string, error) {
+	saltScheme, err := parseSetting(s)
+	if err != nil {
+		return "", err
+	}
+	key, err := Key([]byte(password), saltScheme.Salt, saltScheme.Rounds, &CompatibilityOptions{
+		Prefix:               string(saltScheme.HashPrefix),
+		DisableSaltSeparator: saltScheme.Separator == nil,
+	})
+	if err != nil {
+		return "", err
+	}
+	scheme := scheme{saltScheme: *saltScheme}
+	crypthash.LittleEndianEncoding.Encode(scheme.Sum[:], key)
+	return crypthash.Marshal(scheme)
+}
+
+// genSalt returns a setting with the count rounds, or BasicRounds if 0.
+func genSalt(count uint64, rbytes []byte) (string, error) {
+	saltScheme := saltScheme{
+		HashPrefix: PrefixNonZeroRounds,
+		Rounds:     uint32(min(count, MaxRounds)),
+		Salt:       []byte(crypthash.LittleEndianEncoding.EncodeToString(rbytes[:6])),
+		Separator:  &separator,
+	}
+	if count == 0 {
+		saltScheme.Rounds = BasicRounds
+	}
+	return crypthash.Marshal(saltScheme)
+}
+
+func checkSetting(s string) error {
+	saltScheme, err := parseSetting(s)
+	if err != nil {
+		return err
+	}
+	return validateParams(saltScheme.Salt, saltScheme.Rounds)
+}
+
 func init() {
-	crypt.RegisterHash(PrefixNonZeroRounds, Check)
-	crypt.RegisterHash(PrefixZeroRounds, Check)
+	for _, prefix := range []string{PrefixNonZeroRounds, PrefixZeroRounds} {
+		crypt.Register(prefix, hashAlgorithm)
+		crypt.RegisterMethod(prefix, &crypt.Method{
+			Crypt:        cryptSetting,
+			GenSalt:      genSalt,
+			CheckSetting: checkSetting,
+			Legacy:       true,
+		})
+	}
 }